@@ -2,8 +2,12 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -18,30 +22,119 @@ type App struct {
 	mu             sync.RWMutex
 	config         *Config
 	wnpServer      *media.WebNowPlayingServer
-	activePlayer   *media.Player
+	players        *media.PlayerHub // players of all sources and the active one
+	activePlayer   *media.Player    // as displayed, with optimistic changes
 	windowManager  *WindowManager
 	audioCapture   *media.AudioLevelCapture
 	trayManager    *TrayManager
 	autorunManager *AutorunManager
+	startedAt      time.Time
+
+	// controlledPlayerID receives media commands instead of the active player (0 = follow active)
+	controlledPlayerID int
+	// reportedPlayer is the active player as its source reported it. History and
+	// the integrations follow it, so rolled back commands never reach them.
+	reportedPlayer *media.Player
+
+	// preMuteVolume remembers each player's volume while it is muted
+	preMuteVolume map[int]int
+
+	// pending holds optimistic command results by event ID until the player confirms them
+	pendingMu  sync.Mutex
+	pending    map[string]*pendingCommand
+	pendingSeq uint64
+
+	recMu         sync.Mutex
+	recorder      *media.Recorder
+	replayCapture *media.AudioLevelCapture
+
+	positionTickRate chan int
+
+	// tracker follows the displayed player's listens for the history and scrobbling
+	tracker   *media.SessionTracker
+	history   *HistoryStore
+	scrobbler *media.Scrobbler
+
+	// lyrics of the displayed track and the line last emitted
+	lyricsProvider *media.LyricsProvider
+	lyricsMu       sync.Mutex
+	lyricsKey      string
+	lyrics         *media.Lyrics
+	lyricsLine     int
+
+	// hooks run user commands and webhooks on media events
+	hooks *hookRunner
+
+	// nowPlaying writes the displayed track to files for external tools
+	nowPlaying *nowPlayingWriter
+
+	// notifications announce track changes while the widget is covered by windows
+	notifications *trackNotifier
+
+	// mpris publishes the players on the D-Bus session bus (Linux only)
+	mpris *media.MPRISBridge
+
+	// mpd follows a Music Player Daemon while enabled
+	mpdMu sync.Mutex
+	mpd   *media.MPDSource
+
+	// mqtt publishes the displayed player to an MQTT broker while enabled
+	mqttMu    sync.Mutex
+	mqtt      *media.MQTTPublisher
+	mqttSound soundDetector
+
+	// osc forwards levels, beats and track changes to VJ and lighting software while enabled
+	oscMu sync.Mutex
+	osc   *media.OSCSender
+
+	// discord shows the displayed player as Discord Rich Presence while enabled
+	discordMu sync.Mutex
+	discord   *media.DiscordPresence
+
+	// covers stores cover images sent by the sources; players refer to them by key
+	covers *media.CoverCache
+
+	// assets is the built frontend, also served to OBS overlays
+	assets    fs.FS
+	overlayMu sync.Mutex
+	overlay   *overlayServer
 }
 
-// NewApp creates a new App application struct
-func NewApp() *App {
+// NewApp creates a new App application struct. assets is the built frontend
+// (the contents of frontend/dist).
+func NewApp(assets fs.FS) *App {
 	cfg := LoadConfig()
-	return &App{
-		config: cfg,
-	}
+	covers := media.NewCoverCache(filepath.Join(os.TempDir(), "round-sound", "covers"), media.DefaultCoverCacheSize)
+	a := &App{
+		config:           cfg,
+		assets:           assets,
+		covers:           covers,
+		pending:          make(map[string]*pendingCommand),
+		preMuteVolume:    make(map[int]int),
+		positionTickRate: make(chan int, 1),
+		lyricsProvider:   media.NewLyricsProvider(cfg.LyricsDirs),
+		hooks:            newHookRunner(cfg.Hooks, covers),
+		nowPlaying:       newNowPlayingWriter(cfg.NowPlaying, covers),
+		notifications:    newTrackNotifier(cfg.Notifications, covers),
+	}
+	a.tracker = media.NewSessionTracker(a.onSessionStart, a.onSessionUpdate, a.onSessionEnd)
+	a.players = media.NewPlayerHub(a.onPlayerUpdate)
+	a.players.SetClearedCallback(a.onPlayersCleared)
+	a.players.SetPlayersCallback(a.onPlayersChanged)
+	a.players.SetRules(cfg.PlayerRules)
+	return a
 }
 
 // Startup is called when the app starts
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
+	a.startedAt = time.Now()
 
 	// Initialize window manager
 	a.windowManager = NewWindowManager(ctx)
 
 	// Initialize and setup system tray
-	a.trayManager = NewTrayManager(ctx)
+	a.trayManager = NewTrayManager(ctx, a.ExecuteCommand)
 	a.trayManager.Setup()
 
 	// Initialize autorun manager
@@ -51,9 +144,43 @@ func (a *App) Startup(ctx context.Context) {
 		log.Printf("Failed to initialize autorun manager: %v", err)
 	}
 
+	// Load listening history before the first player update arrives
+	a.openHistory()
+	a.startScrobbler()
+
+	// Publish players to MPRIS clients (media keys, desktop widgets) where available
+	a.mpris, err = media.StartMPRISBridge(a.covers, a.executeCommandOn)
+	if err != nil && !errors.Is(err, media.ErrMPRISUnsupported) {
+		log.Printf("Failed to start MPRIS bridge: %v", err)
+	}
+
 	// Start WebNowPlaying server on configured port
 	a.startWNPServer(a.config.WNPPort)
 
+	// Follow native players (Spotify, mpv, VLC) next to the browser ones where available
+	if source, err := media.StartMPRISSource(); err == nil {
+		a.players.AddSource(source)
+	} else if !errors.Is(err, media.ErrMPRISUnsupported) {
+		log.Printf("Failed to start MPRIS source: %v", err)
+	}
+	a.startMPDSource()
+
+	// Publish to Home Assistant and other MQTT clients when enabled
+	a.startMQTT()
+
+	// Drive VJ and lighting software over OSC when enabled
+	if err := a.startOSC(); err != nil {
+		log.Printf("Failed to start OSC output: %v", err)
+	}
+
+	// Show the track in Discord when enabled
+	a.startDiscord()
+
+	// Serve the widget to OBS browser sources when enabled
+	if err := a.startOverlay(); err != nil {
+		log.Printf("Failed to start overlay server: %v", err)
+	}
+
 	// Start desktop-level window manager (HWND_BOTTOM)
 	go a.windowManager.StartDesktopLevelWatcher()
 
@@ -66,6 +193,12 @@ func (a *App) Startup(ctx context.Context) {
 	// Listen for audio configuration changes from frontend
 	runtime.EventsOn(ctx, "audio:config", a.onAudioConfigUpdate)
 
+	// Start media:position ticks (no-op until a rate is configured)
+	go a.runPositionTicker(a.config.PositionTickHz)
+
+	// Follow the position with the active lyric line
+	go a.runLyricsTicker()
+
 	log.Println("Round Sound started")
 }
 
@@ -98,6 +231,18 @@ func (a *App) Shutdown(ctx context.Context) {
 	a.config.WindowY = y
 	a.config.Save()
 
+	// Finish any recording/replay in progress
+	if a.GetRecordingStatus().Active {
+		a.StopRecording()
+	}
+	a.StopReplay()
+
+	// Record the track that is still playing
+	a.tracker.End(time.Now())
+	if a.scrobbler != nil {
+		a.scrobbler.Stop()
+	}
+
 	// Stop audio capture
 	if a.audioCapture != nil {
 		a.audioCapture.Stop()
@@ -108,9 +253,31 @@ func (a *App) Shutdown(ctx context.Context) {
 		a.trayManager.Remove()
 	}
 
-	// Stop WebNowPlaying server
-	if a.wnpServer != nil {
-		a.wnpServer.Stop()
+	// Stop WebNowPlaying server and the other player sources
+	a.players.Stop()
+	a.overlayMu.Lock()
+	if a.overlay != nil {
+		a.overlay.stop()
+	}
+	a.overlayMu.Unlock()
+	a.mqttMu.Lock()
+	if a.mqtt != nil {
+		a.mqtt.Stop()
+	}
+	a.mqttMu.Unlock()
+	a.oscMu.Lock()
+	if a.osc != nil {
+		a.osc.Close()
+	}
+	a.oscMu.Unlock()
+	a.discordMu.Lock()
+	if a.discord != nil {
+		a.discord.Stop()
+	}
+	a.discordMu.Unlock()
+	a.notifications.close()
+	if a.mpris != nil {
+		a.mpris.Close()
 	}
 
 	log.Println("Round Sound shutdown")
@@ -118,23 +285,95 @@ func (a *App) Shutdown(ctx context.Context) {
 
 // onPlayerUpdate is called when player state changes
 func (a *App) onPlayerUpdate(player *media.Player) {
+	// Hooks follow the real player state, not optimistic changes
+	a.hooks.observePlayer(player, time.Now())
+
+	// Keep unconfirmed optimistic changes visible until the player catches up
+	a.reconcile(player)
+	displayed := a.withPending(player)
+
 	a.mu.Lock()
-	a.activePlayer = player
+	a.activePlayer = displayed
+	a.reportedPlayer = player
 	a.mu.Unlock()
 
 	log.Printf("[App] Player updated: ID=%d, Title=%s, State=%d", player.ID, player.Title, player.State)
 
-	// Emit event to frontend
-	if a.ctx != nil {
-		runtime.EventsEmit(a.ctx, "media:update", player)
+	// History and integrations only see confirmed state
+	a.tracker.Update(player, time.Now())
+	a.nowPlaying.observePlayer(player)
+	a.publishMQTTPlayer(player)
+	a.sendOSCPlayer(player)
+	a.updateDiscord(player)
+	a.notifications.observePlayer(player)
+
+	// Emit event to frontend and overlays
+	a.updateLyricsTrack(displayed)
+	a.emitMedia("media:update", displayed)
+}
+
+// onSessionStart is called when the displayed player starts a new track
+func (a *App) onSessionStart(session media.PlaySession) {
+	if a.scrobbler != nil {
+		a.scrobbler.OnSessionStart(session)
 	}
 }
 
+// onSessionUpdate is called after every update of the running listen
+func (a *App) onSessionUpdate(session media.PlaySession) {
+	if a.scrobbler != nil {
+		a.scrobbler.OnSessionUpdate(session)
+	}
+}
+
+// onSessionEnd is called when a listen finishes
+func (a *App) onSessionEnd(session media.PlaySession) {
+	a.recordListen(session)
+	if a.scrobbler != nil {
+		a.scrobbler.OnSessionEnd(session)
+	}
+}
+
+// onPlayersCleared is called when the last player goes away
+func (a *App) onPlayersCleared() {
+	a.mu.Lock()
+	a.activePlayer = nil
+	a.reportedPlayer = nil
+	a.mu.Unlock()
+
+	log.Println("[App] Players cleared")
+
+	a.tracker.End(time.Now())
+	a.updateLyricsTrack(nil)
+	a.hooks.observePlayer(nil, time.Now())
+	a.nowPlaying.observePlayer(nil)
+	a.publishMQTTPlayer(nil)
+	a.sendOSCPlayer(nil)
+	a.updateDiscord(nil)
+
+	a.emitMedia("media:cleared")
+}
+
 // onAudioLevels is called when audio levels are captured
-func (a *App) onAudioLevels(levels []float32) {
-	if a.ctx != nil {
-		runtime.EventsEmit(a.ctx, "audio:levels", levels)
+func (a *App) onAudioLevels(frame media.LevelFrame) {
+	a.mu.RLock()
+	player := a.reportedPlayer
+	a.mu.RUnlock()
+	a.hooks.observeAudio(frame, player, time.Now())
+	a.publishMQTTAudio(frame, time.Now())
+
+	// Live levels are muted while a recording is replayed
+	if a.IsReplaying() {
+		return
 	}
+	a.emitAudioLevels(frame)
+}
+
+// emitAudioLevels forwards levels from the live capture or a replay to the UI and tray
+func (a *App) emitAudioLevels(frame media.LevelFrame) {
+	levels := frame.Levels
+	a.emitMedia("audio:levels", levels)
+	a.sendOSCLevels(frame)
 
 	// Determine if there's sound (threshold from AudioLevelsRays.vue)
 	const soundThreshold = 0.02
@@ -207,278 +446,132 @@ func (a *App) startPositionSaver() {
 	}
 }
 
-// GetCurrentPlayer returns the current active player state
+// GetCurrentPlayer returns the current active player state, with the position
+// estimated for now so pollers see it advance between updates
 func (a *App) GetCurrentPlayer() *media.Player {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
-	return a.activePlayer
+	return a.activePlayer.Snapshot(time.Now())
 }
 
 // --- Media Control Methods ---
 
-// MediaPlay sends play command to active player
-func (a *App) MediaPlay() error {
-	log.Println("[App] MediaPlay called")
-
+// ExecuteCommand validates a media command against the controlled player's
+// capabilities and sends it. It is the single entry point for the Wails
+// bindings, the tray menu and remote control surfaces.
+func (a *App) ExecuteCommand(cmd media.Command) error {
 	a.mu.RLock()
-	defer a.mu.RUnlock()
+	player := a.controlledPlayerLocked()
+	a.mu.RUnlock()
 
-	if a.wnpServer == nil {
-		log.Println("[App] MediaPlay: wnpServer is nil")
-		return nil
-	}
-	if a.activePlayer == nil {
-		log.Println("[App] MediaPlay: no active player")
-		return nil
+	if player == nil {
+		log.Printf("[App] %s: no active player", cmd)
+		return media.ErrNoPlayer
 	}
+	return a.sendCommand(player, cmd)
+}
 
-	log.Printf("[App] Sending STATE command (PLAYING) to player %d", a.activePlayer.ID)
-	err := a.wnpServer.SendCommand(a.activePlayer.ID, "STATE", 1) // 1 = PLAYING
-	if err != nil {
-		log.Printf("[App] MediaPlay error: %v", err)
+// executeCommandOn sends a media command to a specific player (MPRIS clients
+// address each player on its own)
+func (a *App) executeCommandOn(playerID int, cmd media.Command) error {
+	player := a.players.GetPlayer(playerID)
+	if player == nil {
+		return media.ErrNoPlayer
 	}
-	return err
+	return a.sendCommand(player, cmd)
 }
 
-// MediaPause sends pause command to active player
-func (a *App) MediaPause() error {
-	log.Println("[App] MediaPause called")
-
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-
-	if a.wnpServer == nil {
-		log.Println("[App] MediaPause: wnpServer is nil")
-		return nil
-	}
-	if a.activePlayer == nil {
-		log.Println("[App] MediaPause: no active player")
-		return nil
-	}
+// sendCommand dispatches cmd to player through its source and shows its expected result right away
+func (a *App) sendCommand(player *media.Player, cmd media.Command) error {
+	// Build on changes the player has not confirmed yet (e.g. repeated toggles)
+	player = a.withPending(player)
+	cmd, preMute := a.resolveRelative(player, cmd)
 
-	log.Printf("[App] Sending STATE command (PAUSED) to player %d", a.activePlayer.ID)
-	err := a.wnpServer.SendCommand(a.activePlayer.ID, "STATE", 2) // 2 = PAUSED
+	log.Printf("[App] Sending %s to player %d", cmd, player.ID)
+	eventID, err := media.Dispatch(a.players, player, cmd)
 	if err != nil {
-		log.Printf("[App] MediaPause error: %v", err)
+		log.Printf("[App] %s error: %v", cmd, err)
+		return err
 	}
-	return err
-}
+	a.applyPreMute(player.ID, preMute)
 
-// MediaTogglePlayPause toggles play/pause state
-func (a *App) MediaTogglePlayPause() error {
-	log.Println("[App] MediaTogglePlayPause called")
+	a.applyOptimistic(eventID, player, cmd)
+	return nil
+}
 
-	a.mu.RLock()
-	currentState := media.StateStopped
-	if a.activePlayer != nil {
-		currentState = a.activePlayer.State
-	}
-	a.mu.RUnlock()
+// MediaPlay sends play command to the controlled player
+func (a *App) MediaPlay() error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandPlay})
+}
 
-	if a.activePlayer == nil {
-		log.Println("[App] MediaTogglePlayPause: no active player")
-		return nil
-	}
+// MediaPause sends pause command to the controlled player
+func (a *App) MediaPause() error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandPause})
+}
 
-	if currentState == media.StatePlaying {
-		return a.MediaPause()
-	}
-	return a.MediaPlay()
+// MediaTogglePlayPause toggles play/pause state
+func (a *App) MediaTogglePlayPause() error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandTogglePlayPause})
 }
 
 // MediaNext sends next track command
 func (a *App) MediaNext() error {
-	log.Println("[App] MediaNext called")
-
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-
-	if a.wnpServer == nil {
-		log.Println("[App] MediaNext: wnpServer is nil")
-		return nil
-	}
-	if a.activePlayer == nil {
-		log.Println("[App] MediaNext: no active player")
-		return nil
-	}
-
-	log.Printf("[App] Sending SKIP_NEXT command to player %d", a.activePlayer.ID)
-	err := a.wnpServer.SendCommand(a.activePlayer.ID, "SKIP_NEXT", nil)
-	if err != nil {
-		log.Printf("[App] MediaNext error: %v", err)
-	}
-	return err
+	return a.ExecuteCommand(media.Command{Kind: media.CommandNext})
 }
 
 // MediaPrevious sends previous track command
 func (a *App) MediaPrevious() error {
-	log.Println("[App] MediaPrevious called")
-
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-
-	if a.wnpServer == nil {
-		log.Println("[App] MediaPrevious: wnpServer is nil")
-		return nil
-	}
-	if a.activePlayer == nil {
-		log.Println("[App] MediaPrevious: no active player")
-		return nil
-	}
-
-	log.Printf("[App] Sending SKIP_PREVIOUS command to player %d", a.activePlayer.ID)
-	err := a.wnpServer.SendCommand(a.activePlayer.ID, "SKIP_PREVIOUS", nil)
-	if err != nil {
-		log.Printf("[App] MediaPrevious error: %v", err)
-	}
-	return err
+	return a.ExecuteCommand(media.Command{Kind: media.CommandPrevious})
 }
 
 // MediaToggleShuffle toggles shuffle mode
 func (a *App) MediaToggleShuffle() error {
-	log.Println("[App] MediaToggleShuffle called")
-
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-
-	if a.wnpServer == nil {
-		log.Println("[App] MediaToggleShuffle: wnpServer is nil")
-		return nil
-	}
-	if a.activePlayer == nil {
-		log.Println("[App] MediaToggleShuffle: no active player")
-		return nil
-	}
-
-	newState := !a.activePlayer.Shuffle
-	var val int
-	if newState {
-		val = 1
-	}
-	log.Printf("[App] Sending SHUFFLE command to player %d (newState=%v)", a.activePlayer.ID, newState)
-	err := a.wnpServer.SendCommand(a.activePlayer.ID, "SHUFFLE", val)
-	if err != nil {
-		log.Printf("[App] MediaToggleShuffle error: %v", err)
-	}
-	return err
+	return a.ExecuteCommand(media.Command{Kind: media.CommandToggleShuffle})
 }
 
-// MediaToggleRepeat cycles through repeat modes
+// MediaToggleRepeat cycles through the repeat modes the player offers
 func (a *App) MediaToggleRepeat() error {
-	log.Println("[App] MediaToggleRepeat called")
-
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+	return a.ExecuteCommand(media.Command{Kind: media.CommandToggleRepeat})
+}
 
-	if a.wnpServer == nil {
-		log.Println("[App] MediaToggleRepeat: wnpServer is nil")
-		return nil
-	}
-	if a.activePlayer == nil {
-		log.Println("[App] MediaToggleRepeat: no active player")
-		return nil
-	}
+// MediaSetRating sets the raw track rating (0=none, 1=dislike, 5=like, or 0-5 stars)
+func (a *App) MediaSetRating(rating int) error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandSetRating, Value: rating})
+}
 
-	// Cycle: NONE(1) -> ALL(2) -> ONE(4) -> NONE(1)
-	var nextMode int
-	switch a.activePlayer.Repeat {
-	case media.RepeatNone:
-		nextMode = int(media.RepeatAll)
-	case media.RepeatAll:
-		nextMode = int(media.RepeatOne)
-	default:
-		nextMode = int(media.RepeatNone)
-	}
-	log.Printf("[App] Sending REPEAT command to player %d (nextMode=%d)", a.activePlayer.ID, nextMode)
-	err := a.wnpServer.SendCommand(a.activePlayer.ID, "REPEAT", nextMode)
-	if err != nil {
-		log.Printf("[App] MediaToggleRepeat error: %v", err)
-	}
-	return err
+// MediaLike likes the current track (5 stars on scale rating systems)
+func (a *App) MediaLike() error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandLike})
 }
 
-// MediaSetRating sets track rating (0=none, 1=dislike, 5=like)
-func (a *App) MediaSetRating(rating int) error {
-	log.Printf("[App] MediaSetRating called with rating=%d", rating)
+// MediaDislike dislikes the current track (1 star on scale rating systems)
+func (a *App) MediaDislike() error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandDislike})
+}
 
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+// MediaClearRating removes the rating of the current track
+func (a *App) MediaClearRating() error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandClearRating})
+}
 
-	if a.wnpServer == nil {
-		log.Println("[App] MediaSetRating: wnpServer is nil")
-		return nil
-	}
-	if a.activePlayer == nil {
-		log.Println("[App] MediaSetRating: no active player")
-		return nil
-	}
+// MediaToggleLike likes the current track, or clears the like if it is already liked
+func (a *App) MediaToggleLike() error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandToggleLike})
+}
 
-	log.Printf("[App] Sending RATING command to player %d (rating=%d)", a.activePlayer.ID, rating)
-	err := a.wnpServer.SendCommand(a.activePlayer.ID, "RATING", rating)
-	if err != nil {
-		log.Printf("[App] MediaSetRating error: %v", err)
-	}
-	return err
+// MediaSetStars rates the current track with 0-5 stars (scale rating systems only)
+func (a *App) MediaSetStars(stars int) error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandSetStars, Value: stars})
 }
 
 // MediaSeek seeks to position in seconds
 func (a *App) MediaSeek(position int) error {
-	log.Printf("[App] MediaSeek called with position=%d", position)
-
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-
-	if a.wnpServer == nil {
-		log.Println("[App] MediaSeek: wnpServer is nil")
-		return nil
-	}
-	if a.activePlayer == nil {
-		log.Println("[App] MediaSeek: no active player")
-		return nil
-	}
-
-	log.Printf("[App] Sending POSITION command to player %d (position=%d)", a.activePlayer.ID, position)
-	err := a.wnpServer.SendCommand(a.activePlayer.ID, "POSITION", position)
-	if err != nil {
-		log.Printf("[App] MediaSeek error: %v", err)
-	}
-	return err
+	return a.ExecuteCommand(media.Command{Kind: media.CommandSeek, Value: position})
 }
 
 // MediaSetVolume sets the volume (0-100)
 func (a *App) MediaSetVolume(volume int) error {
-	// Clamp volume between 0 and 100
-	if volume < 0 {
-		volume = 0
-	} else if volume > 100 {
-		volume = 100
-	}
-
-	log.Printf("[App] MediaSetVolume called with volume=%d", volume)
-
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-
-	if a.wnpServer == nil {
-		log.Println("[App] MediaSetVolume: wnpServer is nil")
-		return nil
-	}
-	if a.activePlayer == nil {
-		log.Println("[App] MediaSetVolume: no active player")
-		return nil
-	}
-
-	if !a.activePlayer.CanSetVolume {
-		log.Println("[App] MediaSetVolume: player does not support setting volume")
-		return nil
-	}
-
-	log.Printf("[App] Sending VOLUME command to player %d (volume=%d)", a.activePlayer.ID, volume)
-	err := a.wnpServer.SendCommand(a.activePlayer.ID, "VOLUME", volume)
-	if err != nil {
-		log.Printf("[App] MediaSetVolume error: %v", err)
-	}
-	return err
+	return a.ExecuteCommand(media.Command{Kind: media.CommandSetVolume, Value: volume})
 }
 
 // --- Autorun Methods ---
@@ -529,7 +622,7 @@ func (a *App) Quit() {
 // startWNPServer starts the WNP server on the specified port
 func (a *App) startWNPServer(port int) {
 	var err error
-	a.wnpServer, err = media.NewWebNowPlayingServer(port, a.onPlayerUpdate)
+	a.wnpServer, err = media.NewWebNowPlayingServer(port, a.covers)
 	if err != nil {
 		log.Printf("Failed to start WebNowPlaying server on port %d: %v", port, err)
 
@@ -541,10 +634,18 @@ func (a *App) startWNPServer(port int) {
 			})
 		}
 	} else {
+		a.configureWNPServer()
 		log.Printf("WebNowPlaying server started on port %d", port)
 	}
 }
 
+// configureWNPServer applies player lifecycle settings to the WNP server and adds it to the player sources
+func (a *App) configureWNPServer() {
+	a.wnpServer.SetEventResultCallback(a.onEventResult)
+	a.wnpServer.SetPlayerTTL(time.Duration(a.config.PlayerTTLSeconds) * time.Second)
+	a.players.AddSource(a.wnpServer)
+}
+
 // GetWNPPort returns the current WNP port from config
 func (a *App) GetWNPPort() int {
 	if a.config != nil {
@@ -568,6 +669,7 @@ func (a *App) ChangeWNPPort(port int) error {
 
 	// Stop existing server
 	if a.wnpServer != nil {
+		a.players.RemoveSource(a.wnpServer)
 		a.wnpServer.Stop()
 		a.wnpServer = nil
 	}
@@ -578,7 +680,7 @@ func (a *App) ChangeWNPPort(port int) error {
 
 	// Start new server
 	var err error
-	a.wnpServer, err = media.NewWebNowPlayingServer(port, a.onPlayerUpdate)
+	a.wnpServer, err = media.NewWebNowPlayingServer(port, a.covers)
 	if err != nil {
 		log.Printf("Failed to start WebNowPlaying server on port %d: %v", port, err)
 
@@ -591,6 +693,7 @@ func (a *App) ChangeWNPPort(port int) error {
 		}
 		return err
 	}
+	a.configureWNPServer()
 
 	log.Printf("WebNowPlaying server restarted on port %d", port)
 
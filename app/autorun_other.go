@@ -0,0 +1,28 @@
+//go:build !windows
+
+package app
+
+import "errors"
+
+// errAutorunUnsupported is returned where autostart is not implemented
+var errAutorunUnsupported = errors.New("autorun is only supported on Windows")
+
+// AutorunManager is a stub outside Windows; NewAutorunManager always fails,
+// so the app keeps no manager and reports autorun as disabled
+type AutorunManager struct{}
+
+func NewAutorunManager() (*AutorunManager, error) {
+	return nil, errAutorunUnsupported
+}
+
+func (am *AutorunManager) IsEnabled() (bool, error) {
+	return false, nil
+}
+
+func (am *AutorunManager) Enable() error {
+	return errAutorunUnsupported
+}
+
+func (am *AutorunManager) Disable() error {
+	return nil
+}
@@ -5,6 +5,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
+
+	"round-sound/media"
 )
 
 // DefaultWNPPort is the default WebNowPlaying port (same as Rainmeter adapter)
@@ -15,23 +18,58 @@ type Config struct {
 	WindowX int `json:"windowX"`
 	WindowY int `json:"windowY"`
 	WNPPort int `json:"wnpPort"`
+	// PositionTickHz is the rate of media:position ticks while playing (0 = off)
+	PositionTickHz int `json:"positionTickHz"`
+	// PlayerTTLSeconds expires players without updates after this many seconds (0 = never)
+	PlayerTTLSeconds int `json:"playerTtlSeconds"`
+	// PlayerRules customizes which player is shown (pin, blocklist, priority, sticky)
+	PlayerRules media.PlayerRules `json:"playerRules"`
+	// Scrobbler sends listens to a ListenBrainz-compatible server
+	Scrobbler media.ScrobblerConfig `json:"scrobbler"`
+	// LyricsDirs are searched for .lrc files matching the playing track
+	LyricsDirs []string `json:"lyricsDirs"`
+	// Hooks run commands or webhooks on media events
+	Hooks []HookConfig `json:"hooks"`
+	// MPD adds a Music Player Daemon as a player source
+	MPD media.MPDConfig `json:"mpd"`
+	// Overlay serves the widget to OBS browser sources
+	Overlay OverlayConfig `json:"overlay"`
+	// NowPlaying writes the displayed track to text, JSON and cover files
+	NowPlaying NowPlayingConfig `json:"nowPlaying"`
+	// MQTT publishes the player to a broker and takes commands (Home Assistant)
+	MQTT media.MQTTConfig `json:"mqtt"`
+	// OSC sends levels, beats and track changes to VJ and lighting software
+	OSC media.OSCConfig `json:"osc"`
+	// Discord shows the track as Discord Rich Presence
+	Discord media.DiscordConfig `json:"discord"`
+	// Notifications show a desktop notification when the track changes
+	Notifications NotificationConfig `json:"notifications"`
 }
 
-// getConfigPath returns the path to config file
-func getConfigPath() string {
-	appData := os.Getenv("APPDATA")
-	if appData == "" {
+// getConfigDir returns the application data directory, creating it if needed
+func getConfigDir() string {
+	// %AppData% on Windows, $XDG_CONFIG_HOME or ~/.config on Linux
+	appData, err := os.UserConfigDir()
+	if err != nil {
 		appData = "."
 	}
 	configDir := filepath.Join(appData, "round-sound")
 	os.MkdirAll(configDir, 0755)
-	return filepath.Join(configDir, "config.json")
+	return configDir
+}
+
+// getConfigPath returns the path to config file
+func getConfigPath() string {
+	return filepath.Join(getConfigDir(), "config.json")
 }
 
 // LoadConfig loads configuration from file
 func LoadConfig() *Config {
 	cfg := &Config{
-		WNPPort: DefaultWNPPort, // Default port
+		WNPPort:          DefaultWNPPort, // Default port
+		PlayerTTLSeconds: int(media.DefaultPlayerTTL / time.Second),
+		// Kept for configs saved before OSC existed; a saved osc section overrides them
+		OSC: media.OSCConfig{Addresses: media.DefaultOSCAddresses()},
 	}
 	configPath := getConfigPath()
 
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"time"
+
+	"round-sound/media"
+)
+
+// Diagnostics aggregates capture pipeline and WebNowPlaying health for the settings page
+type Diagnostics struct {
+	UptimeSec  int64              `json:"uptimeSec"`
+	WNPRunning bool               `json:"wnpRunning"`
+	WNP        media.ServerStats  `json:"wnp"`
+	Audio      media.CaptureStats `json:"audio"`
+	FFT        media.FFTConfig    `json:"fft"`
+}
+
+// GetDiagnostics returns a snapshot of audio capture and WebNowPlaying counters
+func (a *App) GetDiagnostics() Diagnostics {
+	d := Diagnostics{
+		WNPRunning: a.wnpServer != nil,
+	}
+
+	if !a.startedAt.IsZero() {
+		d.UptimeSec = int64(time.Since(a.startedAt).Seconds())
+	}
+	if a.wnpServer != nil {
+		d.WNP = a.wnpServer.Stats()
+	} else if a.config != nil {
+		d.WNP.Port = a.config.WNPPort
+	}
+	d.WNP.ActivePlayerID = a.players.ActivePlayerID()
+	if a.audioCapture != nil {
+		d.Audio = a.audioCapture.Stats()
+		d.FFT = a.audioCapture.Config()
+	}
+
+	return d
+}
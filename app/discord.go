@@ -0,0 +1,86 @@
+package app
+
+import (
+	"log"
+	"strings"
+
+	"round-sound/media"
+)
+
+// startDiscord (re)starts the Discord Rich Presence when it is enabled
+func (a *App) startDiscord() {
+	a.discordMu.Lock()
+	old := a.discord
+	a.discord = nil
+	a.discordMu.Unlock()
+
+	// Stop outside the lock: it waits for the presence to be cleared
+	if old != nil {
+		old.Stop()
+	}
+	if !a.config.Discord.Enabled {
+		return
+	}
+
+	presence := media.NewDiscordPresence(a.config.Discord)
+	a.mu.RLock()
+	player := a.reportedPlayer
+	a.mu.RUnlock()
+	presence.Update(player)
+
+	a.discordMu.Lock()
+	a.discord = presence
+	a.discordMu.Unlock()
+}
+
+// updateDiscord shows the displayed player in Discord (nil = nothing is shown)
+func (a *App) updateDiscord(player *media.Player) {
+	a.discordMu.Lock()
+	defer a.discordMu.Unlock()
+
+	if a.discord != nil {
+		a.discord.Update(player)
+	}
+}
+
+// GetDiscordConfig returns the Discord Rich Presence settings
+func (a *App) GetDiscordConfig() media.DiscordConfig {
+	config := a.config.Discord
+	if config.AllowedPlayers == nil {
+		config.AllowedPlayers = []string{}
+	}
+	return config
+}
+
+// SetDiscordConfig validates and saves the Discord Rich Presence settings and reconnects
+func (a *App) SetDiscordConfig(config media.DiscordConfig) error {
+	config.ClientID = strings.TrimSpace(config.ClientID)
+	allowed := make([]string, 0, len(config.AllowedPlayers))
+	for _, name := range config.AllowedPlayers {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed = append(allowed, name)
+		}
+	}
+	config.AllowedPlayers = allowed
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	a.config.Discord = config
+	a.config.Save()
+	a.startDiscord()
+
+	log.Printf("[App] Discord updated: enabled=%v, %d allowed player(s)", config.Enabled, len(config.AllowedPlayers))
+	return nil
+}
+
+// GetDiscordStatus returns the Discord connection state
+func (a *App) GetDiscordStatus() media.DiscordStatus {
+	a.discordMu.Lock()
+	defer a.discordMu.Unlock()
+
+	if a.discord == nil {
+		return media.DiscordStatus{}
+	}
+	return a.discord.Status()
+}
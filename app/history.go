@@ -0,0 +1,346 @@
+package app
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"round-sound/media"
+)
+
+// minHistoryListen drops sessions shorter than this (seconds) from the history
+const minHistoryListen = 5
+
+// HistoryEntry is one listen in the history log
+type HistoryEntry struct {
+	Title     string  `json:"title"`
+	Artist    string  `json:"artist"`
+	Album     string  `json:"album"`
+	Player    string  `json:"player"`
+	Duration  int     `json:"duration"`  // track length, seconds
+	StartedAt int64   `json:"startedAt"` // unix ms
+	EndedAt   int64   `json:"endedAt"`   // unix ms
+	Listened  float64 `json:"listened"`  // seconds actually played
+	Skipped   bool    `json:"skipped"`
+}
+
+// PlayStat aggregates listens of an artist or a track
+type PlayStat struct {
+	Artist   string  `json:"artist"`
+	Title    string  `json:"title,omitempty"`
+	Plays    int     `json:"plays"`
+	Skips    int     `json:"skips"`
+	Listened float64 `json:"listened"` // seconds
+}
+
+// ListeningTime summarizes listening over a period
+type ListeningTime struct {
+	Seconds float64 `json:"seconds"`
+	Plays   int     `json:"plays"`
+	Skips   int     `json:"skips"`
+}
+
+// HistoryStore is an append-only JSONL listening history kept in memory for queries
+type HistoryStore struct {
+	mu      sync.RWMutex
+	path    string
+	entries []HistoryEntry
+}
+
+// OpenHistoryStore loads the history file, skipping unreadable lines
+func OpenHistoryStore(path string) (*HistoryStore, error) {
+	h := &HistoryStore{path: path}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("[History] Skipping bad line: %v", err)
+			continue
+		}
+		h.entries = append(h.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[History] Loaded %d entries from %s", len(h.entries), path)
+	return h, nil
+}
+
+// Append adds an entry and writes it to the end of the file
+func (h *HistoryStore) Append(entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	h.entries = append(h.entries, entry)
+	return nil
+}
+
+// Recent returns up to limit entries, newest first
+func (h *HistoryStore) Recent(limit int) []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	result := make([]HistoryEntry, 0, min(limit, len(h.entries)))
+	for i := len(h.entries) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, h.entries[i])
+	}
+	return result
+}
+
+// Top aggregates listens since the given time by artist (byTrack=false) or by track
+func (h *HistoryStore) Top(since time.Time, limit int, byTrack bool) []PlayStat {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := make(map[string]*PlayStat)
+	for _, entry := range h.entriesSince(since) {
+		key := strings.ToLower(entry.Artist)
+		stat := PlayStat{Artist: entry.Artist}
+		if byTrack {
+			key += "\x00" + strings.ToLower(entry.Title)
+			stat.Title = entry.Title
+		}
+		s, ok := stats[key]
+		if !ok {
+			s = &stat
+			stats[key] = s
+		}
+		s.Plays++
+		s.Listened += entry.Listened
+		if entry.Skipped {
+			s.Skips++
+		}
+	}
+
+	result := make([]PlayStat, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Plays != result[j].Plays {
+			return result[i].Plays > result[j].Plays
+		}
+		return result[i].Listened > result[j].Listened
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// Total sums listening since the given time
+func (h *HistoryStore) Total(since time.Time) ListeningTime {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var total ListeningTime
+	for _, entry := range h.entriesSince(since) {
+		total.Seconds += entry.Listened
+		total.Plays++
+		if entry.Skipped {
+			total.Skips++
+		}
+	}
+	return total
+}
+
+// entriesSince returns entries that started at or after since. Caller must hold mu.
+func (h *HistoryStore) entriesSince(since time.Time) []HistoryEntry {
+	sinceMs := since.UnixMilli()
+	i := sort.Search(len(h.entries), func(i int) bool {
+		return h.entries[i].StartedAt >= sinceMs
+	})
+	return h.entries[i:]
+}
+
+// Export writes the whole history as "csv" or "json"
+func (h *HistoryStore) Export(w io.Writer, format string) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(h.entries)
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"started_at", "ended_at", "artist", "title", "album", "player", "duration", "listened", "skipped"})
+		for _, e := range h.entries {
+			cw.Write([]string{
+				time.UnixMilli(e.StartedAt).Format(time.RFC3339),
+				time.UnixMilli(e.EndedAt).Format(time.RFC3339),
+				e.Artist,
+				e.Title,
+				e.Album,
+				e.Player,
+				strconv.Itoa(e.Duration),
+				strconv.FormatFloat(e.Listened, 'f', 1, 64),
+				strconv.FormatBool(e.Skipped),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown export format: %s", format)
+}
+
+// getHistoryPath returns the listening history file under the config dir
+func getHistoryPath() string {
+	return filepath.Join(getConfigDir(), "history.jsonl")
+}
+
+// openHistory loads the listening history; failures only disable it
+func (a *App) openHistory() {
+	history, err := OpenHistoryStore(getHistoryPath())
+	if err != nil {
+		log.Printf("[History] Failed to open history: %v", err)
+		return
+	}
+	a.history = history
+}
+
+// recordListen records a finished listen in the history
+func (a *App) recordListen(session media.PlaySession) {
+	if a.history == nil || session.Listened < minHistoryListen {
+		return
+	}
+
+	entry := HistoryEntry{
+		Title:     session.Title,
+		Artist:    session.Artist,
+		Album:     session.Album,
+		Player:    session.PlayerName,
+		Duration:  session.Duration,
+		StartedAt: session.StartedAt,
+		EndedAt:   session.EndedAt,
+		Listened:  session.Listened,
+		Skipped:   session.Skipped,
+	}
+	if err := a.history.Append(entry); err != nil {
+		log.Printf("[History] Failed to record %s - %s: %v", entry.Artist, entry.Title, err)
+		return
+	}
+
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "history:added", entry)
+	}
+}
+
+// historySince converts a period in days to its start (0 or less = all time)
+func historySince(days int) time.Time {
+	if days <= 0 {
+		return time.UnixMilli(0)
+	}
+	return time.Now().AddDate(0, 0, -days)
+}
+
+// GetRecentTracks returns the most recent listens, newest first
+func (a *App) GetRecentTracks(limit int) []HistoryEntry {
+	if a.history == nil || limit <= 0 {
+		return []HistoryEntry{}
+	}
+	return a.history.Recent(limit)
+}
+
+// GetTopArtists returns the most played artists of the last days (0 = all time)
+func (a *App) GetTopArtists(days int, limit int) []PlayStat {
+	if a.history == nil || limit <= 0 {
+		return []PlayStat{}
+	}
+	return a.history.Top(historySince(days), limit, false)
+}
+
+// GetTopTracks returns the most played tracks of the last days (0 = all time)
+func (a *App) GetTopTracks(days int, limit int) []PlayStat {
+	if a.history == nil || limit <= 0 {
+		return []PlayStat{}
+	}
+	return a.history.Top(historySince(days), limit, true)
+}
+
+// GetListeningTime returns total listening of the last days (0 = all time)
+func (a *App) GetListeningTime(days int) ListeningTime {
+	if a.history == nil {
+		return ListeningTime{}
+	}
+	return a.history.Total(historySince(days))
+}
+
+// ExportHistory asks for a file name and writes the history as "csv" or "json".
+// It returns the written path, or "" when the dialog was cancelled.
+func (a *App) ExportHistory(format string) (string, error) {
+	if a.history == nil {
+		return "", fmt.Errorf("history is not available")
+	}
+	if format != "csv" && format != "json" {
+		return "", fmt.Errorf("unknown export format: %s", format)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: "round-sound-history." + format,
+		Filters: []runtime.FileFilter{
+			{DisplayName: strings.ToUpper(format), Pattern: "*." + format},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := a.history.Export(f, format); err != nil {
+		f.Close()
+		return "", err
+	}
+	// The export is only complete once the file is flushed
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	log.Printf("[History] Exported history to %s", path)
+	return path, nil
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testHistory opens a store with a bad line followed by the given entries
+func testHistory(t *testing.T, entries ...HistoryEntry) *HistoryStore {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, []byte("not json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	history, err := OpenHistoryStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if err := history.Append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return history
+}
+
+func TestHistoryStoreReload(t *testing.T) {
+	entries := []HistoryEntry{
+		{Title: "One", Artist: "A", StartedAt: 1000, EndedAt: 2000, Listened: 60},
+		{Title: "Two", Artist: "B", StartedAt: 3000, EndedAt: 4000, Listened: 10, Skipped: true},
+	}
+	history := testHistory(t, entries...)
+
+	reloaded, err := OpenHistoryStore(history.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Recent(10); !reflect.DeepEqual(got, []HistoryEntry{entries[1], entries[0]}) {
+		t.Errorf("Recent = %+v", got)
+	}
+	if got := reloaded.Recent(1); len(got) != 1 || got[0].Title != "Two" {
+		t.Errorf("Recent(1) = %+v", got)
+	}
+
+	if missing, err := OpenHistoryStore(filepath.Join(t.TempDir(), "none.jsonl")); err != nil || len(missing.Recent(10)) != 0 {
+		t.Errorf("missing file: %v", err)
+	}
+}
+
+func TestHistoryStoreTop(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	listen := func(daysAgo int, artist, title string, listened float64, skipped bool) HistoryEntry {
+		return HistoryEntry{Artist: artist, Title: title, StartedAt: day.AddDate(0, 0, -daysAgo).UnixMilli(), Listened: listened, Skipped: skipped}
+	}
+	history := testHistory(t,
+		listen(30, "Old", "Song", 100, false),
+		listen(3, "Band", "Hit", 200, false),
+		listen(2, "band", "hit", 100, true), // artists and titles ignore case
+		listen(2, "Band", "Deep Cut", 50, false),
+		listen(1, "Solo", "Ballad", 400, false),
+		listen(0, "Solo", "Ballad", 20, true),
+		listen(0, "Duo", "Single", 30, false),
+	)
+	week := day.AddDate(0, 0, -7)
+
+	artists := history.Top(week, 10, false)
+	want := []PlayStat{
+		{Artist: "Band", Plays: 3, Skips: 1, Listened: 350},
+		{Artist: "Solo", Plays: 2, Skips: 1, Listened: 420},
+		{Artist: "Duo", Plays: 1, Listened: 30},
+	}
+	if !reflect.DeepEqual(artists, want) {
+		t.Errorf("top artists = %+v, want %+v", artists, want)
+	}
+
+	// Equal plays are ranked by listening time
+	tracks := history.Top(week, 3, true)
+	want = []PlayStat{
+		{Artist: "Solo", Title: "Ballad", Plays: 2, Skips: 1, Listened: 420},
+		{Artist: "Band", Title: "Hit", Plays: 2, Skips: 1, Listened: 300},
+		{Artist: "Band", Title: "Deep Cut", Plays: 1, Listened: 50},
+	}
+	if !reflect.DeepEqual(tracks, want) {
+		t.Errorf("top tracks = %+v, want %+v", tracks, want)
+	}
+
+	if all := history.Top(time.UnixMilli(0), 10, false); len(all) != 4 || all[2].Artist != "Old" {
+		t.Errorf("all time = %+v", all)
+	}
+	if got := history.Top(day.AddDate(0, 0, 1), 10, false); len(got) != 0 {
+		t.Errorf("future = %+v", got)
+	}
+	if total := history.Total(week); total != (ListeningTime{Seconds: 800, Plays: 6, Skips: 2}) {
+		t.Errorf("total = %+v", total)
+	}
+}
+
+func TestHistoryStoreExport(t *testing.T) {
+	started := time.Date(2026, 5, 1, 12, 0, 0, 0, time.Local)
+	entries := []HistoryEntry{
+		{Title: "Song, with comma", Artist: `Say "Hi"`, Album: "Album", Player: "Spotify", Duration: 200,
+			StartedAt: started.UnixMilli(), EndedAt: started.Add(3 * time.Minute).UnixMilli(), Listened: 180.5},
+		{Title: "Short", Artist: "B", Duration: 100, StartedAt: started.Add(time.Hour).UnixMilli(),
+			EndedAt: started.Add(time.Hour + 10*time.Second).UnixMilli(), Listened: 10, Skipped: true},
+	}
+	history := testHistory(t, entries...)
+
+	var buf bytes.Buffer
+	if err := history.Export(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var exported []HistoryEntry
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil || !reflect.DeepEqual(exported, entries) {
+		t.Errorf("json export = %+v (%v)", exported, err)
+	}
+
+	buf.Reset()
+	if err := history.Export(&buf, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"started_at", "ended_at", "artist", "title", "album", "player", "duration", "listened", "skipped"},
+		{started.Format(time.RFC3339), started.Add(3 * time.Minute).Format(time.RFC3339), `Say "Hi"`, "Song, with comma", "Album", "Spotify", "200", "180.5", "false"},
+		{started.Add(time.Hour).Format(time.RFC3339), started.Add(time.Hour + 10*time.Second).Format(time.RFC3339), "B", "Short", "", "", "100", "10.0", "true"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("csv export = %q, want %q", records, want)
+	}
+
+	if err := history.Export(&buf, "xml"); err == nil {
+		t.Error("exported an unknown format")
+	}
+}
@@ -0,0 +1,445 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"round-sound/media"
+)
+
+// HookEvent is a media event hooks can subscribe to
+type HookEvent string
+
+const (
+	HookTrackChange  HookEvent = "track_change"
+	HookPlay         HookEvent = "play"
+	HookPause        HookEvent = "pause"
+	HookPlayerChange HookEvent = "player_change"
+	HookSoundStart   HookEvent = "sound_start"
+	HookSoundStop    HookEvent = "sound_stop"
+)
+
+const (
+	// defaultHookTimeout bounds a hook run when its config has no timeout
+	defaultHookTimeout = 10 * time.Second
+	// maxHookTimeout caps configured timeouts
+	maxHookTimeout = 2 * time.Minute
+	// hookWaitDelay bounds the wait for a command's output after it exits or is
+	// killed, in case a background child still holds it open
+	hookWaitDelay = 2 * time.Second
+	// soundStopDelay is how long audio must stay silent before sound_stop fires
+	soundStopDelay = 2 * time.Second
+	// soundLevelThreshold is the band level above which a frame counts as sound
+	soundLevelThreshold = 0.02
+)
+
+// HookConfig is one user hook: a command line or a webhook run on an event
+type HookConfig struct {
+	Name    string    `json:"name"`
+	Event   HookEvent `json:"event"`
+	Enabled bool      `json:"enabled"`
+	// Command runs through the system shell with the payload as ROUNDSOUND_* env vars and JSON on stdin
+	Command string `json:"command,omitempty"`
+	// URL receives a POST with Body rendered as a Go template over the payload (JSON payload when empty)
+	URL  string `json:"url,omitempty"`
+	Body string `json:"body,omitempty"`
+	// DebounceMs delays the hook and restarts the delay on every new event (0 = run at once)
+	DebounceMs int `json:"debounceMs"`
+	// TimeoutSeconds kills the command or aborts the request (0 = 10 s)
+	TimeoutSeconds int `json:"timeoutSeconds"`
+}
+
+// HookPayload is the data passed to hooks
+type HookPayload struct {
+	Event    HookEvent       `json:"event"`
+	Time     int64           `json:"time"` // unix ms
+	PlayerID int             `json:"playerId"`
+	Player   string          `json:"player"`
+	Title    string          `json:"title"`
+	Artist   string          `json:"artist"`
+	Album    string          `json:"album"`
+	Cover    string          `json:"cover"`
+	Duration int             `json:"duration"`
+	Position int             `json:"position"`
+	Volume   int             `json:"volume"`
+	State    media.StateMode `json:"state"`
+}
+
+// HookDryRun describes what a hook would do, without running it
+type HookDryRun struct {
+	Payload HookPayload `json:"payload"`
+	Command []string    `json:"command,omitempty"`
+	Env     []string    `json:"env,omitempty"`
+	Stdin   string      `json:"stdin,omitempty"`
+	URL     string      `json:"url,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+var hookEvents = []HookEvent{HookTrackChange, HookPlay, HookPause, HookPlayerChange, HookSoundStart, HookSoundStop}
+
+// Validate checks the hook event, action and template
+func (h HookConfig) Validate() error {
+	known := false
+	for _, event := range hookEvents {
+		known = known || h.Event == event
+	}
+	if !known {
+		return fmt.Errorf("unknown hook event: %q", h.Event)
+	}
+
+	command, target := strings.TrimSpace(h.Command), strings.TrimSpace(h.URL)
+	if (command == "") == (target == "") {
+		return fmt.Errorf("hook %q: set either a command or a URL", h.Name)
+	}
+	if target != "" {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("hook %q: invalid URL: %s", h.Name, target)
+		}
+	}
+	if h.Body != "" {
+		if _, err := parseHookTemplate(h.Body); err != nil {
+			return fmt.Errorf("hook %q: %w", h.Name, err)
+		}
+	}
+	if h.DebounceMs < 0 || h.TimeoutSeconds < 0 {
+		return fmt.Errorf("hook %q: debounce and timeout must not be negative", h.Name)
+	}
+	return nil
+}
+
+func (h HookConfig) timeout() time.Duration {
+	if h.TimeoutSeconds <= 0 {
+		return defaultHookTimeout
+	}
+	return min(time.Duration(h.TimeoutSeconds)*time.Second, maxHookTimeout)
+}
+
+// parseHookTemplate parses a webhook body; {{json .Title}} emits a quoted JSON value
+func parseHookTemplate(body string) (*template.Template, error) {
+	return template.New("body").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(body)
+}
+
+// hookPayload builds the payload of an event from a player (nil = no player),
+// with cached covers given as their file
+func hookPayload(event HookEvent, player *media.Player, covers *media.CoverCache, now time.Time) HookPayload {
+	payload := HookPayload{Event: event, Time: now.UnixMilli()}
+	if player != nil {
+		payload.PlayerID = player.ID
+		payload.Player = player.Name
+		payload.Title = player.Title
+		payload.Artist = player.Artist
+		payload.Album = player.Album
+		payload.Cover = covers.Location(player.Cover)
+		payload.Duration = player.Duration
+		payload.Position = int(player.EstimatedPositionAt(now))
+		payload.Volume = player.Volume
+		payload.State = player.State
+	}
+	return payload
+}
+
+// prepare renders the hook action for a payload
+func (h HookConfig) prepare(payload HookPayload) (HookDryRun, error) {
+	run := HookDryRun{Payload: payload}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return run, err
+	}
+
+	if command := strings.TrimSpace(h.Command); command != "" {
+		if goruntime.GOOS == "windows" {
+			run.Command = []string{"cmd", "/C", command}
+		} else {
+			run.Command = []string{"sh", "-c", command}
+		}
+		run.Env = []string{
+			"ROUNDSOUND_EVENT=" + string(payload.Event),
+			"ROUNDSOUND_PLAYER_ID=" + strconv.Itoa(payload.PlayerID),
+			"ROUNDSOUND_PLAYER=" + payload.Player,
+			"ROUNDSOUND_TITLE=" + payload.Title,
+			"ROUNDSOUND_ARTIST=" + payload.Artist,
+			"ROUNDSOUND_ALBUM=" + payload.Album,
+			"ROUNDSOUND_COVER=" + payload.Cover,
+			"ROUNDSOUND_DURATION=" + strconv.Itoa(payload.Duration),
+			"ROUNDSOUND_POSITION=" + strconv.Itoa(payload.Position),
+			"ROUNDSOUND_VOLUME=" + strconv.Itoa(payload.Volume),
+			"ROUNDSOUND_STATE=" + strconv.Itoa(int(payload.State)),
+		}
+		run.Stdin = string(data)
+		return run, nil
+	}
+
+	run.URL = strings.TrimSpace(h.URL)
+	if h.Body == "" {
+		run.Body = string(data)
+		return run, nil
+	}
+	tmpl, err := parseHookTemplate(h.Body)
+	if err != nil {
+		return run, err
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, payload); err != nil {
+		return run, err
+	}
+	run.Body = body.String()
+	return run, nil
+}
+
+// execute runs a prepared hook action within the hook timeout
+func (h HookConfig) execute(client *http.Client, run HookDryRun) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout())
+	defer cancel()
+
+	if len(run.Command) > 0 {
+		cmd := exec.CommandContext(ctx, run.Command[0], run.Command[1:]...)
+		cmd.Env = append(os.Environ(), run.Env...)
+		cmd.Stdin = strings.NewReader(run.Stdin)
+		cmd.WaitDelay = hookWaitDelay
+		hideConsole(cmd)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, run.URL, strings.NewReader(run.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// hookRunner detects hook events from player and audio updates and runs matching hooks
+type hookRunner struct {
+	mu     sync.Mutex
+	hooks  []HookConfig
+	timers map[int]*time.Timer // pending debounced runs by hook index
+	client *http.Client
+	covers *media.CoverCache
+
+	// last displayed player state, for change detection
+	lastPlayerID int
+	lastTrack    string
+	lastPlaying  bool
+
+	// audio activity, for sound_start / sound_stop
+	sound soundDetector
+}
+
+func newHookRunner(hooks []HookConfig, covers *media.CoverCache) *hookRunner {
+	return &hookRunner{
+		hooks:  hooks,
+		timers: make(map[int]*time.Timer),
+		client: &http.Client{},
+		covers: covers,
+	}
+}
+
+// setHooks replaces the hooks, dropping pending debounced runs
+func (r *hookRunner) setHooks(hooks []HookConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, timer := range r.timers {
+		timer.Stop()
+	}
+	r.timers = make(map[int]*time.Timer)
+	r.hooks = hooks
+}
+
+// observePlayer fires player_change, track_change, play and pause (nil = players cleared)
+func (r *hookRunner) observePlayer(player *media.Player, now time.Time) {
+	var events []HookEvent
+
+	r.mu.Lock()
+	id, track, playing := 0, "", false
+	if player != nil {
+		id = player.ID
+		track = player.Artist + "\x00" + player.Title
+		playing = player.State == media.StatePlaying
+	}
+	if id != r.lastPlayerID {
+		events = append(events, HookPlayerChange)
+	}
+	if player != nil && player.Title != "" && track != r.lastTrack {
+		events = append(events, HookTrackChange)
+	}
+	if playing != r.lastPlaying {
+		if playing {
+			events = append(events, HookPlay)
+		} else {
+			events = append(events, HookPause)
+		}
+	}
+	r.lastPlayerID, r.lastTrack, r.lastPlaying = id, track, playing
+	r.mu.Unlock()
+
+	for _, event := range events {
+		r.fire(hookPayload(event, player, r.covers, now))
+	}
+}
+
+// soundDetector follows whether audio is playing, ignoring short silent gaps
+type soundDetector struct {
+	sounding    bool
+	lastSoundAt time.Time
+}
+
+// observe reports whether frame started the sound (on the first audible frame)
+// or stopped it (after a silent period)
+func (d *soundDetector) observe(frame media.LevelFrame, now time.Time) (changed, sounding bool) {
+	audible := false
+	if !frame.Silence {
+		for _, level := range frame.Levels {
+			if level > soundLevelThreshold {
+				audible = true
+				break
+			}
+		}
+	}
+
+	switch {
+	case audible:
+		d.lastSoundAt = now
+		if !d.sounding {
+			d.sounding = true
+			return true, true
+		}
+	case d.sounding && now.Sub(d.lastSoundAt) >= soundStopDelay:
+		d.sounding = false
+		return true, false
+	}
+	return false, d.sounding
+}
+
+// observeAudio fires sound_start on the first audible frame and sound_stop after a silent period
+func (r *hookRunner) observeAudio(frame media.LevelFrame, player *media.Player, now time.Time) {
+	r.mu.Lock()
+	changed, sounding := r.sound.observe(frame, now)
+	r.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if sounding {
+		r.fire(hookPayload(HookSoundStart, player, r.covers, now))
+	} else {
+		r.fire(hookPayload(HookSoundStop, player, r.covers, now))
+	}
+}
+
+// fire schedules every enabled hook of the payload's event
+func (r *hookRunner) fire(payload HookPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, hook := range r.hooks {
+		if !hook.Enabled || hook.Event != payload.Event {
+			continue
+		}
+		if hook.DebounceMs <= 0 {
+			go r.run(hook, payload)
+			continue
+		}
+
+		// Restart the delay so only the last event of a burst runs the hook
+		if timer, ok := r.timers[i]; ok {
+			timer.Stop()
+		}
+		index := i
+		var timer *time.Timer
+		timer = time.AfterFunc(time.Duration(hook.DebounceMs)*time.Millisecond, func() {
+			r.mu.Lock()
+			if r.timers[index] != timer {
+				r.mu.Unlock()
+				return // replaced or dropped by setHooks after it fired
+			}
+			delete(r.timers, index)
+			r.mu.Unlock()
+			r.run(hook, payload)
+		})
+		r.timers[i] = timer
+	}
+}
+
+// run executes one hook and logs the outcome
+func (r *hookRunner) run(hook HookConfig, payload HookPayload) {
+	prepared, err := hook.prepare(payload)
+	if err == nil {
+		err = hook.execute(r.client, prepared)
+	}
+	if err != nil {
+		log.Printf("[Hooks] %q on %s failed: %v", hook.Name, payload.Event, err)
+		return
+	}
+	log.Printf("[Hooks] %q on %s done", hook.Name, payload.Event)
+}
+
+// GetHooks returns the configured hooks
+func (a *App) GetHooks() []HookConfig {
+	if a.config.Hooks == nil {
+		return []HookConfig{}
+	}
+	return a.config.Hooks
+}
+
+// SetHooks validates and saves the hooks
+func (a *App) SetHooks(hooks []HookConfig) error {
+	for _, hook := range hooks {
+		if err := hook.Validate(); err != nil {
+			return err
+		}
+	}
+
+	a.config.Hooks = hooks
+	a.config.Save()
+	a.hooks.setHooks(hooks)
+
+	log.Printf("[App] %d hook(s) configured", len(hooks))
+	return nil
+}
+
+// TestHook renders a hook for the displayed player (dry run) without running it
+func (a *App) TestHook(hook HookConfig) (HookDryRun, error) {
+	if err := hook.Validate(); err != nil {
+		return HookDryRun{}, err
+	}
+
+	a.mu.RLock()
+	player := a.activePlayer
+	a.mu.RUnlock()
+
+	return hook.prepare(hookPayload(hook.Event, player, a.covers, time.Now()))
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package app
+
+import "os/exec"
+
+// hideConsole is a no-op outside Windows
+func hideConsole(cmd *exec.Cmd) {}
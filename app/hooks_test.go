@@ -0,0 +1,182 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"round-sound/media"
+)
+
+func TestHookConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		hook HookConfig
+		ok   bool
+	}{
+		{"command", HookConfig{Event: HookPlay, Command: "echo hi"}, true},
+		{"webhook", HookConfig{Event: HookTrackChange, URL: "https://example.com/hook", Body: `{"t":{{json .Title}}}`}, true},
+		{"unknown event", HookConfig{Event: "stop", Command: "echo hi"}, false},
+		{"no action", HookConfig{Event: HookPlay, Command: "  "}, false},
+		{"both actions", HookConfig{Event: HookPlay, Command: "echo hi", URL: "https://example.com"}, false},
+		{"not http", HookConfig{Event: HookPlay, URL: "ftp://example.com"}, false},
+		{"no host", HookConfig{Event: HookPlay, URL: "http://"}, false},
+		{"broken template", HookConfig{Event: HookPlay, URL: "http://localhost/", Body: "{{.Title"}, false},
+		{"negative debounce", HookConfig{Event: HookPlay, Command: "echo hi", DebounceMs: -1}, false},
+		{"negative timeout", HookConfig{Event: HookPlay, Command: "echo hi", TimeoutSeconds: -1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.hook.Validate(); (err == nil) != tt.ok {
+				t.Errorf("Validate() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestHookPrepare(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	player := &media.Player{ID: 4, Name: "Spotify", Title: `Say "Hi"`, Artist: "Artist", Album: "Album", Cover: "https://example.com/c.jpg",
+		State: media.StatePaused, Position: 30, Duration: 200, Volume: 70}
+	player.RebasePosition(30, now)
+	payload := hookPayload(HookTrackChange, player, nil, now)
+	if payload.Position != 30 || payload.Cover != "https://example.com/c.jpg" || payload.Time != now.UnixMilli() {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	// A command gets the payload as env vars and JSON on stdin
+	run, err := HookConfig{Event: HookTrackChange, Command: " notify  "}.prepare(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sh", "-c", "notify"}
+	if goruntime.GOOS == "windows" {
+		want = []string{"cmd", "/C", "notify"}
+	}
+	if !slices.Equal(run.Command, want) {
+		t.Errorf("command = %q, want %q", run.Command, want)
+	}
+	for _, env := range []string{"ROUNDSOUND_EVENT=track_change", "ROUNDSOUND_PLAYER_ID=4", `ROUNDSOUND_TITLE=Say "Hi"`, "ROUNDSOUND_POSITION=30", "ROUNDSOUND_STATE=1"} {
+		if !slices.Contains(run.Env, env) {
+			t.Errorf("env %q missing from %q", env, run.Env)
+		}
+	}
+	var stdin HookPayload
+	if err := json.Unmarshal([]byte(run.Stdin), &stdin); err != nil || stdin != payload {
+		t.Errorf("stdin %s (%v)", run.Stdin, err)
+	}
+
+	// A webhook without a body posts the payload
+	run, err = HookConfig{Event: HookTrackChange, URL: "http://localhost/hook"}.prepare(payload)
+	if want, _ := json.Marshal(payload); err != nil || run.URL != "http://localhost/hook" || run.Body != string(want) || run.Command != nil {
+		t.Errorf("webhook = %+v (%v)", run, err)
+	}
+
+	// The body template escapes with json
+	run, err = HookConfig{Event: HookTrackChange, URL: "http://localhost/hook", Body: `{"text":{{json .Title}},"by":"{{.Artist}}"}`}.prepare(payload)
+	if want := `{"text":"Say \"Hi\"","by":"Artist"}`; err != nil || run.Body != want {
+		t.Errorf("body = %s (%v), want %s", run.Body, err, want)
+	}
+	if _, err := (HookConfig{URL: "http://localhost/", Body: "{{.Missing}}"}).prepare(payload); err == nil {
+		t.Error("template over a missing field rendered")
+	}
+}
+
+func TestSoundDetector(t *testing.T) {
+	start := time.Unix(1000, 0)
+	loud := media.LevelFrame{Levels: []float32{0.01, 0.5}}
+	quiet := media.LevelFrame{Levels: []float32{0.01, 0.01}}
+	silence := media.LevelFrame{Silence: true, Levels: []float32{0.5}}
+
+	steps := []struct {
+		frame    media.LevelFrame
+		at       time.Duration
+		changed  bool
+		sounding bool
+	}{
+		{quiet, 0, false, false},
+		{loud, 100 * time.Millisecond, true, true},
+		{loud, 200 * time.Millisecond, false, true},
+		{silence, time.Second, false, true},                               // short gap
+		{loud, 1500 * time.Millisecond, false, true},                      // continues
+		{quiet, 1500*time.Millisecond + soundStopDelay - 1, false, true},  // not yet
+		{quiet, 1500*time.Millisecond + soundStopDelay, true, false},      // stopped
+		{silence, 1500*time.Millisecond + 2*soundStopDelay, false, false}, // stays stopped
+		{loud, 10 * time.Second, true, true},
+	}
+	var d soundDetector
+	for i, step := range steps {
+		changed, sounding := d.observe(step.frame, start.Add(step.at))
+		if changed != step.changed || sounding != step.sounding {
+			t.Errorf("step %d: changed %v, sounding %v; want %v, %v", i, changed, sounding, step.changed, step.sounding)
+		}
+	}
+}
+
+// newRecordingHookRunner returns a runner with one debounced command hook that
+// appends the title to a file, and the file
+func newRecordingHookRunner(t *testing.T, debounce int) (*hookRunner, string) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("the hook command is a POSIX shell line")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	r := newHookRunner([]HookConfig{{
+		Name:       "record",
+		Event:      HookTrackChange,
+		Enabled:    true,
+		Command:    `printf '%s\n' "$ROUNDSOUND_TITLE" >> "` + out + `"`,
+		DebounceMs: debounce,
+	}}, nil)
+	t.Cleanup(func() { r.setHooks(nil) })
+	return r, out
+}
+
+func readHookOutput(out string) string {
+	data, _ := os.ReadFile(out)
+	return string(data)
+}
+
+func TestHookRunnerDebounce(t *testing.T) {
+	r, out := newRecordingHookRunner(t, 50)
+
+	// Only the last event of a burst runs the hook
+	for _, title := range []string{"A", "B", "C"} {
+		r.fire(HookPayload{Event: HookTrackChange, Title: title})
+		time.Sleep(10 * time.Millisecond)
+	}
+	r.fire(HookPayload{Event: HookPlay, Title: "other event"})
+
+	waitFor(t, func() bool { return readHookOutput(out) != "" })
+	time.Sleep(200 * time.Millisecond)
+	if got := readHookOutput(out); got != "C\n" {
+		t.Errorf("hook output %q, want one run for C", got)
+	}
+}
+
+func TestHookRunnerIgnoresReplacedTimer(t *testing.T) {
+	r, out := newRecordingHookRunner(t, 20)
+
+	r.fire(HookPayload{Event: HookTrackChange, Title: "stale"})
+
+	// Another event (or setHooks) replaces the entry around the time the timer fires
+	r.mu.Lock()
+	replacement := time.AfterFunc(time.Hour, func() {})
+	defer replacement.Stop()
+	r.timers[0] = replacement
+	r.mu.Unlock()
+
+	time.Sleep(200 * time.Millisecond)
+	if got := readHookOutput(out); got != "" {
+		t.Errorf("replaced timer ran the hook: %q", got)
+	}
+	r.mu.Lock()
+	kept := r.timers[0] == replacement
+	r.mu.Unlock()
+	if !kept {
+		t.Error("replaced timer deleted the new entry")
+	}
+}
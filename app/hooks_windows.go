@@ -0,0 +1,26 @@
+//go:build windows
+
+package app
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// createNoWindow keeps console programs started by hooks from opening a window
+const createNoWindow = 0x08000000
+
+// hideConsole runs cmd without a console window. A "cmd /C <command>" hook
+// gets its command line as written: os/exec would quote the command with MSVC
+// rules, which cmd.exe does not understand, breaking quoted paths. With /S
+// cmd.exe strips only the outer quotes added here.
+func hideConsole(cmd *exec.Cmd) {
+	attr := &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: createNoWindow,
+	}
+	if len(cmd.Args) == 3 && cmd.Args[1] == "/C" {
+		attr.CmdLine = `cmd /S /C "` + cmd.Args[2] + `"`
+	}
+	cmd.SysProcAttr = attr
+}
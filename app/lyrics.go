@@ -0,0 +1,172 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"round-sound/media"
+)
+
+// lyricsTickInterval is how often the active lyric line is recomputed
+const lyricsTickInterval = 100 * time.Millisecond
+
+// LyricsUpdate is the media:lyrics payload. Index is -1 before the first line
+// and when the track has no lyrics.
+type LyricsUpdate struct {
+	PlayerID int               `json:"playerId"`
+	Index    int               `json:"index"`
+	Line     string            `json:"line"`
+	Next     string            `json:"next"`
+	Time     float64           `json:"time"` // start of the line, seconds
+	Words    []media.LyricWord `json:"words,omitempty"`
+}
+
+// GetLyricsDirs returns the folders searched for .lrc files
+func (a *App) GetLyricsDirs() []string {
+	if a.config.LyricsDirs == nil {
+		return []string{}
+	}
+	return a.config.LyricsDirs
+}
+
+// SetLyricsDirs replaces the lyrics folders and saves them to config
+func (a *App) SetLyricsDirs(dirs []string) error {
+	cleaned := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("folder not found: %s", dir)
+		}
+		cleaned = append(cleaned, dir)
+	}
+
+	a.config.LyricsDirs = cleaned
+	a.config.Save()
+	a.lyricsProvider.SetDirs(cleaned)
+
+	// Look the current track up again in the new folders
+	a.lyricsMu.Lock()
+	a.lyricsKey = ""
+	a.lyricsMu.Unlock()
+
+	a.mu.RLock()
+	player := a.activePlayer
+	a.mu.RUnlock()
+	a.updateLyricsTrack(player)
+
+	log.Printf("[App] Lyrics folders set to %v", cleaned)
+	return nil
+}
+
+// GetLyrics returns all lines of the current track's lyrics, or nil
+func (a *App) GetLyrics() *media.Lyrics {
+	a.lyricsMu.Lock()
+	defer a.lyricsMu.Unlock()
+	return a.lyrics
+}
+
+// updateLyricsTrack loads the lyrics when the displayed track changes
+func (a *App) updateLyricsTrack(player *media.Player) {
+	key := ""
+	if player != nil && player.Title != "" {
+		key = fmt.Sprintf("%d\x00%s\x00%s", player.ID, player.Artist, player.Title)
+	}
+
+	a.lyricsMu.Lock()
+	if key == a.lyricsKey {
+		a.lyricsMu.Unlock()
+		return
+	}
+	hadLyrics := a.lyrics != nil
+	a.lyricsKey = key
+	a.lyrics = nil
+	a.lyricsLine = -1
+	a.lyricsMu.Unlock()
+
+	if hadLyrics {
+		a.emitLyrics(LyricsUpdate{Index: -1})
+	}
+	if key == "" || len(a.config.LyricsDirs) == 0 {
+		return
+	}
+
+	go func() {
+		lyrics, err := a.lyricsProvider.Find(player.Artist, player.Title)
+		if err != nil {
+			if !errors.Is(err, media.ErrNoLyrics) {
+				log.Printf("[Lyrics] Failed to load lyrics for %s - %s: %v", player.Artist, player.Title, err)
+			}
+			return
+		}
+
+		a.lyricsMu.Lock()
+		if a.lyricsKey == key {
+			a.lyrics = lyrics
+		}
+		a.lyricsMu.Unlock()
+		a.tickLyrics(time.Now())
+	}()
+}
+
+// runLyricsTicker emits media:lyrics whenever the active line changes
+func (a *App) runLyricsTicker() {
+	ticker := time.NewTicker(lyricsTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.tickLyrics(now)
+		}
+	}
+}
+
+// tickLyrics finds the line at the extrapolated position and emits it if it changed
+func (a *App) tickLyrics(now time.Time) {
+	a.mu.RLock()
+	player := a.activePlayer
+	var position float64
+	if player != nil {
+		position = player.EstimatedPositionAt(now)
+	}
+	a.mu.RUnlock()
+
+	a.lyricsMu.Lock()
+	lyrics := a.lyrics
+	if lyrics == nil || player == nil {
+		a.lyricsMu.Unlock()
+		return
+	}
+	index := lyrics.LineAt(position)
+	if index == a.lyricsLine {
+		a.lyricsMu.Unlock()
+		return
+	}
+	a.lyricsLine = index
+	a.lyricsMu.Unlock()
+
+	update := LyricsUpdate{PlayerID: player.ID, Index: index}
+	if index >= 0 {
+		line := lyrics.Lines[index]
+		update.Line = line.Text
+		update.Time = line.Time
+		update.Words = line.Words
+	}
+	if index+1 < len(lyrics.Lines) {
+		update.Next = lyrics.Lines[index+1].Text
+	}
+	a.emitLyrics(update)
+}
+
+func (a *App) emitLyrics(update LyricsUpdate) {
+	a.emitMedia("media:lyrics", update)
+}
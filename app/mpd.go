@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"round-sound/media"
+)
+
+// startMPDSource adds the MPD player source when it is enabled
+func (a *App) startMPDSource() {
+	a.mpdMu.Lock()
+	defer a.mpdMu.Unlock()
+
+	if a.mpd != nil {
+		a.players.RemoveSource(a.mpd)
+		a.mpd.Stop()
+		a.mpd = nil
+	}
+	if !a.config.MPD.Enabled {
+		return
+	}
+	a.mpd = media.NewMPDSource(a.config.MPD, a.covers)
+	a.players.AddSource(a.mpd)
+}
+
+// GetMPDConfig returns the MPD connection settings
+func (a *App) GetMPDConfig() media.MPDConfig {
+	config := a.config.MPD
+	if config.Address == "" {
+		config.Address = media.DefaultMPDAddress
+	}
+	return config
+}
+
+// SetMPDConfig validates and saves the MPD settings and reconnects
+func (a *App) SetMPDConfig(config media.MPDConfig) error {
+	config.Address = strings.TrimSpace(config.Address)
+	if config.Address == "" {
+		config.Address = media.DefaultMPDAddress
+	}
+	if !strings.HasPrefix(config.Address, "/") {
+		if _, port, err := net.SplitHostPort(config.Address); err != nil || port == "" {
+			return fmt.Errorf("invalid address: %s (expected host:port or a socket path)", config.Address)
+		}
+	}
+
+	a.config.MPD = config
+	a.config.Save()
+	a.startMPDSource()
+
+	log.Printf("[App] MPD updated: enabled=%v, address=%s", config.Enabled, config.Address)
+	return nil
+}
+
+// GetMPDStatus returns the MPD connection state
+func (a *App) GetMPDStatus() media.MPDStatus {
+	a.mpdMu.Lock()
+	defer a.mpdMu.Unlock()
+
+	if a.mpd == nil {
+		return media.MPDStatus{}
+	}
+	return a.mpd.Status()
+}
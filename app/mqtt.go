@@ -0,0 +1,161 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"round-sound/media"
+)
+
+// startMQTT (re)starts the MQTT publisher when it is enabled
+func (a *App) startMQTT() {
+	a.mqttMu.Lock()
+	old := a.mqtt
+	a.mqtt = nil
+	a.mqttMu.Unlock()
+
+	// Stop outside the lock: it waits for the connection loop
+	if old != nil {
+		old.Stop()
+	}
+	if !a.config.MQTT.Enabled {
+		return
+	}
+
+	publisher := media.NewMQTTPublisher(a.config.MQTT, a.covers, a.onMQTTCommand)
+	a.mu.RLock()
+	player := a.reportedPlayer
+	a.mu.RUnlock()
+	publisher.PublishPlayer(player)
+
+	a.mqttMu.Lock()
+	a.mqtt = publisher
+	a.mqttMu.Unlock()
+}
+
+// publishMQTTPlayer sends the displayed player to the broker (nil = nothing is shown)
+func (a *App) publishMQTTPlayer(player *media.Player) {
+	a.mqttMu.Lock()
+	defer a.mqttMu.Unlock()
+
+	if a.mqtt != nil {
+		a.mqtt.PublishPlayer(player)
+	}
+}
+
+// publishMQTTAudio sends sound presence and the mean band level of a frame to the broker
+func (a *App) publishMQTTAudio(frame media.LevelFrame, now time.Time) {
+	a.mqttMu.Lock()
+	defer a.mqttMu.Unlock()
+
+	_, sounding := a.mqttSound.observe(frame, now)
+	if a.mqtt == nil {
+		return
+	}
+
+	level := 0.0
+	if !frame.Silence && len(frame.Levels) > 0 {
+		for _, band := range frame.Levels {
+			level += float64(band)
+		}
+		level /= float64(len(frame.Levels))
+	}
+	a.mqtt.PublishAudio(sounding, level)
+}
+
+// onMQTTCommand maps a message from the command topics to the media commands:
+// <prefix>/command takes play, pause, play_pause, next, previous, shuffle, repeat,
+// mute, unmute, toggle_mute, like and dislike; <prefix>/command/volume (0-100),
+// volume_level (0-1) and seek (seconds) take a number
+func (a *App) onMQTTCommand(cmd media.MQTTCommand) {
+	var err error
+	switch cmd.Name {
+	case "":
+		err = a.mqttAction(strings.ToLower(cmd.Payload))
+	case "volume", "volume_level", "seek":
+		var value float64
+		value, err = strconv.ParseFloat(cmd.Payload, 64)
+		if err != nil {
+			break
+		}
+		switch cmd.Name {
+		case "volume":
+			err = a.MediaSetVolume(int(math.Round(value)))
+		case "volume_level":
+			err = a.MediaSetVolume(int(math.Round(value * 100)))
+		case "seek":
+			err = a.MediaSeek(int(value))
+		}
+	default:
+		err = fmt.Errorf("unknown command topic: %s", cmd.Name)
+	}
+	if err != nil {
+		log.Printf("[MQTT] Command %q %q failed: %v", cmd.Name, cmd.Payload, err)
+	}
+}
+
+// mqttAction runs a command named by the payload of <prefix>/command
+func (a *App) mqttAction(action string) error {
+	switch action {
+	case "play":
+		return a.MediaPlay()
+	case "pause":
+		return a.MediaPause()
+	case "play_pause", "playpause":
+		return a.MediaTogglePlayPause()
+	case "next":
+		return a.MediaNext()
+	case "previous":
+		return a.MediaPrevious()
+	case "shuffle":
+		return a.MediaToggleShuffle()
+	case "repeat":
+		return a.MediaToggleRepeat()
+	case "mute":
+		return a.MediaMute()
+	case "unmute":
+		return a.MediaUnmute()
+	case "toggle_mute":
+		return a.MediaToggleMute()
+	case "like":
+		return a.MediaLike()
+	case "dislike":
+		return a.MediaDislike()
+	}
+	return fmt.Errorf("unknown command: %s", action)
+}
+
+// GetMQTTConfig returns the MQTT broker settings
+func (a *App) GetMQTTConfig() media.MQTTConfig {
+	return a.config.MQTT.Normalize()
+}
+
+// SetMQTTConfig validates and saves the MQTT settings and reconnects
+func (a *App) SetMQTTConfig(config media.MQTTConfig) error {
+	config = config.Normalize()
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	a.config.MQTT = config
+	a.config.Save()
+	a.startMQTT()
+
+	log.Printf("[App] MQTT updated: enabled=%v, broker=%s, prefix=%s", config.Enabled, config.Broker, config.TopicPrefix)
+	return nil
+}
+
+// GetMQTTStatus returns the MQTT connection state
+func (a *App) GetMQTTStatus() media.MQTTStatus {
+	a.mqttMu.Lock()
+	defer a.mqttMu.Unlock()
+
+	if a.mqtt == nil {
+		return media.MQTTStatus{}
+	}
+	return a.mqtt.Status()
+}
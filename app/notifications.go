@@ -0,0 +1,185 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"round-sound/media"
+)
+
+// defaultNotificationDelay is how long a track must stay before it is announced
+// when the config has no delay, so quick skips only show the last track
+const defaultNotificationDelay = 1500 * time.Millisecond
+
+// NotificationConfig holds the track change notification settings
+type NotificationConfig struct {
+	Enabled bool `json:"enabled"`
+	// DebounceMs waits for the track to settle before notifying (0 = 1500)
+	DebounceMs int `json:"debounceMs"`
+	// MutedPlayers never notify, matched by player name, case-insensitive
+	MutedPlayers []string `json:"mutedPlayers"`
+}
+
+func (c NotificationConfig) delay() time.Duration {
+	if c.DebounceMs <= 0 {
+		return defaultNotificationDelay
+	}
+	return time.Duration(c.DebounceMs) * time.Millisecond
+}
+
+// mutes reports whether notifications of a player are muted
+func (c NotificationConfig) mutes(player *media.Player) bool {
+	for _, name := range c.MutedPlayers {
+		if strings.EqualFold(strings.TrimSpace(name), player.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// trackNotifier shows a desktop notification when the displayed track changes
+type trackNotifier struct {
+	mu        sync.Mutex
+	config    NotificationConfig
+	notifier  *media.DesktopNotifier // nil where notifications are unsupported
+	covers    *media.CoverCache
+	lastTrack string
+	timer     *time.Timer
+	pending   *media.Player // latest state of the track the timer announces
+}
+
+func newTrackNotifier(config NotificationConfig, covers *media.CoverCache) *trackNotifier {
+	n := &trackNotifier{config: config, covers: covers}
+
+	notifier, err := media.NewDesktopNotifier()
+	if err != nil {
+		if !errors.Is(err, media.ErrNotificationsUnsupported) {
+			log.Printf("[Notifications] Not available: %v", err)
+		}
+		return n
+	}
+	n.notifier = notifier
+	return n
+}
+
+// setConfig replaces the settings, dropping a pending notification
+func (n *trackNotifier) setConfig(config NotificationConfig) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer, n.pending = nil, nil
+	}
+	n.config = config
+}
+
+// observePlayer schedules a notification when the displayed track changes (nil = players cleared)
+func (n *trackNotifier) observePlayer(player *media.Player) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if player == nil || player.Title == "" {
+		return
+	}
+	track := player.Artist + "\x00" + player.Title
+	if track == n.lastTrack {
+		// Updates while waiting (the cover arrives, the album is filled in) are shown
+		if n.timer != nil {
+			n.pending = player
+		}
+		return
+	}
+	n.lastTrack = track
+
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer, n.pending = nil, nil
+	}
+	if !n.config.Enabled || n.notifier == nil || n.config.mutes(player) {
+		return
+	}
+
+	// Restart the delay so only the last track of a burst of skips is shown
+	var timer *time.Timer
+	timer = time.AfterFunc(n.config.delay(), func() {
+		n.mu.Lock()
+		if n.timer != timer {
+			n.mu.Unlock()
+			return // replaced or cancelled after it fired
+		}
+		player := n.pending
+		n.timer, n.pending = nil, nil
+		n.mu.Unlock()
+		n.show(player)
+	})
+	n.timer, n.pending = timer, player
+}
+
+// show shows the notification for a track
+func (n *trackNotifier) show(player *media.Player) {
+	body := player.Artist
+	if player.Album != "" && player.Album != player.Title {
+		if body != "" {
+			body += " — "
+		}
+		body += player.Album
+	}
+
+	err := n.notifier.Show(media.Notification{
+		Summary:   player.Title,
+		Body:      body,
+		ImagePath: n.covers.LocalPath(player),
+	})
+	if err != nil {
+		log.Printf("[Notifications] Failed to show %q: %v", player.Title, err)
+	}
+}
+
+// close releases the notification service
+func (n *trackNotifier) close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer, n.pending = nil, nil
+	}
+	if n.notifier != nil {
+		n.notifier.Close()
+	}
+}
+
+// GetNotificationConfig returns the track change notification settings
+func (a *App) GetNotificationConfig() NotificationConfig {
+	config := a.config.Notifications
+	if config.MutedPlayers == nil {
+		config.MutedPlayers = []string{}
+	}
+	return config
+}
+
+// SetNotificationConfig validates and saves the track change notification settings
+func (a *App) SetNotificationConfig(config NotificationConfig) error {
+	if config.DebounceMs < 0 {
+		return fmt.Errorf("notification delay must not be negative")
+	}
+	muted := make([]string, 0, len(config.MutedPlayers))
+	for _, name := range config.MutedPlayers {
+		if name = strings.TrimSpace(name); name != "" {
+			muted = append(muted, name)
+		}
+	}
+	config.MutedPlayers = muted
+
+	a.config.Notifications = config
+	a.config.Save()
+	a.notifications.setConfig(config)
+
+	log.Printf("[App] Notifications updated: enabled=%v, %d muted player(s)", config.Enabled, len(config.MutedPlayers))
+	return nil
+}
@@ -0,0 +1,348 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"round-sound/media"
+)
+
+const (
+	// defaultNowPlayingInterval is the minimum time between writes when the config has none
+	defaultNowPlayingInterval = time.Second
+	// minNowPlayingInterval keeps configured intervals from hammering the disk
+	minNowPlayingInterval = 100 * time.Millisecond
+	// maxNowPlayingCover skips cover files that are too large to be artwork
+	maxNowPlayingCover = 16 << 20
+)
+
+// NowPlayingFile is one text file rendered from a template
+type NowPlayingFile struct {
+	Path string `json:"path"`
+	// Template uses {title}, {artist}, {album}, {player}, {source}, {state},
+	// {position}, {duration}, {remaining} and {volume}
+	Template string `json:"template"`
+}
+
+// NowPlayingConfig holds the files the displayed track is written to for external tools
+// (polybar, OBS text sources, Rainmeter). Empty paths are not written.
+type NowPlayingConfig struct {
+	Enabled bool             `json:"enabled"`
+	Files   []NowPlayingFile `json:"files"`
+	// JSONPath receives the full player snapshot ("null" without a player)
+	JSONPath string `json:"jsonPath"`
+	// CoverPath receives the cover image; it is removed while there is no local cover
+	CoverPath string `json:"coverPath"`
+	// IntervalMs is the minimum time between writes (0 = 1000)
+	IntervalMs int `json:"intervalMs"`
+}
+
+// nowPlayingPlaceholders lists the fields a template may use
+var nowPlayingPlaceholders = []string{
+	"title", "artist", "album", "player", "source", "state", "position", "duration", "remaining", "volume",
+}
+
+// Validate checks the paths and templates
+func (c NowPlayingConfig) Validate() error {
+	for _, file := range c.Files {
+		if strings.TrimSpace(file.Path) == "" {
+			return fmt.Errorf("now playing file without a path")
+		}
+		if !filepath.IsAbs(file.Path) {
+			return fmt.Errorf("now playing path must be absolute: %s", file.Path)
+		}
+		if err := validateNowPlayingTemplate(file.Template); err != nil {
+			return fmt.Errorf("%s: %w", file.Path, err)
+		}
+	}
+	for _, path := range []string{c.JSONPath, c.CoverPath} {
+		if path != "" && !filepath.IsAbs(path) {
+			return fmt.Errorf("now playing path must be absolute: %s", path)
+		}
+	}
+	if c.IntervalMs < 0 {
+		return fmt.Errorf("now playing interval must not be negative")
+	}
+	return nil
+}
+
+func (c NowPlayingConfig) interval() time.Duration {
+	if c.IntervalMs <= 0 {
+		return defaultNowPlayingInterval
+	}
+	return max(time.Duration(c.IntervalMs)*time.Millisecond, minNowPlayingInterval)
+}
+
+// showsPosition reports whether a file shows the playback position, which moves
+// without updates from the player while it plays
+func (c NowPlayingConfig) showsPosition() bool {
+	if c.JSONPath != "" {
+		return true // estimatedPosition
+	}
+	for _, file := range c.Files {
+		if strings.Contains(file.Template, "{position}") || strings.Contains(file.Template, "{remaining}") {
+			return true
+		}
+	}
+	return false
+}
+
+// validateNowPlayingTemplate rejects unknown or unclosed placeholders
+func validateNowPlayingTemplate(tmpl string) error {
+	for rest := tmpl; ; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			return nil
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return fmt.Errorf("unclosed placeholder in template: %q", rest[start:])
+		}
+		name := rest[start+1 : start+end]
+		known := false
+		for _, placeholder := range nowPlayingPlaceholders {
+			known = known || name == placeholder
+		}
+		if !known {
+			return fmt.Errorf("unknown placeholder in template: {%s}", name)
+		}
+		rest = rest[start+end+1:]
+	}
+}
+
+// renderNowPlaying fills a template for a player (nil = nothing playing renders as "")
+func renderNowPlaying(tmpl string, player *media.Player, now time.Time) string {
+	if player == nil || player.Title == "" {
+		return ""
+	}
+
+	position := int(player.EstimatedPositionAt(now))
+	replacer := strings.NewReplacer(
+		"{title}", player.Title,
+		"{artist}", player.Artist,
+		"{album}", player.Album,
+		"{player}", player.Name,
+		"{source}", player.Source,
+		"{state}", nowPlayingState(player.State),
+		"{position}", formatTrackTime(position),
+		"{duration}", formatTrackTime(player.Duration),
+		"{remaining}", formatTrackTime(max(player.Duration-position, 0)),
+		"{volume}", strconv.Itoa(player.Volume),
+	)
+	return replacer.Replace(tmpl)
+}
+
+// nowPlayingState names a playback state for templates
+func nowPlayingState(state media.StateMode) string {
+	switch state {
+	case media.StatePlaying:
+		return "playing"
+	case media.StatePaused:
+		return "paused"
+	default:
+		return "stopped"
+	}
+}
+
+// formatTrackTime formats seconds as m:ss, or h:mm:ss from an hour on
+func formatTrackTime(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// writeFileAtomic replaces path through a temporary file, so readers never see a partial write
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// nowPlayingWriter writes the displayed player to the configured files, at most once per interval
+type nowPlayingWriter struct {
+	covers  *media.CoverCache
+	mu      sync.Mutex
+	config  NowPlayingConfig
+	player  *media.Player
+	lastRun time.Time
+	timer   *time.Timer // pending write, nil when none is scheduled
+	written map[string][]byte
+}
+
+func newNowPlayingWriter(config NowPlayingConfig, covers *media.CoverCache) *nowPlayingWriter {
+	return &nowPlayingWriter{
+		covers:  covers,
+		config:  config,
+		written: make(map[string][]byte),
+	}
+}
+
+// setConfig replaces the config and rewrites every file
+func (w *nowPlayingWriter) setConfig(config NowPlayingConfig) {
+	w.mu.Lock()
+	w.config = config
+	w.written = make(map[string][]byte)
+	w.mu.Unlock()
+
+	w.schedule()
+}
+
+// observePlayer takes the displayed player (nil = players cleared) and schedules a write
+func (w *nowPlayingWriter) observePlayer(player *media.Player) {
+	w.mu.Lock()
+	w.player = player
+	w.mu.Unlock()
+
+	w.schedule()
+}
+
+// schedule writes now, or once the interval since the last write has passed.
+// Updates arriving in between only replace the player that will be written.
+// While a track plays and a file shows its position, every write schedules the
+// next one: MPD and MPRIS report no position updates between changes.
+func (w *nowPlayingWriter) schedule() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.config.Enabled || w.timer != nil {
+		return
+	}
+	delay := max(w.config.interval()-time.Since(w.lastRun), 0)
+	w.timer = time.AfterFunc(delay, w.flush)
+}
+
+// flush writes the latest player to every configured file
+func (w *nowPlayingWriter) flush() {
+	w.mu.Lock()
+	w.timer = nil
+	w.lastRun = time.Now()
+	config, player := w.config, w.player
+	w.mu.Unlock()
+
+	if !config.Enabled {
+		return
+	}
+
+	now := time.Now()
+	for _, file := range config.Files {
+		w.write(file.Path, []byte(renderNowPlaying(file.Template, player, now)))
+	}
+
+	if config.JSONPath != "" {
+		var snapshot *media.Player
+		if player != nil {
+			snapshot = player.Snapshot(now)
+		}
+		data, err := json.MarshalIndent(snapshot, "", "  ")
+		if err != nil {
+			log.Printf("[NowPlaying] Failed to encode player: %v", err)
+		} else {
+			w.write(config.JSONPath, data)
+		}
+	}
+
+	if config.CoverPath != "" {
+		if cover := w.covers.ReadLocal(player, maxNowPlayingCover); cover != nil {
+			w.write(config.CoverPath, cover)
+		} else {
+			w.remove(config.CoverPath)
+		}
+	}
+
+	if player != nil && player.State == media.StatePlaying && config.showsPosition() {
+		w.schedule()
+	}
+}
+
+// write replaces a file unless it already holds data
+func (w *nowPlayingWriter) write(path string, data []byte) {
+	w.mu.Lock()
+	last, ok := w.written[path]
+	w.mu.Unlock()
+	if ok && bytes.Equal(last, data) {
+		return
+	}
+
+	if err := writeFileAtomic(path, data); err != nil {
+		log.Printf("[NowPlaying] Failed to write %s: %v", path, err)
+		return
+	}
+
+	w.mu.Lock()
+	w.written[path] = data
+	w.mu.Unlock()
+}
+
+// remove deletes a file written before, e.g. the cover of the previous track
+func (w *nowPlayingWriter) remove(path string) {
+	w.mu.Lock()
+	last, ok := w.written[path]
+	w.mu.Unlock()
+	if ok && last == nil {
+		return
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("[NowPlaying] Failed to remove %s: %v", path, err)
+		return
+	}
+
+	w.mu.Lock()
+	w.written[path] = nil
+	w.mu.Unlock()
+}
+
+// GetNowPlayingConfig returns the now playing file settings
+func (a *App) GetNowPlayingConfig() NowPlayingConfig {
+	config := a.config.NowPlaying
+	if config.Files == nil {
+		config.Files = []NowPlayingFile{}
+	}
+	return config
+}
+
+// SetNowPlayingConfig validates and saves the now playing file settings and rewrites the files
+func (a *App) SetNowPlayingConfig(config NowPlayingConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	a.config.NowPlaying = config
+	a.config.Save()
+	a.nowPlaying.setConfig(config)
+
+	log.Printf("[App] Now playing files: enabled=%v, %d text file(s)", config.Enabled, len(config.Files))
+	return nil
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"round-sound/media"
+)
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestValidateNowPlayingTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		ok   bool
+	}{
+		{"", true},
+		{"plain text", true},
+		{"{artist} — {title} [{position}/{duration}]", true},
+		{"{remaining} left at {volume}% in {player} ({source}, {state}) from {album}", true},
+		{"{Title}", false},
+		{"{title} {bitrate}", false},
+		{"{title", false},
+		{"{}", false},
+		{"} {title}", true},
+	}
+	for _, tt := range tests {
+		if err := validateNowPlayingTemplate(tt.tmpl); (err == nil) != tt.ok {
+			t.Errorf("validateNowPlayingTemplate(%q) = %v, want ok %v", tt.tmpl, err, tt.ok)
+		}
+	}
+}
+
+func TestRenderNowPlaying(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	player := &media.Player{Name: "Spotify", Source: media.SourceMPD, Title: "Song", Artist: "Artist", Album: "Album",
+		State: media.StatePlaying, Duration: 3725, Volume: 40, PlaybackRate: 1}
+	player.RebasePosition(61, now.Add(-4*time.Second))
+
+	tests := []struct {
+		name   string
+		tmpl   string
+		player *media.Player
+		want   string
+	}{
+		{"fields", "{artist} — {title} [{position}/{duration}]", player, "Artist — Song [1:05/1:02:05]"},
+		{"remaining", "-{remaining} {state} {volume}% {player}/{source} {album}", player, "-1:01:00 playing 40% Spotify/mpd Album"},
+		{"no player", "{artist} — {title}", nil, ""},
+		{"no title", "{artist}", &media.Player{Artist: "Artist"}, ""},
+		{"paused", "{state}", &media.Player{Title: "Song", State: media.StatePaused}, "paused"},
+		{"stopped", "{state}", &media.Player{Title: "Song", State: media.StateStopped}, "stopped"},
+		{"literal braces", "{title} {x}", player, "Song {x}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderNowPlaying(tt.tmpl, tt.player, now); got != tt.want {
+				t.Errorf("renderNowPlaying(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "now.txt")
+
+	// Creates missing directories and replaces the previous content
+	for _, content := range []string{"first, longer content", "second"} {
+		if err := writeFileAtomic(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if data, err := os.ReadFile(path); err != nil || string(data) != content {
+			t.Errorf("file = %q (%v), want %q", data, err, content)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v (%v)", info.Mode(), err)
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("directory holds %v (%v)", entries, err)
+	}
+
+	// A directory in the way fails without leaving a temporary file
+	if err := writeFileAtomic(dir, []byte("x")); err == nil {
+		t.Error("replaced a directory")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dir)); len(entries) != 1 {
+		t.Errorf("parent holds %v", entries)
+	}
+}
+
+func TestNowPlayingWriterRefreshesPosition(t *testing.T) {
+	dir := t.TempDir()
+	text, snapshot := filepath.Join(dir, "now.txt"), filepath.Join(dir, "now.json")
+	w := newNowPlayingWriter(NowPlayingConfig{
+		Enabled:    true,
+		Files:      []NowPlayingFile{{Path: text, Template: "{title} {position}"}},
+		JSONPath:   snapshot,
+		IntervalMs: 100,
+	}, nil)
+	t.Cleanup(func() { w.setConfig(NowPlayingConfig{}) })
+	read := func(path string) string {
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+
+	// A playing track is rewritten without further updates, as MPD and MPRIS send none
+	player := &media.Player{Title: "Song", State: media.StatePlaying, Duration: 200, PlaybackRate: 1}
+	player.RebasePosition(10, time.Now())
+	w.observePlayer(player)
+	waitFor(t, func() bool { return read(text) == "Song 0:10" })
+	waitFor(t, func() bool { return read(text) == "Song 0:11" })
+
+	snapshotAt := func(state media.StateMode, position float64) bool {
+		var written media.Player
+		err := json.Unmarshal([]byte(read(snapshot)), &written)
+		return err == nil && written.State == state && written.EstimatedPosition >= position
+	}
+	waitFor(t, func() bool { return snapshotAt(media.StatePlaying, 11) })
+
+	// A paused track stays as written
+	paused := player.Clone()
+	paused.State = media.StatePaused
+	paused.RebasePosition(20, time.Now())
+	w.observePlayer(paused)
+	waitFor(t, func() bool { return read(text) == "Song 0:20" && snapshotAt(media.StatePaused, 20) })
+	before := read(snapshot)
+	time.Sleep(300 * time.Millisecond)
+	if after := read(snapshot); after != before {
+		t.Errorf("paused snapshot rewritten:\n%s\n%s", before, after)
+	}
+	if read(text) != "Song 0:20" {
+		t.Errorf("paused text = %q", read(text))
+	}
+}
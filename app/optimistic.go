@@ -0,0 +1,270 @@
+package app
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"round-sound/media"
+)
+
+// commandConfirmTimeout is how long an optimistic change waits for the player to confirm it
+const commandConfirmTimeout = 3 * time.Second
+
+// seekTolerance is how far (seconds) a reported position may be from the seek target
+// and still confirm it (the track keeps playing while the command travels)
+const seekTolerance = 4
+
+// pendingCommand is an optimistic change waiting for the player to confirm it
+type pendingCommand struct {
+	seq      uint64 // send order
+	eventID  string
+	playerID int
+	cmd      media.Command
+	expected *media.Player // player state the command should produce
+	timer    *time.Timer
+}
+
+// CommandFailure is the media:command_failed payload
+type CommandFailure struct {
+	PlayerID int               `json:"playerId"`
+	Kind     media.CommandKind `json:"kind"`
+	Error    string            `json:"error"`
+}
+
+// expectedState returns the player as it should look after cmd, or false when
+// the outcome is not predictable (track skips)
+func expectedState(player *media.Player, cmd media.Command) (*media.Player, bool) {
+	expected := player.Clone()
+	switch cmd.Kind {
+	case media.CommandPlay:
+		expected.State = media.StatePlaying
+	case media.CommandPause:
+		expected.State = media.StatePaused
+	case media.CommandTogglePlayPause:
+		if player.State == media.StatePlaying {
+			expected.State = media.StatePaused
+		} else {
+			expected.State = media.StatePlaying
+		}
+	case media.CommandToggleShuffle:
+		expected.Shuffle = !player.Shuffle
+	case media.CommandToggleRepeat:
+		expected.Repeat = media.NextRepeatMode(player)
+	case media.CommandSetRepeat:
+		expected.Repeat = media.RepeatMode(cmd.Value)
+	case media.CommandSetRating, media.CommandLike, media.CommandDislike, media.CommandClearRating,
+		media.CommandToggleLike, media.CommandSetStars:
+		rating, err := media.RatingFor(player, cmd)
+		if err != nil {
+			return nil, false
+		}
+		expected.Rating = rating
+	case media.CommandSeek:
+		expected.Position = max(cmd.Value, 0)
+		if player.Duration > 0 {
+			expected.Position = min(expected.Position, player.Duration)
+		}
+		expected.RebasePosition(float64(expected.Position), time.Now())
+		expected.EstimatedPosition = float64(expected.Position)
+	case media.CommandSetVolume:
+		expected.Volume = min(max(cmd.Value, 0), 100)
+	default:
+		return nil, false
+	}
+	return expected, true
+}
+
+// overlay copies the field changed by the pending command onto player
+func (p *pendingCommand) overlay(player *media.Player) {
+	switch p.cmd.Kind {
+	case media.CommandPlay, media.CommandPause, media.CommandTogglePlayPause:
+		player.State = p.expected.State
+	case media.CommandToggleShuffle:
+		player.Shuffle = p.expected.Shuffle
+	case media.CommandToggleRepeat, media.CommandSetRepeat:
+		player.Repeat = p.expected.Repeat
+	case media.CommandSetRating, media.CommandLike, media.CommandDislike, media.CommandClearRating,
+		media.CommandToggleLike, media.CommandSetStars:
+		player.Rating = p.expected.Rating
+	case media.CommandSeek:
+		// Extrapolate from the seek target as of when the command was sent
+		player.Position = p.expected.Position
+		player.RebasePosition(float64(p.expected.Position), time.UnixMilli(p.expected.PositionAt))
+		player.EstimatedPosition = player.EstimatedPositionAt(time.Now())
+	case media.CommandSetVolume:
+		player.Volume = p.expected.Volume
+	}
+}
+
+// confirmedBy reports whether the real player state reflects the pending change
+func (p *pendingCommand) confirmedBy(player *media.Player) bool {
+	switch p.cmd.Kind {
+	case media.CommandPlay, media.CommandPause, media.CommandTogglePlayPause:
+		return player.State == p.expected.State
+	case media.CommandToggleShuffle:
+		return player.Shuffle == p.expected.Shuffle
+	case media.CommandToggleRepeat, media.CommandSetRepeat:
+		return player.Repeat == p.expected.Repeat
+	case media.CommandSetRating, media.CommandLike, media.CommandDislike, media.CommandClearRating,
+		media.CommandToggleLike, media.CommandSetStars:
+		return player.Rating == p.expected.Rating
+	case media.CommandSeek:
+		diff := player.Position - p.expected.Position
+		return diff >= -seekTolerance && diff <= seekTolerance
+	case media.CommandSetVolume:
+		return player.Volume == p.expected.Volume
+	}
+	return true
+}
+
+// applyOptimistic records the expected result of a sent command and shows it right away
+func (a *App) applyOptimistic(eventID string, player *media.Player, cmd media.Command) {
+	expected, ok := expectedState(player, cmd)
+	if !ok {
+		return
+	}
+
+	pending := &pendingCommand{
+		eventID:  eventID,
+		playerID: player.ID,
+		cmd:      cmd,
+		expected: expected,
+	}
+	pending.timer = time.AfterFunc(commandConfirmTimeout, func() {
+		a.expirePending(eventID)
+	})
+
+	a.pendingMu.Lock()
+	a.pendingSeq++
+	pending.seq = a.pendingSeq
+	a.pending[eventID] = pending
+	a.pendingMu.Unlock()
+
+	a.republishPlayer(player.ID, nil)
+}
+
+// withPending returns a copy of player with all unconfirmed changes applied
+func (a *App) withPending(player *media.Player) *media.Player {
+	if player == nil {
+		return nil
+	}
+
+	a.pendingMu.Lock()
+	defer a.pendingMu.Unlock()
+
+	result := player.Clone()
+	for _, pending := range a.pendingInOrder(player.ID) {
+		pending.overlay(result)
+	}
+	return result
+}
+
+// pendingInOrder returns the player's pending commands oldest first. Caller must hold pendingMu.
+func (a *App) pendingInOrder(playerID int) []*pendingCommand {
+	var result []*pendingCommand
+	for _, pending := range a.pending {
+		if pending.playerID == playerID {
+			result = append(result, pending)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].seq < result[j].seq
+	})
+	return result
+}
+
+// reconcile drops pending changes the real player state now confirms
+func (a *App) reconcile(player *media.Player) {
+	a.pendingMu.Lock()
+	defer a.pendingMu.Unlock()
+
+	for eventID, pending := range a.pending {
+		if pending.playerID == player.ID && pending.confirmedBy(player) {
+			pending.timer.Stop()
+			delete(a.pending, eventID)
+			log.Printf("[App] %s confirmed by player %d", pending.cmd, player.ID)
+		}
+	}
+}
+
+// onEventResult rolls back an optimistic change the player reported as failed
+func (a *App) onEventResult(eventID string, status media.EventStatus) {
+	if status == media.EventSucceeded {
+		// Wait for the player update that carries the new state
+		return
+	}
+
+	a.pendingMu.Lock()
+	pending, ok := a.pending[eventID]
+	a.pendingMu.Unlock()
+
+	if ok {
+		a.rollback(pending, status.String())
+	}
+}
+
+// expirePending resolves a change the player did not confirm in time
+func (a *App) expirePending(eventID string) {
+	a.pendingMu.Lock()
+	pending, ok := a.pending[eventID]
+	a.pendingMu.Unlock()
+	if !ok {
+		return
+	}
+
+	if real := a.players.GetPlayer(pending.playerID); real != nil && pending.confirmedBy(real) {
+		a.pendingMu.Lock()
+		delete(a.pending, eventID)
+		a.pendingMu.Unlock()
+		return
+	}
+	a.rollback(pending, "timeout")
+}
+
+// rollback drops a pending change, reports the failure and shows the real state again
+func (a *App) rollback(pending *pendingCommand, reason string) {
+	a.pendingMu.Lock()
+	pending.timer.Stop()
+	delete(a.pending, pending.eventID)
+	a.pendingMu.Unlock()
+
+	log.Printf("[App] %s on player %d rolled back: %s", pending.cmd, pending.playerID, reason)
+
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "media:command_failed", CommandFailure{
+			PlayerID: pending.playerID,
+			Kind:     pending.cmd.Kind,
+			Error:    reason,
+		})
+	}
+
+	a.republishPlayer(pending.playerID, a.players.GetPlayer(pending.playerID))
+}
+
+// republishPlayer re-emits the displayed player with pending changes applied when it is
+// playerID. base replaces the displayed state when given.
+func (a *App) republishPlayer(playerID int, base *media.Player) {
+	a.mu.RLock()
+	displayed := a.activePlayer
+	a.mu.RUnlock()
+
+	if displayed == nil || displayed.ID != playerID {
+		return
+	}
+	if base == nil {
+		base = displayed
+	}
+
+	player := a.withPending(base)
+
+	a.mu.Lock()
+	if a.activePlayer != nil && a.activePlayer.ID == playerID {
+		a.activePlayer = player
+	}
+	a.mu.Unlock()
+
+	a.emitMedia("media:update", player)
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"log"
+
+	"round-sound/media"
+)
+
+// startOSC (re)creates the OSC sender when it is enabled
+func (a *App) startOSC() error {
+	a.oscMu.Lock()
+	defer a.oscMu.Unlock()
+
+	if a.osc != nil {
+		a.osc.Close()
+		a.osc = nil
+	}
+	if !a.config.OSC.Enabled {
+		return nil
+	}
+
+	sender, err := media.NewOSCSender(a.config.OSC)
+	if err != nil {
+		return err
+	}
+	a.osc = sender
+
+	a.mu.RLock()
+	player := a.reportedPlayer
+	a.mu.RUnlock()
+	sender.SendPlayer(player)
+	return nil
+}
+
+// sendOSCLevels forwards a level frame to the OSC sender
+func (a *App) sendOSCLevels(frame media.LevelFrame) {
+	a.oscMu.Lock()
+	defer a.oscMu.Unlock()
+
+	if a.osc != nil {
+		a.osc.SendLevels(frame)
+	}
+}
+
+// sendOSCPlayer forwards the displayed player to the OSC sender (nil = nothing is shown)
+func (a *App) sendOSCPlayer(player *media.Player) {
+	a.oscMu.Lock()
+	defer a.oscMu.Unlock()
+
+	if a.osc != nil {
+		a.osc.SendPlayer(player)
+	}
+}
+
+// GetOSCConfig returns the OSC output settings
+func (a *App) GetOSCConfig() media.OSCConfig {
+	return a.config.OSC.Normalize()
+}
+
+// SetOSCConfig validates and saves the OSC output settings and recreates the sender
+func (a *App) SetOSCConfig(config media.OSCConfig) error {
+	config = config.Normalize()
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	a.config.OSC = config
+	a.config.Save()
+
+	if err := a.startOSC(); err != nil {
+		log.Printf("[OSC] Failed to start sending to %s:%d: %v", config.Host, config.Port, err)
+		return fmt.Errorf("failed to start OSC output: %w", err)
+	}
+	log.Printf("[App] OSC updated: enabled=%v, target=%s:%d", config.Enabled, config.Host, config.Port)
+	return nil
+}
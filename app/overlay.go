@@ -0,0 +1,309 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// DefaultOverlayPort is the default port of the OBS overlay server
+const DefaultOverlayPort = 8975
+
+// overlayClientBuffer is how many events may wait for a slow overlay before new ones are dropped
+const overlayClientBuffer = 64
+
+// OverlayConfig holds the settings of the local overlay server for OBS browser sources
+type OverlayConfig struct {
+	Enabled bool `json:"enabled"`
+	Port    int  `json:"port"`
+}
+
+// overlayMessage is one event sent to overlay pages, mirroring the Wails events
+type overlayMessage struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+// overlayServer serves the overlay page and streams player and level events over WebSocket
+type overlayServer struct {
+	app      *App
+	server   *http.Server
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	clients map[*overlayClient]struct{}
+}
+
+// overlayClient is one connected overlay page
+type overlayClient struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// startOverlay (re)starts the overlay server when it is enabled
+func (a *App) startOverlay() error {
+	a.overlayMu.Lock()
+	defer a.overlayMu.Unlock()
+
+	if a.overlay != nil {
+		a.overlay.stop()
+		a.overlay = nil
+	}
+	if !a.config.Overlay.Enabled {
+		return nil
+	}
+
+	overlay, err := newOverlayServer(a, a.config.Overlay.Port)
+	if err != nil {
+		return err
+	}
+	a.overlay = overlay
+	log.Printf("[Overlay] Serving on http://127.0.0.1:%d/", a.config.Overlay.Port)
+	return nil
+}
+
+// GetOverlayConfig returns the overlay server settings
+func (a *App) GetOverlayConfig() OverlayConfig {
+	config := a.config.Overlay
+	if config.Port == 0 {
+		config.Port = DefaultOverlayPort
+	}
+	return config
+}
+
+// SetOverlayConfig validates and saves the overlay server settings and restarts it
+func (a *App) SetOverlayConfig(config OverlayConfig) error {
+	if config.Port == 0 {
+		config.Port = DefaultOverlayPort
+	}
+	if config.Port < 1024 || config.Port > 65535 {
+		return fmt.Errorf("invalid port: must be between 1024 and 65535")
+	}
+	if config.Enabled && config.Port == a.config.WNPPort {
+		return fmt.Errorf("port %d is used by WebNowPlaying", config.Port)
+	}
+
+	a.config.Overlay = config
+	a.config.Save()
+
+	if err := a.startOverlay(); err != nil {
+		log.Printf("[Overlay] Failed to start on port %d: %v", config.Port, err)
+		return fmt.Errorf("failed to start overlay server: %w", err)
+	}
+	return nil
+}
+
+// GetOverlayURL returns the address of the overlay page, or "" while the server is off
+func (a *App) GetOverlayURL() string {
+	a.overlayMu.Lock()
+	defer a.overlayMu.Unlock()
+
+	if a.overlay == nil {
+		return ""
+	}
+	return fmt.Sprintf("http://127.0.0.1:%d/", a.config.Overlay.Port)
+}
+
+// emitMedia sends a player, lyrics or level event to the UI and to connected overlays
+func (a *App) emitMedia(event string, data ...interface{}) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, event, data...)
+	}
+
+	a.overlayMu.Lock()
+	overlay := a.overlay
+	a.overlayMu.Unlock()
+
+	if overlay != nil {
+		var payload interface{}
+		if len(data) > 0 {
+			payload = data[0]
+		}
+		overlay.broadcast(overlayMessage{Event: event, Data: payload})
+	}
+}
+
+// newOverlayServer listens on 127.0.0.1:port and serves in the background
+func newOverlayServer(a *App, port int) (*overlayServer, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &overlayServer{
+		app:     a,
+		clients: make(map[*overlayClient]struct{}),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return true // OBS browser sources may use any origin
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("/nowplaying.txt", s.handleNowPlaying)
+	mux.HandleFunc("/cover", s.handleCover)
+	if a.assets != nil {
+		mux.Handle("/", overlayPageHandler(a.assets))
+	}
+	s.server = &http.Server{Handler: mux}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[Overlay] Server error: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// overlayPageHandler serves the built frontend with overlay.html as the index
+func overlayPageHandler(assets fs.FS) http.Handler {
+	files := http.FileServer(http.FS(assets))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			r.URL.Path = "/overlay.html"
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// stop closes the server and every overlay connection
+func (s *overlayServer) stop() {
+	s.server.Close()
+
+	s.mu.Lock()
+	for client := range s.clients {
+		client.conn.Close()
+	}
+	s.clients = make(map[*overlayClient]struct{})
+	s.mu.Unlock()
+}
+
+// handleWebSocket streams events to an overlay page, starting with the current player
+func (s *overlayServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("[Overlay] WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	client := &overlayClient{conn: conn, send: make(chan []byte, overlayClientBuffer)}
+
+	s.app.mu.RLock()
+	player := s.app.activePlayer
+	s.app.mu.RUnlock()
+	if player != nil {
+		client.queue(overlayMessage{Event: "media:update", Data: player})
+	} else {
+		client.queue(overlayMessage{Event: "media:cleared"})
+	}
+
+	s.mu.Lock()
+	s.clients[client] = struct{}{}
+	count := len(s.clients)
+	s.mu.Unlock()
+	log.Printf("[Overlay] Client connected from %s (%d total)", r.RemoteAddr, count)
+
+	go client.writeLoop()
+
+	// Overlays only listen; reading detects the disconnect
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.clients, client)
+	s.mu.Unlock()
+	close(client.send)
+	conn.Close()
+}
+
+// broadcast sends msg to every overlay, dropping it for clients that fall behind
+func (s *overlayServer) broadcast(msg overlayMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.clients) == 0 {
+		return
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("[Overlay] Failed to encode %s: %v", msg.Event, err)
+		return
+	}
+	for client := range s.clients {
+		select {
+		case client.send <- data:
+		default:
+		}
+	}
+}
+
+// queue adds a message before the client is registered
+func (c *overlayClient) queue(msg overlayMessage) {
+	if data, err := json.Marshal(msg); err == nil {
+		c.send <- data
+	}
+}
+
+// writeLoop writes queued events until the client goes away
+func (c *overlayClient) writeLoop() {
+	for data := range c.send {
+		if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			c.conn.Close()
+			return
+		}
+	}
+}
+
+// handleNowPlaying returns "Artist - Title" of the displayed track for OBS text sources
+func (s *overlayServer) handleNowPlaying(w http.ResponseWriter, r *http.Request) {
+	s.app.mu.RLock()
+	player := s.app.activePlayer
+	s.app.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if player == nil {
+		return
+	}
+
+	parts := make([]string, 0, 2)
+	if player.Artist != "" {
+		parts = append(parts, player.Artist)
+	}
+	if player.Title != "" {
+		parts = append(parts, player.Title)
+	}
+	fmt.Fprint(w, strings.Join(parts, " - "))
+}
+
+// handleCover returns the cover of the displayed track, or redirects to its URL
+func (s *overlayServer) handleCover(w http.ResponseWriter, r *http.Request) {
+	s.app.mu.RLock()
+	player := s.app.activePlayer
+	s.app.mu.RUnlock()
+
+	w.Header().Set("Cache-Control", "no-store")
+	path := s.app.covers.LocalPath(player)
+	switch {
+	case path != "":
+		http.ServeFile(w, r, path)
+	case player != nil && (strings.HasPrefix(player.Cover, "http://") || strings.HasPrefix(player.Cover, "https://")):
+		http.Redirect(w, r, player.Cover, http.StatusFound)
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"round-sound/media"
+)
+
+// GetPlayerRules returns the player selection rules
+func (a *App) GetPlayerRules() media.PlayerRules {
+	return a.config.PlayerRules
+}
+
+// SetPlayerRules replaces the player selection rules and saves them to config
+func (a *App) SetPlayerRules(rules media.PlayerRules) error {
+	if rules.StickySeconds < 0 {
+		return fmt.Errorf("invalid sticky period: must not be negative")
+	}
+
+	a.config.PlayerRules = rules.Normalize()
+	a.config.Save()
+	a.players.SetRules(a.config.PlayerRules)
+
+	log.Printf("[App] Player rules updated: %+v", a.config.PlayerRules)
+	return nil
+}
+
+// PinCurrentPlayer keeps the currently shown player on screen and remembers its site
+func (a *App) PinCurrentPlayer() error {
+	a.mu.RLock()
+	player := a.activePlayer
+	a.mu.RUnlock()
+
+	if player == nil {
+		return fmt.Errorf("no active player")
+	}
+
+	a.config.PlayerRules.Pinned = player.Name
+	a.config.Save()
+
+	a.players.SetRules(a.config.PlayerRules)
+	a.players.PinPlayer(player.ID)
+
+	log.Printf("[App] Pinned player %d (%s)", player.ID, player.Name)
+	return nil
+}
+
+// UnpinPlayer removes the manual pin and the pinned site
+func (a *App) UnpinPlayer() {
+	a.config.PlayerRules.Pinned = ""
+	a.config.Save()
+
+	a.players.SetRules(a.config.PlayerRules)
+	a.players.PinPlayer(0)
+
+	log.Println("[App] Player unpinned")
+}
+
+// GetPlayers returns every known player of all sources, not only the displayed one
+func (a *App) GetPlayers() []*media.Player {
+	return a.players.GetPlayers()
+}
+
+// GetControlledPlayer returns the ID of the player receiving media commands (0 = follows the active player)
+func (a *App) GetControlledPlayer() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.controlledPlayerID
+}
+
+// SetControlledPlayer sends media commands to the given player without making it the displayed one (0 = follow active)
+func (a *App) SetControlledPlayer(playerID int) error {
+	if playerID != 0 && a.players.GetPlayer(playerID) == nil {
+		return fmt.Errorf("unknown player: %d", playerID)
+	}
+
+	a.mu.Lock()
+	a.controlledPlayerID = playerID
+	a.mu.Unlock()
+
+	log.Printf("[App] Controlled player set to %d", playerID)
+	a.emitControlledPlayer(playerID)
+	return nil
+}
+
+// onPlayersChanged is called with the full player list whenever any player changes
+func (a *App) onPlayersChanged(players []*media.Player) {
+	for _, player := range players {
+		a.reconcile(player)
+	}
+
+	// Fall back to the active player once the controlled one goes away
+	a.mu.Lock()
+	reset := a.controlledPlayerID != 0 && !containsPlayer(players, a.controlledPlayerID)
+	if reset {
+		a.controlledPlayerID = 0
+	}
+	for playerID := range a.preMuteVolume {
+		if !containsPlayer(players, playerID) {
+			delete(a.preMuteVolume, playerID)
+		}
+	}
+	a.mu.Unlock()
+
+	if reset {
+		log.Println("[App] Controlled player gone, following active player")
+		a.emitControlledPlayer(0)
+	}
+
+	if a.mpris != nil {
+		a.mpris.Sync(players)
+	}
+
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "media:players", players)
+	}
+}
+
+// emitControlledPlayer notifies the frontend about the controlled player selection
+func (a *App) emitControlledPlayer(playerID int) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "media:controlled", playerID)
+	}
+}
+
+// controlledPlayerLocked returns the player media commands go to. Caller must hold a.mu.
+func (a *App) controlledPlayerLocked() *media.Player {
+	if a.controlledPlayerID != 0 {
+		if player := a.players.GetPlayer(a.controlledPlayerID); player != nil {
+			return player
+		}
+	}
+	return a.activePlayer
+}
+
+func containsPlayer(players []*media.Player, playerID int) bool {
+	for _, player := range players {
+		if player.ID == playerID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"round-sound/media"
+)
+
+// maxPositionTickHz caps media:position ticks (the widget redraws at display rate anyway)
+const maxPositionTickHz = 30
+
+// PositionTick is the lightweight media:position payload
+type PositionTick struct {
+	PlayerID int             `json:"playerId"`
+	Position float64         `json:"position"` // seconds, extrapolated
+	Duration int             `json:"duration"`
+	State    media.StateMode `json:"state"`
+}
+
+// GetPositionTickRate returns the media:position tick rate in Hz (0 = off)
+func (a *App) GetPositionTickRate() int {
+	return a.config.PositionTickHz
+}
+
+// SetPositionTickRate changes the media:position tick rate and saves it to config
+func (a *App) SetPositionTickRate(hz int) error {
+	if hz < 0 || hz > maxPositionTickHz {
+		return fmt.Errorf("invalid tick rate: must be between 0 and %d", maxPositionTickHz)
+	}
+
+	a.config.PositionTickHz = hz
+	a.config.Save()
+
+	// Replace any pending rate change with the new one
+	select {
+	case <-a.positionTickRate:
+	default:
+	}
+	a.positionTickRate <- hz
+
+	log.Printf("[App] Position tick rate set to %d Hz", hz)
+	return nil
+}
+
+// runPositionTicker emits media:position at the configured rate while the active player is playing
+func (a *App) runPositionTicker(hz int) {
+	var ticker *time.Ticker
+	var tickC <-chan time.Time
+
+	reset := func(hz int) {
+		if ticker != nil {
+			ticker.Stop()
+			ticker, tickC = nil, nil
+		}
+		if hz > 0 {
+			ticker = time.NewTicker(time.Second / time.Duration(hz))
+			tickC = ticker.C
+		}
+	}
+	reset(hz)
+	defer reset(0)
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case hz := <-a.positionTickRate:
+			reset(hz)
+		case now := <-tickC:
+			a.emitPositionTick(now)
+		}
+	}
+}
+
+// emitPositionTick sends the extrapolated position of the active player
+func (a *App) emitPositionTick(now time.Time) {
+	a.mu.RLock()
+	player := a.activePlayer
+	var tick PositionTick
+	if player != nil {
+		tick = PositionTick{
+			PlayerID: player.ID,
+			Position: player.EstimatedPositionAt(now),
+			Duration: player.Duration,
+			State:    player.State,
+		}
+	}
+	a.mu.RUnlock()
+
+	if player == nil || player.State != media.StatePlaying {
+		return
+	}
+	a.emitMedia("media:position", tick)
+}
@@ -0,0 +1,216 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"round-sound/media"
+)
+
+const (
+	// defaultRecordingDuration is used when StartRecording gets no explicit cap
+	defaultRecordingDuration = 5 * time.Minute
+	// maxRecordingDuration caps any recording (~1.4GB of 48kHz stereo float PCM).
+	// Streams with more channels stop earlier, at the 4 GiB WAV size limit
+	maxRecordingDuration = time.Hour
+)
+
+// getRecordingsDir returns the directory for audio debug recordings
+func getRecordingsDir() string {
+	dir := filepath.Join(getConfigDir(), "recordings")
+	os.MkdirAll(dir, 0755)
+	return dir
+}
+
+// StartRecording starts recording captured loopback audio (WAV) and emitted level
+// frames with player metadata (JSONL). maxSeconds <= 0 uses the default cap.
+func (a *App) StartRecording(maxSeconds int) (media.RecordingStatus, error) {
+	a.recMu.Lock()
+	defer a.recMu.Unlock()
+
+	if a.audioCapture == nil {
+		return media.RecordingStatus{}, fmt.Errorf("audio capture is not running")
+	}
+	if a.replayCapture != nil {
+		return media.RecordingStatus{}, fmt.Errorf("cannot record while replaying")
+	}
+	if a.recorder != nil {
+		return a.recorder.Status(), fmt.Errorf("recording already in progress")
+	}
+
+	maxDuration := time.Duration(maxSeconds) * time.Second
+	if maxDuration <= 0 {
+		maxDuration = defaultRecordingDuration
+	}
+	if maxDuration > maxRecordingDuration {
+		maxDuration = maxRecordingDuration
+	}
+
+	basePath := filepath.Join(getRecordingsDir(), "rec-"+time.Now().Format("20060102-150405"))
+	rec, err := media.NewRecorder(basePath, maxDuration, a.GetCurrentPlayer)
+	if err != nil {
+		log.Printf("[App] StartRecording error: %v", err)
+		return media.RecordingStatus{}, err
+	}
+
+	a.recorder = rec
+	a.audioCapture.SetRecorder(rec)
+	go a.watchRecorder(rec)
+
+	status := rec.Status()
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "recording:started", status)
+	}
+	return status, nil
+}
+
+// StopRecording stops the current recording and returns its final status
+func (a *App) StopRecording() (media.RecordingStatus, error) {
+	a.recMu.Lock()
+	rec := a.recorder
+	a.recMu.Unlock()
+
+	if rec == nil {
+		return media.RecordingStatus{}, fmt.Errorf("no recording in progress")
+	}
+
+	err := rec.Close()
+	<-rec.Done()
+	return rec.Status(), err
+}
+
+// GetRecordingStatus returns the status of the current recording (Active=false if none)
+func (a *App) GetRecordingStatus() media.RecordingStatus {
+	a.recMu.Lock()
+	defer a.recMu.Unlock()
+
+	if a.recorder == nil {
+		return media.RecordingStatus{}
+	}
+	return a.recorder.Status()
+}
+
+// ListRecordings returns WAV paths of saved recordings, newest first
+func (a *App) ListRecordings() ([]string, error) {
+	dir := getRecordingsDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".wav") {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
+
+// watchRecorder detaches the recorder from the capture once it stops (manually or by cap)
+func (a *App) watchRecorder(rec *media.Recorder) {
+	<-rec.Done()
+
+	a.recMu.Lock()
+	if a.recorder == rec {
+		a.recorder = nil
+		if a.audioCapture != nil {
+			a.audioCapture.SetRecorder(nil)
+		}
+	}
+	a.recMu.Unlock()
+
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "recording:stopped", rec.Status())
+	}
+}
+
+// StartReplay plays a recording through the analysis pipeline instead of the live
+// loopback capture, reproducing the rays exactly as they were recorded
+func (a *App) StartReplay(wavPath string) error {
+	a.recMu.Lock()
+	defer a.recMu.Unlock()
+
+	if a.recorder != nil {
+		return fmt.Errorf("cannot replay while recording")
+	}
+	if a.replayCapture != nil {
+		a.replayCapture.Stop()
+		a.replayCapture = nil
+	}
+
+	var replay *media.AudioLevelCapture
+	replay, err := media.NewFileAudioCapture(wavPath, a.emitAudioLevels, func(err error) {
+		a.onReplayDone(replay, err)
+	})
+	if err != nil {
+		log.Printf("[App] StartReplay error: %v", err)
+		return err
+	}
+	if err := replay.Start(); err != nil {
+		return err
+	}
+
+	a.replayCapture = replay
+	log.Printf("[App] Replaying recording %s", wavPath)
+
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "replay:started", wavPath)
+	}
+	return nil
+}
+
+// StopReplay stops a running replay and switches back to the live capture
+func (a *App) StopReplay() {
+	a.recMu.Lock()
+	replay := a.replayCapture
+	a.replayCapture = nil
+	a.recMu.Unlock()
+
+	if replay == nil {
+		return
+	}
+	replay.Stop()
+
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "replay:stopped", nil)
+	}
+}
+
+// IsReplaying reports whether levels currently come from a recording
+func (a *App) IsReplaying() bool {
+	a.recMu.Lock()
+	defer a.recMu.Unlock()
+	return a.replayCapture != nil
+}
+
+// onReplayDone switches back to the live capture when a replay reaches the end of the file
+func (a *App) onReplayDone(replay *media.AudioLevelCapture, err error) {
+	a.recMu.Lock()
+	current := a.replayCapture == replay
+	if current {
+		a.replayCapture = nil
+	}
+	a.recMu.Unlock()
+
+	if !current {
+		return
+	}
+	replay.Stop()
+
+	if a.ctx != nil {
+		payload := map[string]interface{}{"error": ""}
+		if err != nil {
+			payload["error"] = err.Error()
+		}
+		runtime.EventsEmit(a.ctx, "replay:stopped", payload)
+	}
+}
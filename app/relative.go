@@ -0,0 +1,116 @@
+package app
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"round-sound/media"
+)
+
+// defaultUnmuteVolume is restored when a player was muted outside the app
+const defaultUnmuteVolume = 50
+
+// preMuteChange is how a command changes the remembered pre-mute volume. It is
+// applied only once the command was dispatched, so failed commands keep it.
+type preMuteChange struct {
+	remember bool // store volume as the player's pre-mute volume
+	forget   bool // drop the remembered volume
+	volume   int
+}
+
+// resolveRelative turns relative and mute commands into absolute POSITION/VOLUME
+// commands based on the player's current state. Other commands are returned as is.
+// The pre-mute change is left to the caller, to apply after a successful dispatch.
+func (a *App) resolveRelative(player *media.Player, cmd media.Command) (media.Command, preMuteChange) {
+	switch cmd.Kind {
+	case media.CommandSeekBy:
+		position := int(math.Round(player.EstimatedPositionAt(time.Now()))) + cmd.Value
+		if player.Duration > 0 {
+			position = min(position, player.Duration)
+		}
+		return media.Command{Kind: media.CommandSeek, Value: max(position, 0)}, preMuteChange{}
+
+	case media.CommandVolumeBy:
+		volume := min(max(player.Volume+cmd.Value, 0), 100)
+		return media.Command{Kind: media.CommandSetVolume, Value: volume}, preMuteChange{forget: volume > 0}
+
+	case media.CommandToggleMute:
+		if player.Volume == 0 {
+			return a.resolveRelative(player, media.Command{Kind: media.CommandUnmute})
+		}
+		return a.resolveRelative(player, media.Command{Kind: media.CommandMute})
+
+	case media.CommandMute:
+		change := preMuteChange{}
+		if player.Volume > 0 {
+			change = preMuteChange{remember: true, volume: player.Volume}
+		}
+		return media.Command{Kind: media.CommandSetVolume, Value: 0}, change
+
+	case media.CommandUnmute:
+		a.mu.RLock()
+		volume, ok := a.preMuteVolume[player.ID]
+		a.mu.RUnlock()
+
+		if !ok {
+			if player.Volume > 0 {
+				volume = player.Volume
+			} else {
+				volume = defaultUnmuteVolume
+			}
+		}
+		return media.Command{Kind: media.CommandSetVolume, Value: volume}, preMuteChange{forget: true}
+
+	case media.CommandSetVolume:
+		return cmd, preMuteChange{forget: cmd.Value > 0}
+	}
+	return cmd, preMuteChange{}
+}
+
+// applyPreMute records the pre-mute volume change of a dispatched command
+func (a *App) applyPreMute(playerID int, change preMuteChange) {
+	switch {
+	case change.remember:
+		a.mu.Lock()
+		a.preMuteVolume[playerID] = change.volume
+		a.mu.Unlock()
+	case change.forget:
+		a.forgetPreMuteVolume(playerID)
+	}
+}
+
+// forgetPreMuteVolume drops the remembered volume once the player is audible again
+func (a *App) forgetPreMuteVolume(playerID int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.preMuteVolume[playerID]; ok {
+		delete(a.preMuteVolume, playerID)
+		log.Printf("[App] Forgot pre-mute volume of player %d", playerID)
+	}
+}
+
+// MediaSeekBy seeks relative to the current position, clamped to the track
+func (a *App) MediaSeekBy(deltaSeconds int) error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandSeekBy, Value: deltaSeconds})
+}
+
+// MediaVolumeBy changes the volume by delta percent, clamped to 0-100
+func (a *App) MediaVolumeBy(delta int) error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandVolumeBy, Value: delta})
+}
+
+// MediaMute mutes the controlled player and remembers its volume
+func (a *App) MediaMute() error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandMute})
+}
+
+// MediaUnmute restores the volume the controlled player had before muting
+func (a *App) MediaUnmute() error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandUnmute})
+}
+
+// MediaToggleMute mutes or unmutes the controlled player
+func (a *App) MediaToggleMute() error {
+	return a.ExecuteCommand(media.Command{Kind: media.CommandToggleMute})
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"round-sound/media"
+)
+
+// getScrobbleQueuePath returns the file holding listens not yet submitted
+func getScrobbleQueuePath() string {
+	return filepath.Join(getConfigDir(), "scrobble-queue.json")
+}
+
+// startScrobbler creates the scrobbler; it stays idle until enabled with a token
+func (a *App) startScrobbler() {
+	a.scrobbler = media.NewScrobbler(a.config.Scrobbler, getScrobbleQueuePath(), nil)
+}
+
+// GetScrobblerConfig returns the scrobbling settings
+func (a *App) GetScrobblerConfig() media.ScrobblerConfig {
+	if a.scrobbler == nil {
+		return a.config.Scrobbler
+	}
+	return a.scrobbler.Config()
+}
+
+// SetScrobblerConfig validates and saves the scrobbling settings
+func (a *App) SetScrobblerConfig(config media.ScrobblerConfig) error {
+	if endpoint := strings.TrimSpace(config.Endpoint); endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid endpoint: %s", endpoint)
+		}
+	}
+	if config.Enabled && strings.TrimSpace(config.Token) == "" {
+		return fmt.Errorf("a user token is required to enable scrobbling")
+	}
+
+	if a.scrobbler != nil {
+		a.scrobbler.SetConfig(config)
+		config = a.scrobbler.Config()
+	}
+	a.config.Scrobbler = config
+	a.config.Save()
+
+	log.Printf("[App] Scrobbler updated: enabled=%v, endpoint=%s", config.Enabled, config.Endpoint)
+	return nil
+}
+
+// GetScrobblerStatus returns the queue size and the last submission result
+func (a *App) GetScrobblerStatus() media.ScrobblerStatus {
+	if a.scrobbler == nil {
+		return media.ScrobblerStatus{}
+	}
+	return a.scrobbler.Status()
+}
+
+// FlushScrobbles retries submitting the queued listens now
+func (a *App) FlushScrobbles() {
+	if a.scrobbler != nil {
+		a.scrobbler.Flush()
+	}
+}
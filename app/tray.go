@@ -9,6 +9,8 @@ import (
 
 	"github.com/getlantern/systray"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"round-sound/media"
 )
 
 //go:embed tray-color.ico
@@ -19,12 +21,13 @@ var trayIconGray []byte
 
 type TrayManager struct {
 	ctx            context.Context
+	execute        func(cmd media.Command) error
 	hasSound       bool
 	lastUpdateTime time.Time
 }
 
-func NewTrayManager(ctx context.Context) *TrayManager {
-	return &TrayManager{ctx: ctx}
+func NewTrayManager(ctx context.Context, execute func(cmd media.Command) error) *TrayManager {
+	return &TrayManager{ctx: ctx, execute: execute}
 }
 
 func (t *TrayManager) Setup() {
@@ -47,20 +50,47 @@ func (t *TrayManager) onReady() {
 	systray.SetTooltip("Round Sound Widget")
 	t.hasSound = false
 
-	// Add menu items - только выход
+	// Add menu items
+	mPlayPause := systray.AddMenuItem("Воспроизведение / пауза", "Запустить или приостановить воспроизведение")
+	mNext := systray.AddMenuItem("Следующий трек", "Перейти к следующему треку")
+	mPrevious := systray.AddMenuItem("Предыдущий трек", "Перейти к предыдущему треку")
+	mMute := systray.AddMenuItem("Звук вкл / выкл", "Выключить или вернуть звук плеера")
+	systray.AddSeparator()
 	mQuit := systray.AddMenuItem("Выход", "Закрыть приложение")
 
 	// Handle menu clicks
 	go func() {
-		<-mQuit.ClickedCh
-		log.Println("[Tray] Quit requested from tray menu")
-		systray.Quit()
-		os.Exit(0)
+		for {
+			select {
+			case <-mPlayPause.ClickedCh:
+				t.runCommand(media.CommandTogglePlayPause)
+			case <-mNext.ClickedCh:
+				t.runCommand(media.CommandNext)
+			case <-mPrevious.ClickedCh:
+				t.runCommand(media.CommandPrevious)
+			case <-mMute.ClickedCh:
+				t.runCommand(media.CommandToggleMute)
+			case <-mQuit.ClickedCh:
+				log.Println("[Tray] Quit requested from tray menu")
+				systray.Quit()
+				os.Exit(0)
+			}
+		}
 	}()
 
 	log.Println("[Tray] System tray ready")
 }
 
+// runCommand sends a media command from the tray menu
+func (t *TrayManager) runCommand(kind media.CommandKind) {
+	if t.execute == nil {
+		return
+	}
+	if err := t.execute(media.Command{Kind: kind}); err != nil {
+		log.Printf("[Tray] %s failed: %v", kind, err)
+	}
+}
+
 func (t *TrayManager) onExit() {
 	log.Println("[Tray] System tray exited")
 }
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package app
+
+// setDesktopLevelImpl is a no-op outside Windows: the window stays where the
+// window manager puts it
+func (w *WindowManager) setDesktopLevelImpl() {}
+
+// setToolWindow is a no-op outside Windows (Hwnd is never set)
+func setToolWindow(hwnd uintptr) {}
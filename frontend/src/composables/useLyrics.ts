@@ -0,0 +1,37 @@
+import {
+  onMounted,
+  onUnmounted,
+  ref,
+} from 'vue'
+
+import type { LyricsUpdate } from '@/types'
+
+// Check if Wails runtime is available
+const isWailsAvailable = () => typeof window !== 'undefined' && 'go' in window
+
+export function useLyrics() {
+  // Active line of the displayed track ('' before the first line or without lyrics)
+  const line = ref('')
+  const nextLine = ref('')
+
+  let unsubscribe: (() => void) | null = null
+
+  onMounted(() => {
+    if (!isWailsAvailable()) return
+
+    unsubscribe = window.runtime.EventsOn('media:lyrics', (...args: unknown[]) => {
+      const update = args[0] as LyricsUpdate | undefined
+      line.value = update?.line ?? ''
+      nextLine.value = update?.next ?? ''
+    })
+  })
+
+  onUnmounted(() => {
+    if (unsubscribe) unsubscribe()
+  })
+
+  return {
+    line,
+    nextLine,
+  }
+}
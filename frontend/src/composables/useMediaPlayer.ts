@@ -5,8 +5,10 @@ import {
 } from 'vue'
 
 import {
+  type CommandFailure,
   defaultPlayer,
   type Player,
+  type PositionTick,
 } from '@/types'
 
 // Check if Wails runtime is available
@@ -18,6 +20,9 @@ export function useMediaPlayer() {
   const error = ref<string | null>(null)
 
   let unsubscribe: (() => void) | null = null
+  let unsubscribePosition: (() => void) | null = null
+  let unsubscribeCleared: (() => void) | null = null
+  let unsubscribeFailed: (() => void) | null = null
 
   onMounted(() => {
     if (!isWailsAvailable()) {
@@ -40,11 +45,30 @@ export function useMediaPlayer() {
     unsubscribe = window.runtime.EventsOn('media:update', (...args: unknown[]) => {
       const data = args[0] as Player | undefined
       if (data) {
-        player.value = data
+        player.value = { ...data, position: data.estimatedPosition ?? data.position }
         isConnected.value = true
       }
     })
 
+    // Smooth progress between WNP updates (only sent when a tick rate is configured)
+    unsubscribePosition = window.runtime.EventsOn('media:position', (...args: unknown[]) => {
+      const tick = args[0] as PositionTick | undefined
+      if (tick && tick.playerId === player.value.id) {
+        player.value.position = tick.position
+      }
+    })
+
+    // All players went away (browser closed or players expired) - show idle state
+    unsubscribeCleared = window.runtime.EventsOn('media:cleared', () => {
+      player.value = { ...defaultPlayer }
+    })
+
+    // Optimistic state was rolled back by the backend (a corrected media:update follows)
+    unsubscribeFailed = window.runtime.EventsOn('media:command_failed', (...args: unknown[]) => {
+      const failure = args[0] as CommandFailure | undefined
+      if (failure) console.warn('[useMediaPlayer] Command failed:', failure.kind, failure.error)
+    })
+
     // Subscribe to errors
     window.runtime.EventsOn('error:port_busy', (...args: unknown[]) => {
       const msg = args[0] as string | undefined
@@ -62,6 +86,9 @@ export function useMediaPlayer() {
 
   onUnmounted(() => {
     if (unsubscribe) unsubscribe()
+    if (unsubscribePosition) unsubscribePosition()
+    if (unsubscribeCleared) unsubscribeCleared()
+    if (unsubscribeFailed) unsubscribeFailed()
   })
 
   // Control methods
@@ -143,6 +170,45 @@ export function useMediaPlayer() {
     }
   }
 
+  const toggleLike = async () => {
+    console.log('[useMediaPlayer] toggleLike called')
+    if (isWailsAvailable()) {
+      try {
+        await window.go.app.App.MediaToggleLike()
+        console.log('[useMediaPlayer] MediaToggleLike success')
+      }
+      catch (err) {
+        console.error('[useMediaPlayer] MediaToggleLike error:', err)
+      }
+    }
+  }
+
+  const dislike = async () => {
+    console.log('[useMediaPlayer] dislike called')
+    if (isWailsAvailable()) {
+      try {
+        await window.go.app.App.MediaDislike()
+        console.log('[useMediaPlayer] MediaDislike success')
+      }
+      catch (err) {
+        console.error('[useMediaPlayer] MediaDislike error:', err)
+      }
+    }
+  }
+
+  const clearRating = async () => {
+    console.log('[useMediaPlayer] clearRating called')
+    if (isWailsAvailable()) {
+      try {
+        await window.go.app.App.MediaClearRating()
+        console.log('[useMediaPlayer] MediaClearRating success')
+      }
+      catch (err) {
+        console.error('[useMediaPlayer] MediaClearRating error:', err)
+      }
+    }
+  }
+
   const seek = async (position: number) => {
     const positionInt = Math.round(position)
     console.log('[useMediaPlayer] seek called:', position, '-> rounded to:', positionInt)
@@ -160,6 +226,7 @@ export function useMediaPlayer() {
   const setVolume = async (volume: number) => {
     console.log('[useMediaPlayer] setVolume called:', volume)
     // Optimistic update
+    const previousVolume = player.value.volume
     player.value.volume = volume
 
     if (isWailsAvailable()) {
@@ -169,6 +236,46 @@ export function useMediaPlayer() {
       }
       catch (err) {
         console.error('[useMediaPlayer] MediaSetVolume error:', err)
+        player.value.volume = previousVolume
+      }
+    }
+  }
+
+  const seekBy = async (deltaSeconds: number) => {
+    console.log('[useMediaPlayer] seekBy called:', deltaSeconds)
+    if (isWailsAvailable()) {
+      try {
+        await window.go.app.App.MediaSeekBy(deltaSeconds)
+        console.log('[useMediaPlayer] MediaSeekBy success')
+      }
+      catch (err) {
+        console.error('[useMediaPlayer] MediaSeekBy error:', err)
+      }
+    }
+  }
+
+  const volumeBy = async (delta: number) => {
+    console.log('[useMediaPlayer] volumeBy called:', delta)
+    if (isWailsAvailable()) {
+      try {
+        await window.go.app.App.MediaVolumeBy(delta)
+        console.log('[useMediaPlayer] MediaVolumeBy success')
+      }
+      catch (err) {
+        console.error('[useMediaPlayer] MediaVolumeBy error:', err)
+      }
+    }
+  }
+
+  const toggleMute = async () => {
+    console.log('[useMediaPlayer] toggleMute called')
+    if (isWailsAvailable()) {
+      try {
+        await window.go.app.App.MediaToggleMute()
+        console.log('[useMediaPlayer] MediaToggleMute success')
+      }
+      catch (err) {
+        console.error('[useMediaPlayer] MediaToggleMute error:', err)
       }
     }
   }
@@ -183,7 +290,13 @@ export function useMediaPlayer() {
     toggleShuffle,
     toggleRepeat,
     setRating,
+    toggleLike,
+    dislike,
+    clearRating,
     seek,
     setVolume,
+    seekBy,
+    volumeBy,
+    toggleMute,
   }
 }
@@ -0,0 +1,63 @@
+import {
+  onMounted,
+  onUnmounted,
+  ref,
+} from 'vue'
+
+import type { Player } from '@/types'
+
+// Check if Wails runtime is available
+const isWailsAvailable = () => typeof window !== 'undefined' && 'go' in window
+
+export function usePlayers() {
+  const players = ref<Player[]>([])
+  // 0 = media commands follow the displayed player
+  const controlledPlayerId = ref(0)
+
+  let unsubscribePlayers: (() => void) | null = null
+  let unsubscribeControlled: (() => void) | null = null
+
+  onMounted(() => {
+    if (!isWailsAvailable()) return
+
+    // Full list of browser players, sent on every player change
+    unsubscribePlayers = window.runtime.EventsOn('media:players', (...args: unknown[]) => {
+      players.value = (args[0] as Player[] | undefined) ?? []
+    })
+
+    unsubscribeControlled = window.runtime.EventsOn('media:controlled', (...args: unknown[]) => {
+      controlledPlayerId.value = (args[0] as number | undefined) ?? 0
+    })
+
+    window.go.app.App.GetPlayers().then((list) => {
+      players.value = list ?? []
+    })
+    window.go.app.App.GetControlledPlayer().then((id) => {
+      controlledPlayerId.value = id
+    })
+  })
+
+  onUnmounted(() => {
+    if (unsubscribePlayers) unsubscribePlayers()
+    if (unsubscribeControlled) unsubscribeControlled()
+  })
+
+  const setControlledPlayer = async (playerId: number) => {
+    console.log('[usePlayers] setControlledPlayer called:', playerId)
+    if (isWailsAvailable()) {
+      try {
+        await window.go.app.App.SetControlledPlayer(playerId)
+        controlledPlayerId.value = playerId
+      }
+      catch (err) {
+        console.error('[usePlayers] SetControlledPlayer error:', err)
+      }
+    }
+  }
+
+  return {
+    players,
+    controlledPlayerId,
+    setControlledPlayer,
+  }
+}
@@ -0,0 +1,38 @@
+import { createApp } from 'vue'
+
+import Overlay from '@/Overlay.vue'
+import { useSettings } from '@/composables/useSettings'
+import { installOverlayRuntime } from '@/utils/overlayRuntime'
+
+import '@/assets/styles/main.css'
+
+// Query params of the overlay URL:
+//   size  - widget size in px (default 580)
+//   color - primary colour, hex without # (e.g. ff8c42)
+//   text  - text colour, hex without #
+//   show  - elements to show: rays,progress,cover,info,lyrics (default all)
+const OVERLAY_ELEMENTS = ['rays', 'progress', 'cover', 'info', 'lyrics']
+const WIDGET_SIZE = 580
+
+const params = new URLSearchParams(window.location.search)
+const root = document.documentElement
+
+const size = Number(params.get('size'))
+if (size > 0) root.style.setProperty('--overlay-scale', String(size / WIDGET_SIZE))
+
+const hex = (value: string | null) => value && /^[0-9a-f]{6}$/i.test(value) ? `#${value}` : null
+
+const color = hex(params.get('color'))
+if (color) useSettings().updatePrimaryColor(color)
+
+const text = hex(params.get('text'))
+if (text) root.style.setProperty('--color-text', text)
+
+const show = params.get('show')
+const elements = show
+  ? show.split(',').map(name => name.trim()).filter(name => OVERLAY_ELEMENTS.includes(name))
+  : OVERLAY_ELEMENTS
+
+installOverlayRuntime(`ws://${window.location.host}/ws`)
+
+createApp(Overlay, { elements }).mount('#app')
@@ -20,9 +20,10 @@ export enum RatingSystem {
   Scale = 3,
 }
 
-// Player state from WebNowPlaying
+// Player state from WebNowPlaying or a native MPRIS player
 export interface Player {
   id: number;
+  source: string;    // 'wnp' | 'mpris'
   name: string;
   title: string;
   artist: string;
@@ -32,7 +33,7 @@ export interface Player {
   position: number;  // seconds
   duration: number;  // seconds
   volume: number;    // 0-100
-  rating: number;    // 0=none, 1=dislike, 5=like
+  rating: number;    // 0=none; like systems: 1=dislike, 5=like; scale: 1-5 stars
   repeat: RepeatMode;
   shuffle: boolean;
   ratingSystem: RatingSystem;
@@ -48,11 +49,40 @@ export interface Player {
   createdAt: number;
   updatedAt: number;
   activeAt: number;
+  positionAt: number;         // unix ms when position was received
+  playbackRate: number;
+  estimatedPosition: number;  // seconds, extrapolated by backend
+}
+
+// Lightweight position tick (media:position)
+export interface PositionTick {
+  playerId: number;
+  position: number;  // seconds, extrapolated
+  duration: number;
+  state: StateMode;
+}
+
+// Optimistic command the player rejected or did not confirm (media:command_failed)
+export interface CommandFailure {
+  playerId: number;
+  kind: string;
+  error: string;
+}
+
+// Active lyric line of the displayed track (media:lyrics); index is -1 without a line
+export interface LyricsUpdate {
+  playerId: number;
+  index: number;
+  line: string;
+  next: string;
+  time: number;
+  words?: { time: number; text: string }[];
 }
 
 // Default empty player
 export const defaultPlayer: Player = {
   id: 0,
+  source: '',
   name: '',
   title: '',
   artist: '',
@@ -78,4 +108,7 @@ export const defaultPlayer: Player = {
   createdAt: 0,
   updatedAt: 0,
   activeAt: 0,
+  positionAt: 0,
+  playbackRate: 1,
+  estimatedPosition: 0,
 }
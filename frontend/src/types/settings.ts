@@ -41,4 +41,5 @@ export const DEFAULT_SETTINGS: AppSettings = {
 }
 
 export const FFT_SIZE_OPTIONS = [1024, 2048, 4096, 8192] as const
+export const POSITION_TICK_OPTIONS = [0, 5, 10, 30] as const
 export type FFTSize = typeof FFT_SIZE_OPTIONS[number]
@@ -7,14 +7,23 @@ declare global {
       app: {
         App: {
           GetCurrentPlayer: () => Promise<Player | null>;
+          GetPlayers: () => Promise<Player[]>;
+          GetControlledPlayer: () => Promise<number>;
+          SetControlledPlayer: (playerId: number) => Promise<void>;
           MediaTogglePlayPause: () => Promise<void>;
           MediaNext: () => Promise<void>;
           MediaPrevious: () => Promise<void>;
           MediaToggleShuffle: () => Promise<void>;
           MediaToggleRepeat: () => Promise<void>;
           MediaSetRating: (rating: number) => Promise<void>;
+          MediaToggleLike: () => Promise<void>;
+          MediaDislike: () => Promise<void>;
+          MediaClearRating: () => Promise<void>;
           MediaSeek: (position: number) => Promise<void>;
           MediaSetVolume: (volume: number) => Promise<void>;
+          MediaSeekBy: (deltaSeconds: number) => Promise<void>;
+          MediaVolumeBy: (delta: number) => Promise<void>;
+          MediaToggleMute: () => Promise<void>;
           Quit: () => Promise<void>;
         };
       };
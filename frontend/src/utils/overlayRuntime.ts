@@ -0,0 +1,82 @@
+// Stand-in for the Wails runtime on the OBS overlay page: the app's events arrive
+// over a WebSocket from the overlay server, so the regular composables work unchanged.
+
+type Callback = (...args: unknown[]) => void
+
+interface Listener {
+  callback: Callback;
+  remaining: number; // -1 = unlimited
+}
+
+interface OverlayMessage {
+  event: string;
+  data?: unknown;
+}
+
+const RECONNECT_DELAY_MS = 2000
+
+export function installOverlayRuntime(url: string) {
+  const listeners = new Map<string, Listener[]>()
+
+  function dispatch(event: string, data: unknown) {
+    const list = listeners.get(event)
+    if (!list) return
+    for (const listener of [...list]) {
+      if (data === undefined || data === null) listener.callback()
+      else listener.callback(data)
+      if (listener.remaining > 0 && --listener.remaining === 0) {
+        list.splice(list.indexOf(listener), 1)
+      }
+    }
+  }
+
+  function EventsOnMultiple(eventName: string, callback: Callback, maxCallbacks: number) {
+    const listener: Listener = { callback, remaining: maxCallbacks }
+    const list = listeners.get(eventName) ?? []
+    list.push(listener)
+    listeners.set(eventName, list)
+    return () => {
+      const index = list.indexOf(listener)
+      if (index >= 0) list.splice(index, 1)
+    }
+  }
+
+  function connect() {
+    const socket = new WebSocket(url)
+    socket.onmessage = (e: MessageEvent<string>) => {
+      try {
+        const msg = JSON.parse(e.data) as OverlayMessage
+        dispatch(msg.event, msg.data)
+      }
+      catch (error) {
+        console.error('[Overlay] Bad message:', error)
+      }
+    }
+    // App closed or overlay server stopped: hide the widget and keep trying
+    socket.onclose = () => {
+      dispatch('media:cleared', undefined)
+      setTimeout(connect, RECONNECT_DELAY_MS)
+    }
+  }
+
+  window.runtime = {
+    EventsOn: (eventName: string, callback: Callback) => EventsOnMultiple(eventName, callback, -1),
+    EventsOnMultiple,
+    EventsOff: (...eventNames: string[]) => {
+      for (const name of eventNames) listeners.delete(name)
+    },
+    // The overlay only listens (e.g. audio:config stays with the desktop widget)
+    EventsEmit: () => {},
+  } as Window['runtime']
+
+  // Bound methods are not available; the server sends the current state on connect
+  window.go = {
+    app: {
+      App: new Proxy({}, {
+        get: () => () => Promise.resolve(null),
+      }) as Window['go']['app']['App'],
+    },
+  }
+
+  connect()
+}
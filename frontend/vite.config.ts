@@ -14,6 +14,13 @@ export default defineConfig({
   build: {
     minify: true,
     target: 'esnext',
+    rollupOptions: {
+      // overlay.html is the OBS browser-source page served by the overlay server
+      input: {
+        main: path.resolve(__dirname, 'index.html'),
+        overlay: path.resolve(__dirname, 'overlay.html'),
+      },
+    },
   },
   define: {
     'import.meta.env.APP_VERSION': JSON.stringify(process.env.npm_package_version),
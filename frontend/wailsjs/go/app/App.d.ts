@@ -1,19 +1,90 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {media} from '../models';
+import {app} from '../models';
 
 export function ChangeWNPPort(arg1:number):Promise<void>;
 
+export function ExecuteCommand(arg1:media.Command):Promise<void>;
+
+export function ExportHistory(arg1:string):Promise<string>;
+
+export function FlushScrobbles():Promise<void>;
+
+export function GetControlledPlayer():Promise<number>;
+
 export function GetCurrentPlayer():Promise<media.Player>;
 
+export function GetDiagnostics():Promise<app.Diagnostics>;
+
+export function GetDiscordConfig():Promise<media.DiscordConfig>;
+
+export function GetDiscordStatus():Promise<media.DiscordStatus>;
+
+export function GetHooks():Promise<Array<app.HookConfig>>;
+
+export function GetListeningTime(arg1:number):Promise<app.ListeningTime>;
+
+export function GetLyrics():Promise<media.Lyrics>;
+
+export function GetLyricsDirs():Promise<Array<string>>;
+
+export function GetMPDConfig():Promise<media.MPDConfig>;
+
+export function GetMPDStatus():Promise<media.MPDStatus>;
+
+export function GetMQTTConfig():Promise<media.MQTTConfig>;
+
+export function GetMQTTStatus():Promise<media.MQTTStatus>;
+
+export function GetNotificationConfig():Promise<app.NotificationConfig>;
+
+export function GetNowPlayingConfig():Promise<app.NowPlayingConfig>;
+
+export function GetOSCConfig():Promise<media.OSCConfig>;
+
+export function GetOverlayConfig():Promise<app.OverlayConfig>;
+
+export function GetOverlayURL():Promise<string>;
+
+export function GetPlayerRules():Promise<media.PlayerRules>;
+
+export function GetPlayers():Promise<Array<media.Player>>;
+
+export function GetPositionTickRate():Promise<number>;
+
+export function GetRecentTracks(arg1:number):Promise<Array<app.HistoryEntry>>;
+
+export function GetRecordingStatus():Promise<media.RecordingStatus>;
+
+export function GetScrobblerConfig():Promise<media.ScrobblerConfig>;
+
+export function GetScrobblerStatus():Promise<media.ScrobblerStatus>;
+
+export function GetTopArtists(arg1:number, arg2:number):Promise<Array<app.PlayStat>>;
+
+export function GetTopTracks(arg1:number, arg2:number):Promise<Array<app.PlayStat>>;
+
 export function GetWNPPort():Promise<number>;
 
 export function IsAutorunEnabled():Promise<boolean>;
 
+export function IsReplaying():Promise<boolean>;
+
 export function IsWNPConnected():Promise<boolean>;
 
+export function ListRecordings():Promise<Array<string>>;
+
 export function LoadWindowPosition():Promise<number|number>;
 
+export function MediaClearRating():Promise<void>;
+
+export function MediaDislike():Promise<void>;
+
+export function MediaLike():Promise<void>;
+
+export function MediaMute():Promise<void>;
+
 export function MediaNext():Promise<void>;
 
 export function MediaPause():Promise<void>;
@@ -24,20 +95,72 @@ export function MediaPrevious():Promise<void>;
 
 export function MediaSeek(arg1:number):Promise<void>;
 
+export function MediaSeekBy(arg1:number):Promise<void>;
+
 export function MediaSetRating(arg1:number):Promise<void>;
 
+export function MediaSetStars(arg1:number):Promise<void>;
+
 export function MediaSetVolume(arg1:number):Promise<void>;
 
+export function MediaToggleLike():Promise<void>;
+
+export function MediaToggleMute():Promise<void>;
+
 export function MediaTogglePlayPause():Promise<void>;
 
 export function MediaToggleRepeat():Promise<void>;
 
 export function MediaToggleShuffle():Promise<void>;
 
+export function MediaUnmute():Promise<void>;
+
+export function MediaVolumeBy(arg1:number):Promise<void>;
+
+export function PinCurrentPlayer():Promise<void>;
+
 export function Quit():Promise<void>;
 
 export function SaveWindowPosition():Promise<void>;
 
 export function SetAutorun(arg1:boolean):Promise<void>;
 
+export function SetControlledPlayer(arg1:number):Promise<void>;
+
+export function SetDiscordConfig(arg1:media.DiscordConfig):Promise<void>;
+
+export function SetHooks(arg1:Array<app.HookConfig>):Promise<void>;
+
+export function SetLyricsDirs(arg1:Array<string>):Promise<void>;
+
+export function SetMPDConfig(arg1:media.MPDConfig):Promise<void>;
+
+export function SetMQTTConfig(arg1:media.MQTTConfig):Promise<void>;
+
+export function SetNotificationConfig(arg1:app.NotificationConfig):Promise<void>;
+
+export function SetNowPlayingConfig(arg1:app.NowPlayingConfig):Promise<void>;
+
+export function SetOSCConfig(arg1:media.OSCConfig):Promise<void>;
+
+export function SetOverlayConfig(arg1:app.OverlayConfig):Promise<void>;
+
+export function SetPlayerRules(arg1:media.PlayerRules):Promise<void>;
+
+export function SetPositionTickRate(arg1:number):Promise<void>;
+
+export function SetScrobblerConfig(arg1:media.ScrobblerConfig):Promise<void>;
+
 export function ShowWindow():Promise<void>;
+
+export function StartRecording(arg1:number):Promise<media.RecordingStatus>;
+
+export function StartReplay(arg1:string):Promise<void>;
+
+export function StopRecording():Promise<media.RecordingStatus>;
+
+export function StopReplay():Promise<void>;
+
+export function TestHook(arg1:app.HookConfig):Promise<app.HookDryRun>;
+
+export function UnpinPlayer():Promise<void>;
@@ -6,10 +6,126 @@ export function ChangeWNPPort(arg1) {
   return window['go']['app']['App']['ChangeWNPPort'](arg1);
 }
 
+export function ExecuteCommand(arg1) {
+  return window['go']['app']['App']['ExecuteCommand'](arg1);
+}
+
+export function ExportHistory(arg1) {
+  return window['go']['app']['App']['ExportHistory'](arg1);
+}
+
+export function FlushScrobbles() {
+  return window['go']['app']['App']['FlushScrobbles']();
+}
+
+export function GetControlledPlayer() {
+  return window['go']['app']['App']['GetControlledPlayer']();
+}
+
 export function GetCurrentPlayer() {
   return window['go']['app']['App']['GetCurrentPlayer']();
 }
 
+export function GetDiagnostics() {
+  return window['go']['app']['App']['GetDiagnostics']();
+}
+
+export function GetDiscordConfig() {
+  return window['go']['app']['App']['GetDiscordConfig']();
+}
+
+export function GetDiscordStatus() {
+  return window['go']['app']['App']['GetDiscordStatus']();
+}
+
+export function GetHooks() {
+  return window['go']['app']['App']['GetHooks']();
+}
+
+export function GetListeningTime(arg1) {
+  return window['go']['app']['App']['GetListeningTime'](arg1);
+}
+
+export function GetLyrics() {
+  return window['go']['app']['App']['GetLyrics']();
+}
+
+export function GetLyricsDirs() {
+  return window['go']['app']['App']['GetLyricsDirs']();
+}
+
+export function GetMPDConfig() {
+  return window['go']['app']['App']['GetMPDConfig']();
+}
+
+export function GetMPDStatus() {
+  return window['go']['app']['App']['GetMPDStatus']();
+}
+
+export function GetMQTTConfig() {
+  return window['go']['app']['App']['GetMQTTConfig']();
+}
+
+export function GetMQTTStatus() {
+  return window['go']['app']['App']['GetMQTTStatus']();
+}
+
+export function GetNotificationConfig() {
+  return window['go']['app']['App']['GetNotificationConfig']();
+}
+
+export function GetNowPlayingConfig() {
+  return window['go']['app']['App']['GetNowPlayingConfig']();
+}
+
+export function GetOSCConfig() {
+  return window['go']['app']['App']['GetOSCConfig']();
+}
+
+export function GetOverlayConfig() {
+  return window['go']['app']['App']['GetOverlayConfig']();
+}
+
+export function GetOverlayURL() {
+  return window['go']['app']['App']['GetOverlayURL']();
+}
+
+export function GetPlayerRules() {
+  return window['go']['app']['App']['GetPlayerRules']();
+}
+
+export function GetPlayers() {
+  return window['go']['app']['App']['GetPlayers']();
+}
+
+export function GetPositionTickRate() {
+  return window['go']['app']['App']['GetPositionTickRate']();
+}
+
+export function GetRecentTracks(arg1) {
+  return window['go']['app']['App']['GetRecentTracks'](arg1);
+}
+
+export function GetRecordingStatus() {
+  return window['go']['app']['App']['GetRecordingStatus']();
+}
+
+export function GetScrobblerConfig() {
+  return window['go']['app']['App']['GetScrobblerConfig']();
+}
+
+export function GetScrobblerStatus() {
+  return window['go']['app']['App']['GetScrobblerStatus']();
+}
+
+export function GetTopArtists(arg1, arg2) {
+  return window['go']['app']['App']['GetTopArtists'](arg1, arg2);
+}
+
+export function GetTopTracks(arg1, arg2) {
+  return window['go']['app']['App']['GetTopTracks'](arg1, arg2);
+}
+
 export function GetWNPPort() {
   return window['go']['app']['App']['GetWNPPort']();
 }
@@ -18,14 +134,38 @@ export function IsAutorunEnabled() {
   return window['go']['app']['App']['IsAutorunEnabled']();
 }
 
+export function IsReplaying() {
+  return window['go']['app']['App']['IsReplaying']();
+}
+
 export function IsWNPConnected() {
   return window['go']['app']['App']['IsWNPConnected']();
 }
 
+export function ListRecordings() {
+  return window['go']['app']['App']['ListRecordings']();
+}
+
 export function LoadWindowPosition() {
   return window['go']['app']['App']['LoadWindowPosition']();
 }
 
+export function MediaClearRating() {
+  return window['go']['app']['App']['MediaClearRating']();
+}
+
+export function MediaDislike() {
+  return window['go']['app']['App']['MediaDislike']();
+}
+
+export function MediaLike() {
+  return window['go']['app']['App']['MediaLike']();
+}
+
+export function MediaMute() {
+  return window['go']['app']['App']['MediaMute']();
+}
+
 export function MediaNext() {
   return window['go']['app']['App']['MediaNext']();
 }
@@ -46,14 +186,30 @@ export function MediaSeek(arg1) {
   return window['go']['app']['App']['MediaSeek'](arg1);
 }
 
+export function MediaSeekBy(arg1) {
+  return window['go']['app']['App']['MediaSeekBy'](arg1);
+}
+
 export function MediaSetRating(arg1) {
   return window['go']['app']['App']['MediaSetRating'](arg1);
 }
 
+export function MediaSetStars(arg1) {
+  return window['go']['app']['App']['MediaSetStars'](arg1);
+}
+
 export function MediaSetVolume(arg1) {
   return window['go']['app']['App']['MediaSetVolume'](arg1);
 }
 
+export function MediaToggleLike() {
+  return window['go']['app']['App']['MediaToggleLike']();
+}
+
+export function MediaToggleMute() {
+  return window['go']['app']['App']['MediaToggleMute']();
+}
+
 export function MediaTogglePlayPause() {
   return window['go']['app']['App']['MediaTogglePlayPause']();
 }
@@ -66,6 +222,18 @@ export function MediaToggleShuffle() {
   return window['go']['app']['App']['MediaToggleShuffle']();
 }
 
+export function MediaUnmute() {
+  return window['go']['app']['App']['MediaUnmute']();
+}
+
+export function MediaVolumeBy(arg1) {
+  return window['go']['app']['App']['MediaVolumeBy'](arg1);
+}
+
+export function PinCurrentPlayer() {
+  return window['go']['app']['App']['PinCurrentPlayer']();
+}
+
 export function Quit() {
   return window['go']['app']['App']['Quit']();
 }
@@ -78,6 +246,82 @@ export function SetAutorun(arg1) {
   return window['go']['app']['App']['SetAutorun'](arg1);
 }
 
+export function SetControlledPlayer(arg1) {
+  return window['go']['app']['App']['SetControlledPlayer'](arg1);
+}
+
+export function SetDiscordConfig(arg1) {
+  return window['go']['app']['App']['SetDiscordConfig'](arg1);
+}
+
+export function SetHooks(arg1) {
+  return window['go']['app']['App']['SetHooks'](arg1);
+}
+
+export function SetLyricsDirs(arg1) {
+  return window['go']['app']['App']['SetLyricsDirs'](arg1);
+}
+
+export function SetMPDConfig(arg1) {
+  return window['go']['app']['App']['SetMPDConfig'](arg1);
+}
+
+export function SetMQTTConfig(arg1) {
+  return window['go']['app']['App']['SetMQTTConfig'](arg1);
+}
+
+export function SetNotificationConfig(arg1) {
+  return window['go']['app']['App']['SetNotificationConfig'](arg1);
+}
+
+export function SetNowPlayingConfig(arg1) {
+  return window['go']['app']['App']['SetNowPlayingConfig'](arg1);
+}
+
+export function SetOSCConfig(arg1) {
+  return window['go']['app']['App']['SetOSCConfig'](arg1);
+}
+
+export function SetOverlayConfig(arg1) {
+  return window['go']['app']['App']['SetOverlayConfig'](arg1);
+}
+
+export function SetPlayerRules(arg1) {
+  return window['go']['app']['App']['SetPlayerRules'](arg1);
+}
+
+export function SetPositionTickRate(arg1) {
+  return window['go']['app']['App']['SetPositionTickRate'](arg1);
+}
+
+export function SetScrobblerConfig(arg1) {
+  return window['go']['app']['App']['SetScrobblerConfig'](arg1);
+}
+
 export function ShowWindow() {
   return window['go']['app']['App']['ShowWindow']();
 }
+
+export function StartRecording(arg1) {
+  return window['go']['app']['App']['StartRecording'](arg1);
+}
+
+export function StartReplay(arg1) {
+  return window['go']['app']['App']['StartReplay'](arg1);
+}
+
+export function StopRecording() {
+  return window['go']['app']['App']['StopRecording']();
+}
+
+export function StopReplay() {
+  return window['go']['app']['App']['StopReplay']();
+}
+
+export function TestHook(arg1) {
+  return window['go']['app']['App']['TestHook'](arg1);
+}
+
+export function UnpinPlayer() {
+  return window['go']['app']['App']['UnpinPlayer']();
+}
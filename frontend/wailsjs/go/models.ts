@@ -1,7 +1,631 @@
+export namespace app {
+	
+	export class Diagnostics {
+	    uptimeSec: number;
+	    wnpRunning: boolean;
+	    wnp: media.ServerStats;
+	    audio: media.CaptureStats;
+	    fft: media.FFTConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new Diagnostics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.uptimeSec = source["uptimeSec"];
+	        this.wnpRunning = source["wnpRunning"];
+	        this.wnp = this.convertValues(source["wnp"], media.ServerStats);
+	        this.audio = this.convertValues(source["audio"], media.CaptureStats);
+	        this.fft = this.convertValues(source["fft"], media.FFTConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryEntry {
+	    title: string;
+	    artist: string;
+	    album: string;
+	    player: string;
+	    duration: number;
+	    startedAt: number;
+	    endedAt: number;
+	    listened: number;
+	    skipped: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.artist = source["artist"];
+	        this.album = source["album"];
+	        this.player = source["player"];
+	        this.duration = source["duration"];
+	        this.startedAt = source["startedAt"];
+	        this.endedAt = source["endedAt"];
+	        this.listened = source["listened"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+	export class HookConfig {
+	    name: string;
+	    event: string;
+	    enabled: boolean;
+	    command?: string;
+	    url?: string;
+	    body?: string;
+	    debounceMs: number;
+	    timeoutSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HookConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.event = source["event"];
+	        this.enabled = source["enabled"];
+	        this.command = source["command"];
+	        this.url = source["url"];
+	        this.body = source["body"];
+	        this.debounceMs = source["debounceMs"];
+	        this.timeoutSeconds = source["timeoutSeconds"];
+	    }
+	}
+	export class HookDryRun {
+	    payload: app.HookPayload;
+	    command?: string[];
+	    env?: string[];
+	    stdin?: string;
+	    url?: string;
+	    body?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HookDryRun(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.payload = this.convertValues(source["payload"], app.HookPayload);
+	        this.command = source["command"];
+	        this.env = source["env"];
+	        this.stdin = source["stdin"];
+	        this.url = source["url"];
+	        this.body = source["body"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HookPayload {
+	    event: string;
+	    time: number;
+	    playerId: number;
+	    player: string;
+	    title: string;
+	    artist: string;
+	    album: string;
+	    cover: string;
+	    duration: number;
+	    position: number;
+	    volume: number;
+	    state: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HookPayload(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.event = source["event"];
+	        this.time = source["time"];
+	        this.playerId = source["playerId"];
+	        this.player = source["player"];
+	        this.title = source["title"];
+	        this.artist = source["artist"];
+	        this.album = source["album"];
+	        this.cover = source["cover"];
+	        this.duration = source["duration"];
+	        this.position = source["position"];
+	        this.volume = source["volume"];
+	        this.state = source["state"];
+	    }
+	}
+	export class ListeningTime {
+	    seconds: number;
+	    plays: number;
+	    skips: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ListeningTime(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seconds = source["seconds"];
+	        this.plays = source["plays"];
+	        this.skips = source["skips"];
+	    }
+	}
+	export class NotificationConfig {
+	    enabled: boolean;
+	    debounceMs: number;
+	    mutedPlayers: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new NotificationConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.debounceMs = source["debounceMs"];
+	        this.mutedPlayers = source["mutedPlayers"];
+	    }
+	}
+	export class NowPlayingConfig {
+	    enabled: boolean;
+	    files: app.NowPlayingFile[];
+	    jsonPath: string;
+	    coverPath: string;
+	    intervalMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new NowPlayingConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.files = this.convertValues(source["files"], app.NowPlayingFile);
+	        this.jsonPath = source["jsonPath"];
+	        this.coverPath = source["coverPath"];
+	        this.intervalMs = source["intervalMs"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NowPlayingFile {
+	    path: string;
+	    template: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NowPlayingFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.template = source["template"];
+	    }
+	}
+	export class OverlayConfig {
+	    enabled: boolean;
+	    port: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OverlayConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.port = source["port"];
+	    }
+	}
+	export class PlayStat {
+	    artist: string;
+	    title?: string;
+	    plays: number;
+	    skips: number;
+	    listened: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlayStat(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.artist = source["artist"];
+	        this.title = source["title"];
+	        this.plays = source["plays"];
+	        this.skips = source["skips"];
+	        this.listened = source["listened"];
+	    }
+	}
+
+}
+
 export namespace media {
 	
+	export class CaptureStats {
+	    running: boolean;
+	    sessionOpen: boolean;
+	    captureMode: string;
+	    deviceId: string;
+	    sampleRate: number;
+	    channels: number;
+	    bitsPerSample: number;
+	    framesCaptured: number;
+	    packetsCaptured: number;
+	    silentPackets: number;
+	    glitchPackets: number;
+	    analysesRun: number;
+	    silenceFrames: number;
+	    reinitAttempts: number;
+	    reinitFailures: number;
+	    deviceChanges: number;
+	    bufferedSamples: number;
+	    analysisLatencyMs: number;
+	    lastError: string;
+	    lastErrorAt: number;
+	    lastFrameAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CaptureStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.running = source["running"];
+	        this.sessionOpen = source["sessionOpen"];
+	        this.captureMode = source["captureMode"];
+	        this.deviceId = source["deviceId"];
+	        this.sampleRate = source["sampleRate"];
+	        this.channels = source["channels"];
+	        this.bitsPerSample = source["bitsPerSample"];
+	        this.framesCaptured = source["framesCaptured"];
+	        this.packetsCaptured = source["packetsCaptured"];
+	        this.silentPackets = source["silentPackets"];
+	        this.glitchPackets = source["glitchPackets"];
+	        this.analysesRun = source["analysesRun"];
+	        this.silenceFrames = source["silenceFrames"];
+	        this.reinitAttempts = source["reinitAttempts"];
+	        this.reinitFailures = source["reinitFailures"];
+	        this.deviceChanges = source["deviceChanges"];
+	        this.bufferedSamples = source["bufferedSamples"];
+	        this.analysisLatencyMs = source["analysisLatencyMs"];
+	        this.lastError = source["lastError"];
+	        this.lastErrorAt = source["lastErrorAt"];
+	        this.lastFrameAt = source["lastFrameAt"];
+	    }
+	}
+	export class Command {
+	    kind: string;
+	    value: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Command(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.value = source["value"];
+	    }
+	}
+	export class DiscordConfig {
+	    enabled: boolean;
+	    clientId: string;
+	    allowedPlayers: string[];
+	    clearAfterPauseSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiscordConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.clientId = source["clientId"];
+	        this.allowedPlayers = source["allowedPlayers"];
+	        this.clearAfterPauseSeconds = source["clearAfterPauseSeconds"];
+	    }
+	}
+	export class DiscordStatus {
+	    connected: boolean;
+	    user: string;
+	    lastError: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiscordStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.connected = source["connected"];
+	        this.user = source["user"];
+	        this.lastError = source["lastError"];
+	    }
+	}
+	export class FFTConfig {
+	    fftSize: number;
+	    freqMin: number;
+	    freqMax: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FFTConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fftSize = source["fftSize"];
+	        this.freqMin = source["freqMin"];
+	        this.freqMax = source["freqMax"];
+	    }
+	}
+	export class LyricLine {
+	    time: number;
+	    text: string;
+	    words?: media.LyricWord[];
+	
+	    static createFrom(source: any = {}) {
+	        return new LyricLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = source["time"];
+	        this.text = source["text"];
+	        this.words = this.convertValues(source["words"], media.LyricWord);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LyricWord {
+	    time: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LyricWord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = source["time"];
+	        this.text = source["text"];
+	    }
+	}
+	export class Lyrics {
+	    artist: string;
+	    title: string;
+	    album: string;
+	    offset: number;
+	    lines: media.LyricLine[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Lyrics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.artist = source["artist"];
+	        this.title = source["title"];
+	        this.album = source["album"];
+	        this.offset = source["offset"];
+	        this.lines = this.convertValues(source["lines"], media.LyricLine);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MPDConfig {
+	    enabled: boolean;
+	    address: string;
+	    password: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MPDConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.address = source["address"];
+	        this.password = source["password"];
+	    }
+	}
+	export class MPDStatus {
+	    connected: boolean;
+	    version: string;
+	    lastError: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MPDStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.connected = source["connected"];
+	        this.version = source["version"];
+	        this.lastError = source["lastError"];
+	    }
+	}
+	export class MQTTConfig {
+	    enabled: boolean;
+	    broker: string;
+	    username: string;
+	    password: string;
+	    topicPrefix: string;
+	    discovery: boolean;
+	    discoveryPrefix: string;
+	    retain: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MQTTConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.broker = source["broker"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.topicPrefix = source["topicPrefix"];
+	        this.discovery = source["discovery"];
+	        this.discoveryPrefix = source["discoveryPrefix"];
+	        this.retain = source["retain"];
+	    }
+	}
+	export class MQTTStatus {
+	    connected: boolean;
+	    lastError: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MQTTStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.connected = source["connected"];
+	        this.lastError = source["lastError"];
+	    }
+	}
+	export class OSCAddresses {
+	    bands: string;
+	    band: string;
+	    rms: string;
+	    beat: string;
+	    track: string;
+	    playing: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OSCAddresses(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.bands = source["bands"];
+	        this.band = source["band"];
+	        this.rms = source["rms"];
+	        this.beat = source["beat"];
+	        this.track = source["track"];
+	        this.playing = source["playing"];
+	    }
+	}
+	export class OSCConfig {
+	    enabled: boolean;
+	    host: string;
+	    port: number;
+	    rateHz: number;
+	    addresses: media.OSCAddresses;
+	
+	    static createFrom(source: any = {}) {
+	        return new OSCConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.rateHz = source["rateHz"];
+	        this.addresses = this.convertValues(source["addresses"], media.OSCAddresses);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class Player {
 	    id: number;
+	    source: string;
 	    name: string;
 	    title: string;
 	    artist: string;
@@ -27,6 +651,9 @@ export namespace media {
 	    createdAt: number;
 	    updatedAt: number;
 	    activeAt: number;
+	    positionAt: number;
+	    playbackRate: number;
+	    estimatedPosition: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new Player(source);
@@ -35,6 +662,7 @@ export namespace media {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
+	        this.source = source["source"];
 	        this.name = source["name"];
 	        this.title = source["title"];
 	        this.artist = source["artist"];
@@ -60,6 +688,135 @@ export namespace media {
 	        this.createdAt = source["createdAt"];
 	        this.updatedAt = source["updatedAt"];
 	        this.activeAt = source["activeAt"];
+	        this.positionAt = source["positionAt"];
+	        this.playbackRate = source["playbackRate"];
+	        this.estimatedPosition = source["estimatedPosition"];
+	    }
+	}
+	export class PlayerRules {
+	    pinned: string;
+	    blocklist: string[];
+	    hideMuted: boolean;
+	    priority: string[];
+	    stickySeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlayerRules(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pinned = source["pinned"];
+	        this.blocklist = source["blocklist"];
+	        this.hideMuted = source["hideMuted"];
+	        this.priority = source["priority"];
+	        this.stickySeconds = source["stickySeconds"];
+	    }
+	}
+	export class RecordingStatus {
+	    active: boolean;
+	    wavPath: string;
+	    framesPath: string;
+	    seconds: number;
+	    maxSeconds: number;
+	    packets: number;
+	    levelFrames: number;
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.active = source["active"];
+	        this.wavPath = source["wavPath"];
+	        this.framesPath = source["framesPath"];
+	        this.seconds = source["seconds"];
+	        this.maxSeconds = source["maxSeconds"];
+	        this.packets = source["packets"];
+	        this.levelFrames = source["levelFrames"];
+	        this.error = source["error"];
+	    }
+	}
+	export class ScrobblerConfig {
+	    enabled: boolean;
+	    endpoint: string;
+	    token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScrobblerConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.endpoint = source["endpoint"];
+	        this.token = source["token"];
+	    }
+	}
+	export class ScrobblerStatus {
+	    enabled: boolean;
+	    queued: number;
+	    submitted: number;
+	    nowPlaying: string;
+	    lastError: string;
+	    lastErrorAt: number;
+	    lastSubmitAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScrobblerStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.queued = source["queued"];
+	        this.submitted = source["submitted"];
+	        this.nowPlaying = source["nowPlaying"];
+	        this.lastError = source["lastError"];
+	        this.lastErrorAt = source["lastErrorAt"];
+	        this.lastSubmitAt = source["lastSubmitAt"];
+	    }
+	}
+	export class ServerStats {
+	    port: number;
+	    connected: boolean;
+	    connections: number;
+	    disconnections: number;
+	    messagesReceived: number;
+	    binaryMessages: number;
+	    parseErrors: number;
+	    commandsSent: number;
+	    commandErrors: number;
+	    playerCount: number;
+	    activePlayerId: number;
+	    lastError: string;
+	    lastErrorAt: number;
+	    lastMessageAt: number;
+	    connectedAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ServerStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.port = source["port"];
+	        this.connected = source["connected"];
+	        this.connections = source["connections"];
+	        this.disconnections = source["disconnections"];
+	        this.messagesReceived = source["messagesReceived"];
+	        this.binaryMessages = source["binaryMessages"];
+	        this.parseErrors = source["parseErrors"];
+	        this.commandsSent = source["commandsSent"];
+	        this.commandErrors = source["commandErrors"];
+	        this.playerCount = source["playerCount"];
+	        this.activePlayerId = source["activePlayerId"];
+	        this.lastError = source["lastError"];
+	        this.lastErrorAt = source["lastErrorAt"];
+	        this.lastMessageAt = source["lastMessageAt"];
+	        this.connectedAt = source["connectedAt"];
 	    }
 	}
 
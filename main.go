@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
@@ -19,8 +20,8 @@ var assets embed.FS
 
 // getWebViewDataPath returns the path for WebView2 user data
 func getWebViewDataPath() string {
-	appData := os.Getenv("APPDATA")
-	if appData == "" {
+	appData, err := os.UserConfigDir()
+	if err != nil {
 		appData = "."
 	}
 	// Use the same folder as config (without .exe suffix)
@@ -30,14 +31,18 @@ func getWebViewDataPath() string {
 }
 
 func main() {
-	// Create application instance
-	application := app.NewApp()
+	// Create application instance; it also serves the frontend to OBS overlays
+	dist, err := fs.Sub(assets, "frontend/dist")
+	if err != nil {
+		log.Fatal(err)
+	}
+	application := app.NewApp(dist)
 
 	// Load saved window position
 	x, y := application.LoadWindowPosition()
 
 	// Create application with options
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:             "Round Sound",
 		Width:             600,
 		Height:            600,
@@ -1,3 +1,5 @@
+//go:build windows
+
 package media
 
 import (
@@ -5,11 +7,13 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"syscall"
 	"time"
 	"unsafe"
 
 	"github.com/go-ole/go-ole"
 	"github.com/moutend/go-wca/pkg/wca"
+	"golang.org/x/sys/windows"
 )
 
 const (
@@ -19,26 +23,64 @@ const (
 	// AUDCLNT_E_DEVICE_INVALIDATED full HRESULT: severity(0x8) + facility AUDCLNT(0x889) + code(0x004).
 	hrAudClntDeviceInvalidated uintptr = 0x88890004
 
-	// reinitBackoffTicks throttles WASAPI re-initialization attempts to ~500ms when the
-	// session is down, so we don't hammer COM at the full 60Hz refresh rate.
-	reinitBackoffTicks = RefreshRate / 2
+	// reinitBackoff throttles WASAPI re-initialization attempts when the session is
+	// down, so we don't hammer COM at the full 60Hz refresh rate.
+	reinitBackoff = 500 * time.Millisecond
 
 	// defaultDeviceCheckInterval polls the current default render endpoint every
 	// ~166ms to detect Windows "default output" switches. WASAPI does NOT signal
 	// AUDCLNT_E_DEVICE_INVALIDATED in that scenario when the old device is still
 	// physically connected — the loopback session just goes silent. Polling the
 	// default endpoint ID is the only reliable way to react.
-	defaultDeviceCheckInterval = 10
+	defaultDeviceCheckInterval = 166 * time.Millisecond
+
+	// eventWaitTimeoutMs bounds the wait on the WASAPI buffer event. A loopback client
+	// is not signalled while nothing is being rendered, so on timeout we poll once and
+	// emit silence at the regular refresh rate.
+	eventWaitTimeoutMs = 1000 / RefreshRate
+)
+
+// Capture modes reported in CaptureStats
+const (
+	CaptureModeEvent   = "event"
+	CaptureModePolling = "polling"
+)
+
+var (
+	kernel32                      = syscall.NewLazyDLL("kernel32.dll")
+	procQueryPerformanceCounter   = kernel32.NewProc("QueryPerformanceCounter")
+	procQueryPerformanceFrequency = kernel32.NewProc("QueryPerformanceFrequency")
 )
 
+// packetTiming carries GetBuffer timing for the newest sample of a captured packet
+type packetTiming struct {
+	qpcPosition    uint64
+	devicePosition uint64
+	capturedAt     time.Time
+}
+
 type AudioLevelCapture struct {
 	mu          sync.RWMutex
 	isCapturing bool
 	stopChan    chan struct{}
-	callback    func([]float32)
+	callback    LevelsCallback
 	config      FFTConfig
 	buffer      []float32
 	bufferSize  int
+
+	// eventDriven selects AUDCLNT_STREAMFLAGS_EVENTCALLBACK capture; it is cleared
+	// for good once an endpoint refuses event mode but accepts polling.
+	eventDriven bool
+	// discontinuity is reported on the next emitted frame after the window was reset
+	discontinuity bool
+
+	// source replaces WASAPI with a recorded file (see NewFileAudioCapture)
+	source       *fileSource
+	onSourceDone func(error)
+	recorder     *Recorder
+
+	statsMu sync.Mutex
+	stats   CaptureStats
 }
 
 // wasapiSession bundles the WASAPI stack needed for one loopback capture.
@@ -55,6 +97,7 @@ type wasapiSession struct {
 	pwfx          *wca.WAVEFORMATEX
 	sampleRate    uint32
 	deviceID      string
+	event         windows.Handle // buffer-ready event, zero in polling mode
 }
 
 func (s *wasapiSession) release() {
@@ -74,13 +117,20 @@ func (s *wasapiSession) release() {
 		s.mmd.Release()
 		s.mmd = nil
 	}
+	if s.event != 0 {
+		windows.CloseHandle(s.event)
+		s.event = 0
+	}
 	if s.pwfx != nil {
 		ole.CoTaskMemFree(uintptr(unsafe.Pointer(s.pwfx)))
 		s.pwfx = nil
 	}
 }
 
-func openWASAPISession(mmde *wca.IMMDeviceEnumerator) (*wasapiSession, error) {
+// openWASAPISession opens a loopback capture on the default render endpoint.
+// With eventDriven set the client is initialized with AUDCLNT_STREAMFLAGS_EVENTCALLBACK
+// and signals s.event whenever a buffer is ready, instead of being polled.
+func openWASAPISession(mmde *wca.IMMDeviceEnumerator, eventDriven bool) (*wasapiSession, error) {
 	s := &wasapiSession{}
 
 	if err := mmde.GetDefaultAudioEndpoint(wca.ERender, wca.EConsole, &s.mmd); err != nil {
@@ -105,17 +155,36 @@ func openWASAPISession(mmde *wca.IMMDeviceEnumerator) (*wasapiSession, error) {
 
 	s.sampleRate = s.pwfx.NSamplesPerSec
 
+	streamFlags := uint32(wca.AUDCLNT_STREAMFLAGS_LOOPBACK)
+	if eventDriven {
+		streamFlags |= wca.AUDCLNT_STREAMFLAGS_EVENTCALLBACK
+	}
+
 	hnsRequestedDuration := wca.REFERENCE_TIME(10000000)
 	if err := s.audioClient.Initialize(
 		wca.AUDCLNT_SHAREMODE_SHARED,
-		wca.AUDCLNT_STREAMFLAGS_LOOPBACK,
+		streamFlags,
 		hnsRequestedDuration,
 		0,
 		s.pwfx,
 		nil,
 	); err != nil {
 		s.release()
-		return nil, fmt.Errorf("initialize audio client (LOOPBACK): %w", err)
+		return nil, fmt.Errorf("initialize audio client (LOOPBACK, event=%v): %w", eventDriven, err)
+	}
+
+	if eventDriven {
+		event, err := windows.CreateEvent(nil, 0, 0, nil)
+		if err != nil {
+			s.release()
+			return nil, fmt.Errorf("create buffer event: %w", err)
+		}
+		s.event = event
+
+		if err := s.audioClient.SetEventHandle(uintptr(event)); err != nil {
+			s.release()
+			return nil, fmt.Errorf("set event handle: %w", err)
+		}
 	}
 
 	if err := s.audioClient.GetService(wca.IID_IAudioCaptureClient, &s.captureClient); err != nil {
@@ -128,12 +197,36 @@ func openWASAPISession(mmde *wca.IMMDeviceEnumerator) (*wasapiSession, error) {
 		return nil, fmt.Errorf("start audio client: %w", err)
 	}
 
-	log.Printf("[AudioLevels] WASAPI loopback opened: deviceID=%s, sampleRate=%d Hz, channels=%d, bitsPerSample=%d",
-		s.deviceID, s.sampleRate, s.pwfx.NChannels, s.pwfx.WBitsPerSample)
+	log.Printf("[AudioLevels] WASAPI loopback opened: deviceID=%s, sampleRate=%d Hz, channels=%d, bitsPerSample=%d, mode=%s",
+		s.deviceID, s.sampleRate, s.pwfx.NChannels, s.pwfx.WBitsPerSample, s.mode())
 
 	return s, nil
 }
 
+// format describes the session mix format as written to recordings
+func (s *wasapiSession) format() pcmFormat {
+	f := pcmFormat{
+		FormatTag:     wavFormatPCM,
+		Channels:      s.pwfx.NChannels,
+		SampleRate:    s.sampleRate,
+		BitsPerSample: s.pwfx.WBitsPerSample,
+		BlockAlign:    s.pwfx.NBlockAlign,
+	}
+	// Shared-mode mix formats with 32-bit samples are always float
+	if f.BitsPerSample == 32 {
+		f.FormatTag = wavFormatIEEEFloat
+	}
+	return f
+}
+
+// mode reports whether the session is event-driven or polled
+func (s *wasapiSession) mode() string {
+	if s.event != 0 {
+		return CaptureModeEvent
+	}
+	return CaptureModePolling
+}
+
 // currentDefaultDeviceID returns the ID of the current default render endpoint,
 // without keeping any references — caller decides whether to act on it.
 func currentDefaultDeviceID(mmde *wca.IMMDeviceEnumerator) (string, error) {
@@ -160,12 +253,35 @@ func isDeviceInvalidated(err error) bool {
 	return false
 }
 
-func NewAudioLevelCapture(callback func([]float32)) *AudioLevelCapture {
+// qpcNow returns the current QueryPerformanceCounter value in 100ns units,
+// the same scale GetBuffer uses for qpcPosition.
+func qpcNow() uint64 {
+	var counter, freq int64
+	procQueryPerformanceCounter.Call(uintptr(unsafe.Pointer(&counter)))
+	procQueryPerformanceFrequency.Call(uintptr(unsafe.Pointer(&freq)))
+	if freq == 0 {
+		return 0
+	}
+	return uint64(counter/freq)*10000000 + uint64(counter%freq)*10000000/uint64(freq)
+}
+
+// qpcToTime converts a GetBuffer QPC position to wall-clock time
+func qpcToTime(qpc uint64) time.Time {
+	now := time.Now()
+	current := qpcNow()
+	if qpc == 0 || current < qpc {
+		return now
+	}
+	return now.Add(-time.Duration(current-qpc) * 100)
+}
+
+func NewAudioLevelCapture(callback LevelsCallback) *AudioLevelCapture {
 	return &AudioLevelCapture{
-		callback:   callback,
-		stopChan:   make(chan struct{}),
-		config:     DefaultFFTConfig(),
-		bufferSize: 0,
+		callback:    callback,
+		stopChan:    make(chan struct{}),
+		config:      DefaultFFTConfig(),
+		bufferSize:  0,
+		eventDriven: true,
 	}
 }
 
@@ -180,6 +296,13 @@ func (a *AudioLevelCapture) UpdateConfig(fftSize int, freqMin, freqMax float64)
 	log.Printf("[AudioLevels] Config updated: FFTSize=%d, FreqMin=%.1f, FreqMax=%.1f", fftSize, freqMin, freqMax)
 }
 
+// Config returns the current FFT configuration
+func (a *AudioLevelCapture) Config() FFTConfig {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.config
+}
+
 func (a *AudioLevelCapture) Start() error {
 	a.mu.Lock()
 	if a.isCapturing {
@@ -189,7 +312,15 @@ func (a *AudioLevelCapture) Start() error {
 	a.isCapturing = true
 	a.mu.Unlock()
 
-	go a.captureLoop()
+	a.statsMu.Lock()
+	a.stats.Running = true
+	a.statsMu.Unlock()
+
+	if a.source != nil {
+		go a.replayLoop(a.source)
+	} else {
+		go a.captureLoop()
+	}
 	log.Println("[AudioLevels] FFT Capture started")
 	return nil
 }
@@ -204,12 +335,106 @@ func (a *AudioLevelCapture) Stop() {
 
 	close(a.stopChan)
 	a.isCapturing = false
+
+	a.statsMu.Lock()
+	a.stats.Running = false
+	a.statsMu.Unlock()
+
 	log.Println("[AudioLevels] FFT Capture stopped")
 }
 
+// SetRecorder starts (or with nil, stops) teeing captured packets and emitted
+// frames into r. The caller owns r and is responsible for closing it.
+func (a *AudioLevelCapture) SetRecorder(r *Recorder) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.recorder = r
+}
+
+func (a *AudioLevelCapture) getRecorder() *Recorder {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.recorder
+}
+
+// Stats returns a snapshot of the capture pipeline counters
+func (a *AudioLevelCapture) Stats() CaptureStats {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	return a.stats
+}
+
+// recordError remembers the last pipeline error for diagnostics
+func (a *AudioLevelCapture) recordError(err error) {
+	a.statsMu.Lock()
+	a.stats.LastError = err.Error()
+	a.stats.LastErrorAt = time.Now().UnixMilli()
+	a.statsMu.Unlock()
+}
+
+// recordSession updates device/format info when a WASAPI session is opened or dropped
+func (a *AudioLevelCapture) recordSession(s *wasapiSession) {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+
+	if s == nil {
+		a.stats.SessionOpen = false
+		return
+	}
+	a.stats.SessionOpen = true
+	a.stats.CaptureMode = s.mode()
+	a.stats.DeviceID = s.deviceID
+	a.stats.SampleRate = s.sampleRate
+	a.stats.Channels = s.pwfx.NChannels
+	a.stats.BitsPerSample = s.pwfx.WBitsPerSample
+}
+
+// openSession opens a WASAPI session, preferring event-driven capture and falling
+// back to ticker polling when the endpoint refuses AUDCLNT_STREAMFLAGS_EVENTCALLBACK.
+func (a *AudioLevelCapture) openSession(mmde *wca.IMMDeviceEnumerator) (*wasapiSession, error) {
+	if !a.eventDriven {
+		return openWASAPISession(mmde, false)
+	}
+
+	s, err := openWASAPISession(mmde, true)
+	if err == nil {
+		return s, nil
+	}
+
+	log.Printf("[AudioLevels] Event-driven capture unavailable, trying polling: %v", err)
+	s, err = openWASAPISession(mmde, false)
+	if err == nil {
+		a.eventDriven = false
+	}
+	return s, err
+}
+
+// waitForPacket blocks until the next capture step: the WASAPI buffer event in
+// event-driven mode, the refresh ticker otherwise. Returns false once capture is stopped.
+func (a *AudioLevelCapture) waitForPacket(s *wasapiSession, ticker *time.Ticker) bool {
+	if s != nil && s.event != 0 {
+		if _, err := windows.WaitForSingleObject(s.event, eventWaitTimeoutMs); err == nil {
+			select {
+			case <-a.stopChan:
+				return false
+			default:
+				return true
+			}
+		}
+	}
+
+	select {
+	case <-a.stopChan:
+		return false
+	case <-ticker.C:
+		return true
+	}
+}
+
 func (a *AudioLevelCapture) captureLoop() {
 	if err := ole.CoInitializeEx(0, ole.COINIT_APARTMENTTHREADED); err != nil {
 		log.Printf("[AudioLevels] Failed to initialize COM: %v", err)
+		a.recordError(fmt.Errorf("initialize COM: %w", err))
 		return
 	}
 	defer ole.CoUninitialize()
@@ -222,6 +447,7 @@ func (a *AudioLevelCapture) captureLoop() {
 	var mmde *wca.IMMDeviceEnumerator
 	if err := wca.CoCreateInstance(wca.CLSID_MMDeviceEnumerator, 0, wca.CLSCTX_ALL, wca.IID_IMMDeviceEnumerator, &mmde); err != nil {
 		log.Printf("[AudioLevels] Failed to create device enumerator: %v", err)
+		a.recordError(fmt.Errorf("create device enumerator: %w", err))
 		return
 	}
 	defer mmde.Release()
@@ -229,59 +455,69 @@ func (a *AudioLevelCapture) captureLoop() {
 	var session *wasapiSession
 	defer func() { session.release() }()
 
-	session, err := openWASAPISession(mmde)
+	session, err := a.openSession(mmde)
 	if err != nil {
 		log.Printf("[AudioLevels] Initial WASAPI open failed: %v", err)
+		a.recordError(err)
 	}
+	a.recordSession(session)
+
+	lastReinitAttempt := time.Now()
+	lastDeviceCheck := time.Now()
 
-	ticksSinceReinitAttempt := 0
-	ticksSinceDeviceCheck := 0
-
-	for {
-		select {
-		case <-a.stopChan:
-			return
-		case <-ticker.C:
-			if session == nil {
-				ticksSinceReinitAttempt++
-				if ticksSinceReinitAttempt >= reinitBackoffTicks {
-					ticksSinceReinitAttempt = 0
-					if s, err := openWASAPISession(mmde); err == nil {
-						session = s
-						a.buffer = a.buffer[:0]
-						log.Println("[AudioLevels] WASAPI session re-opened")
-					} else {
-						log.Printf("[AudioLevels] Reinit attempt failed: %v", err)
-					}
+	// dropSession releases the current session and schedules a re-open after the backoff
+	dropSession := func() {
+		session.release()
+		session = nil
+		a.recordSession(nil)
+		lastReinitAttempt = time.Now()
+		a.sendSilence()
+	}
+
+	for a.waitForPacket(session, ticker) {
+		if session == nil {
+			if time.Since(lastReinitAttempt) >= reinitBackoff {
+				lastReinitAttempt = time.Now()
+				a.statsMu.Lock()
+				a.stats.ReinitAttempts++
+				a.statsMu.Unlock()
+				if s, err := a.openSession(mmde); err == nil {
+					session = s
+					a.resetBuffer()
+					a.recordSession(session)
+					log.Println("[AudioLevels] WASAPI session re-opened")
+				} else {
+					a.statsMu.Lock()
+					a.stats.ReinitFailures++
+					a.statsMu.Unlock()
+					a.recordError(err)
+					log.Printf("[AudioLevels] Reinit attempt failed: %v", err)
 				}
-				a.sendSilence()
-				continue
 			}
+			a.sendSilence()
+			continue
+		}
 
-			ticksSinceDeviceCheck++
-			if ticksSinceDeviceCheck >= defaultDeviceCheckInterval {
-				ticksSinceDeviceCheck = 0
-				if currentID, err := currentDefaultDeviceID(mmde); err == nil && currentID != session.deviceID {
-					log.Printf("[AudioLevels] Default render endpoint changed (%s -> %s) — reopening WASAPI session", session.deviceID, currentID)
-					session.release()
-					session = nil
-					ticksSinceReinitAttempt = 0
-					a.sendSilence()
-					continue
-				}
+		if time.Since(lastDeviceCheck) >= defaultDeviceCheckInterval {
+			lastDeviceCheck = time.Now()
+			if currentID, err := currentDefaultDeviceID(mmde); err == nil && currentID != session.deviceID {
+				log.Printf("[AudioLevels] Default render endpoint changed (%s -> %s) — reopening WASAPI session", session.deviceID, currentID)
+				a.statsMu.Lock()
+				a.stats.DeviceChanges++
+				a.statsMu.Unlock()
+				dropSession()
+				continue
 			}
+		}
 
-			if err := a.processAudioFrame(session); err != nil {
-				if isDeviceInvalidated(err) {
-					log.Println("[AudioLevels] Audio endpoint invalidated — reopening WASAPI session")
-				} else {
-					log.Printf("[AudioLevels] Capture error, reopening session: %v", err)
-				}
-				session.release()
-				session = nil
-				ticksSinceReinitAttempt = 0
-				a.sendSilence()
+		if err := a.processAudioFrame(session); err != nil {
+			if isDeviceInvalidated(err) {
+				log.Println("[AudioLevels] Audio endpoint invalidated — reopening WASAPI session")
+			} else {
+				log.Printf("[AudioLevels] Capture error, reopening session: %v", err)
 			}
+			a.recordError(err)
+			dropSession()
 		}
 	}
 }
@@ -300,11 +536,46 @@ func (a *AudioLevelCapture) processAudioFrame(s *wasapiSession) error {
 	var pData *byte
 	var numFrames uint32
 	var flags uint32
+	var devicePosition uint64
+	var qpcPosition uint64
 
 	for packetLength > 0 {
-		if err := s.captureClient.GetBuffer(&pData, &numFrames, &flags, nil, nil); err != nil {
+		if err := s.captureClient.GetBuffer(&pData, &numFrames, &flags, &devicePosition, &qpcPosition); err != nil {
 			return err
 		}
+		capturedAt := time.Now()
+
+		a.statsMu.Lock()
+		a.stats.PacketsCaptured++
+		a.stats.FramesCaptured += uint64(numFrames)
+		a.stats.LastFrameAt = capturedAt.UnixMilli()
+		if flags&wca.AUDCLNT_BUFFERFLAGS_DATA_DISCONTINUITY != 0 {
+			a.stats.GlitchPackets++
+		}
+		if flags&wca.AUDCLNT_BUFFERFLAGS_SILENT != 0 {
+			a.stats.SilentPackets++
+		}
+		a.statsMu.Unlock()
+
+		// After a glitch the buffered samples are no longer contiguous with this packet —
+		// drop them so the analysis window never mixes stale and fresh audio.
+		if flags&wca.AUDCLNT_BUFFERFLAGS_DATA_DISCONTINUITY != 0 {
+			a.resetBuffer()
+			a.discontinuity = true
+		}
+
+		// qpcPosition refers to the first frame of the packet; timing is tracked for the last one
+		timing := packetTiming{
+			devicePosition: devicePosition + uint64(numFrames),
+			capturedAt:     capturedAt,
+		}
+		if flags&wca.AUDCLNT_BUFFERFLAGS_TIMESTAMP_ERROR == 0 && qpcPosition != 0 && s.sampleRate != 0 {
+			timing.qpcPosition = qpcPosition + uint64(numFrames)*10000000/uint64(s.sampleRate)
+		}
+
+		if rec := a.getRecorder(); rec != nil {
+			a.recordPacket(rec, s, pData, numFrames, flags, devicePosition, qpcPosition)
+		}
 
 		if flags&wca.AUDCLNT_BUFFERFLAGS_SILENT != 0 || numFrames == 0 {
 			if err := s.captureClient.ReleaseBuffer(numFrames); err != nil {
@@ -316,7 +587,7 @@ func (a *AudioLevelCapture) processAudioFrame(s *wasapiSession) error {
 			if err := s.captureClient.ReleaseBuffer(numFrames); err != nil {
 				return err
 			}
-			a.sendFFTLevels(samples, s.sampleRate)
+			a.sendFFTLevels(samples, s.sampleRate, timing)
 		}
 
 		if err := s.captureClient.GetNextPacketSize(&packetLength); err != nil {
@@ -326,6 +597,41 @@ func (a *AudioLevelCapture) processAudioFrame(s *wasapiSession) error {
 	return nil
 }
 
+// recordPacket copies a WASAPI packet into the recorder before the buffer is released
+func (a *AudioLevelCapture) recordPacket(rec *Recorder, s *wasapiSession, pData *byte, numFrames, flags uint32, devicePosition, qpcPosition uint64) {
+	format := s.format()
+	p := capturedPacket{
+		format:         format,
+		deviceID:       s.deviceID,
+		fft:            a.Config(),
+		frames:         numFrames,
+		flags:          flags,
+		devicePosition: devicePosition,
+		qpcPosition:    qpcPosition,
+	}
+	if flags&wca.AUDCLNT_BUFFERFLAGS_SILENT == 0 && numFrames > 0 {
+		size := int(numFrames) * int(format.BlockAlign)
+		p.data = make([]byte, size)
+		copy(p.data, (*[1 << 30]byte)(unsafe.Pointer(pData))[:size:size])
+	}
+	rec.writePacket(p)
+}
+
+// emit hands a frame to the callback and the active recorder
+func (a *AudioLevelCapture) emit(frame LevelFrame) {
+	if a.callback != nil {
+		a.callback(frame)
+	}
+	if rec := a.getRecorder(); rec != nil {
+		rec.writeFrame(frame)
+	}
+}
+
+// resetBuffer empties the analysis window (after a glitch or a session re-open)
+func (a *AudioLevelCapture) resetBuffer() {
+	a.buffer = a.buffer[:0]
+}
+
 func (a *AudioLevelCapture) extractSamples(pData *byte, numFrames uint32, pwfx *wca.WAVEFORMATEX) []float32 {
 	channels := pwfx.NChannels
 	totalSamples := int(numFrames) * int(channels)
@@ -357,7 +663,7 @@ func (a *AudioLevelCapture) extractSamples(pData *byte, numFrames uint32, pwfx *
 	return samples
 }
 
-func (a *AudioLevelCapture) sendFFTLevels(samples []float32, sampleRate uint32) {
+func (a *AudioLevelCapture) sendFFTLevels(samples []float32, sampleRate uint32, timing packetTiming) {
 	a.mu.RLock()
 	config := a.config
 	a.mu.RUnlock()
@@ -367,14 +673,36 @@ func (a *AudioLevelCapture) sendFFTLevels(samples []float32, sampleRate uint32)
 	if len(a.buffer) >= config.FFTSize {
 		levels := ProcessFFT(a.buffer[:config.FFTSize], sampleRate, config, BandCount)
 
+		// The window ends `lag` samples before the newest captured one
+		frame := LevelFrame{
+			Levels:        levels,
+			RMS:           RMS(a.buffer[:config.FFTSize]),
+			Discontinuity: a.discontinuity,
+		}
+		lag := uint64(len(a.buffer) - config.FFTSize)
+		if timing.devicePosition >= lag {
+			frame.DevicePosition = timing.devicePosition - lag
+		}
+		if timing.qpcPosition != 0 {
+			frame.QPCPosition = timing.qpcPosition - lag*10000000/uint64(sampleRate)
+			frame.Timestamp = qpcToTime(frame.QPCPosition).UnixMilli()
+		} else {
+			frame.Timestamp = timing.capturedAt.UnixMilli()
+		}
+		a.discontinuity = false
+
 		a.buffer = a.buffer[len(samples):]
 		if len(a.buffer) > config.FFTSize*2 {
 			a.buffer = a.buffer[len(a.buffer)-config.FFTSize:]
 		}
 
-		if a.callback != nil {
-			a.callback(levels)
-		}
+		a.emit(frame)
+
+		a.statsMu.Lock()
+		a.stats.AnalysesRun++
+		a.stats.AnalysisLatencyMs = float64(time.Since(timing.capturedAt).Microseconds()) / 1000
+		a.stats.BufferedSamples = len(a.buffer)
+		a.statsMu.Unlock()
 	}
 }
 
@@ -384,7 +712,13 @@ func (a *AudioLevelCapture) sendSilence() {
 		silence[i] = 0.05
 	}
 
-	if a.callback != nil {
-		a.callback(silence)
-	}
+	a.emit(LevelFrame{
+		Levels:    silence,
+		Timestamp: time.Now().UnixMilli(),
+		Silence:   true,
+	})
+
+	a.statsMu.Lock()
+	a.stats.SilenceFrames++
+	a.statsMu.Unlock()
 }
@@ -0,0 +1,60 @@
+//go:build !windows
+
+package media
+
+import (
+	"errors"
+	"sync"
+)
+
+// errCaptureUnsupported is returned where WASAPI loopback capture is not available
+var errCaptureUnsupported = errors.New("audio capture is only supported on Windows")
+
+// AudioLevelCapture is a placeholder outside Windows: it never produces frames
+type AudioLevelCapture struct {
+	mu       sync.RWMutex
+	config   FFTConfig
+	recorder *Recorder
+}
+
+func NewAudioLevelCapture(callback LevelsCallback) *AudioLevelCapture {
+	return &AudioLevelCapture{config: DefaultFFTConfig()}
+}
+
+// NewFileAudioCapture is not available outside Windows
+func NewFileAudioCapture(wavPath string, callback LevelsCallback, onDone func(error)) (*AudioLevelCapture, error) {
+	return nil, errCaptureUnsupported
+}
+
+func (a *AudioLevelCapture) UpdateConfig(fftSize int, freqMin, freqMax float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.config.FFTSize = fftSize
+	a.config.FreqMin = freqMin
+	a.config.FreqMax = freqMax
+}
+
+// Config returns the current FFT configuration
+func (a *AudioLevelCapture) Config() FFTConfig {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.config
+}
+
+func (a *AudioLevelCapture) Start() error {
+	return errCaptureUnsupported
+}
+
+func (a *AudioLevelCapture) Stop() {}
+
+// SetRecorder is accepted but nothing is ever recorded
+func (a *AudioLevelCapture) SetRecorder(r *Recorder) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.recorder = r
+}
+
+// Stats returns empty counters
+func (a *AudioLevelCapture) Stats() CaptureStats {
+	return CaptureStats{LastError: errCaptureUnsupported.Error()}
+}
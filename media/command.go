@@ -0,0 +1,150 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CommandKind identifies a media control command
+type CommandKind string
+
+const (
+	CommandPlay            CommandKind = "play"
+	CommandPause           CommandKind = "pause"
+	CommandTogglePlayPause CommandKind = "togglePlayPause"
+	CommandNext            CommandKind = "next"
+	CommandPrevious        CommandKind = "previous"
+	CommandToggleShuffle   CommandKind = "toggleShuffle"
+	CommandToggleRepeat    CommandKind = "toggleRepeat"
+	CommandSetRepeat       CommandKind = "setRepeat" // Value: RepeatMode
+	CommandSetRating       CommandKind = "setRating" // Value: raw WNP rating, checked against the rating system
+	CommandLike            CommandKind = "like"
+	CommandDislike         CommandKind = "dislike"
+	CommandClearRating     CommandKind = "clearRating"
+	CommandToggleLike      CommandKind = "toggleLike"
+	CommandSetStars        CommandKind = "setStars"  // Value: 0-5 stars
+	CommandSeek            CommandKind = "seek"      // Value: position in seconds
+	CommandSetVolume       CommandKind = "setVolume" // Value: 0-100
+
+	// Relative commands are resolved against the player state by the app layer
+	CommandSeekBy     CommandKind = "seekBy"   // Value: delta in seconds
+	CommandVolumeBy   CommandKind = "volumeBy" // Value: delta in percent
+	CommandMute       CommandKind = "mute"
+	CommandUnmute     CommandKind = "unmute"
+	CommandToggleMute CommandKind = "toggleMute"
+)
+
+var (
+	// ErrNoPlayer is returned when there is no player to send a command to
+	ErrNoPlayer = errors.New("no active player")
+	// ErrNotSupported is returned when the player does not support a command
+	ErrNotSupported = errors.New("command not supported by player")
+)
+
+// Command is a typed media control request
+type Command struct {
+	Kind  CommandKind `json:"kind"`
+	Value int         `json:"value"`
+}
+
+func (c Command) String() string {
+	return fmt.Sprintf("%s(%d)", c.Kind, c.Value)
+}
+
+// CommandSender delivers a raw WebNowPlaying command to a player and returns
+// the event ID its result will be reported with
+type CommandSender interface {
+	SendEvent(playerID int, command string, data interface{}) (string, error)
+}
+
+// Dispatch validates cmd against the player's capabilities and sends it through sender.
+// It returns the event ID of the sent command.
+func Dispatch(sender CommandSender, player *Player, cmd Command) (string, error) {
+	if sender == nil || player == nil {
+		return "", ErrNoPlayer
+	}
+
+	command, data, err := resolveCommand(player, cmd)
+	if err != nil {
+		return "", err
+	}
+	return sender.SendEvent(player.ID, command, data)
+}
+
+// resolveCommand maps a typed command to the WebNowPlaying command and its payload
+func resolveCommand(player *Player, cmd Command) (string, interface{}, error) {
+	switch cmd.Kind {
+	case CommandPlay:
+		return require(player.CanSetState, cmd, "STATE", 1) // 1 = PLAYING
+	case CommandPause:
+		return require(player.CanSetState, cmd, "STATE", 2) // 2 = PAUSED
+	case CommandTogglePlayPause:
+		if player.State == StatePlaying {
+			return require(player.CanSetState, cmd, "STATE", 2)
+		}
+		return require(player.CanSetState, cmd, "STATE", 1)
+	case CommandNext:
+		return require(player.CanSkipNext, cmd, "SKIP_NEXT", nil)
+	case CommandPrevious:
+		return require(player.CanSkipPrevious, cmd, "SKIP_PREVIOUS", nil)
+	case CommandToggleShuffle:
+		var val int
+		if !player.Shuffle {
+			val = 1
+		}
+		return require(player.CanSetShuffle, cmd, "SHUFFLE", val)
+	case CommandToggleRepeat:
+		return require(player.CanSetRepeat, cmd, "REPEAT", int(NextRepeatMode(player)))
+	case CommandSetRepeat:
+		mode := RepeatMode(cmd.Value)
+		if mode != RepeatNone && mode != RepeatAll && mode != RepeatOne {
+			return "", nil, fmt.Errorf("invalid repeat mode: %d", cmd.Value)
+		}
+		return require(player.CanSetRepeat && repeatAvailable(player, mode), cmd, "REPEAT", int(mode))
+	case CommandSetRating, CommandLike, CommandDislike, CommandClearRating, CommandToggleLike, CommandSetStars:
+		rating, err := RatingFor(player, cmd)
+		if err != nil {
+			return "", nil, err
+		}
+		return "RATING", rating, nil
+	case CommandSeek:
+		position := max(cmd.Value, 0)
+		if player.Duration > 0 {
+			position = min(position, player.Duration)
+		}
+		return require(player.CanSetPosition, cmd, "POSITION", position)
+	case CommandSetVolume:
+		return require(player.CanSetVolume, cmd, "VOLUME", min(max(cmd.Value, 0), 100))
+	}
+	return "", nil, fmt.Errorf("unknown command: %s", cmd.Kind)
+}
+
+// require returns the command when the capability is present, ErrNotSupported otherwise
+func require(capable bool, cmd Command, command string, data interface{}) (string, interface{}, error) {
+	if !capable {
+		return "", nil, fmt.Errorf("%s: %w", cmd.Kind, ErrNotSupported)
+	}
+	return command, data, nil
+}
+
+// repeatAvailable reports whether the player offers the repeat mode (an empty mask allows all)
+func repeatAvailable(player *Player, mode RepeatMode) bool {
+	return player.AvailableRepeat == 0 || player.AvailableRepeat&int(mode) != 0
+}
+
+// NextRepeatMode cycles NONE -> ALL -> ONE -> NONE, skipping modes the player does not offer
+func NextRepeatMode(player *Player) RepeatMode {
+	cycle := []RepeatMode{RepeatNone, RepeatAll, RepeatOne}
+	current := 0
+	for i, mode := range cycle {
+		if mode == player.Repeat {
+			current = i
+		}
+	}
+	for step := 1; step <= len(cycle); step++ {
+		if mode := cycle[(current+step)%len(cycle)]; repeatAvailable(player, mode) {
+			return mode
+		}
+	}
+	return RepeatNone
+}
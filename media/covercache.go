@@ -0,0 +1,281 @@
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCoverCacheSize caps the cover cache on disk; least recently used covers go first
+const DefaultCoverCacheSize = 64 << 20
+
+// ErrUnknownCoverFormat is returned for cover data that is not a JPEG, PNG or WebP image
+var ErrUnknownCoverFormat = errors.New("unknown cover image format")
+
+// coverFileName matches cache entries: the SHA-256 of the image and its sniffed extension
+var coverFileName = regexp.MustCompile(`^[0-9a-f]{64}\.(jpg|png|webp)$`)
+
+// CoverCache stores cover images under the hash of their content, so the same
+// album sent by several players or tabs is written once. Players carry the key
+// of their cover (the file name in the cache), resolved with Path or LocalPath.
+type CoverCache struct {
+	dir      string
+	maxBytes int64
+
+	mu     sync.Mutex
+	owners map[string]string // cover key in use per owner, never evicted
+}
+
+// NewCoverCache opens a cache in dir. Files that are not cache entries (covers
+// of older versions, interrupted writes) are removed and the size cap is enforced.
+func NewCoverCache(dir string, maxBytes int64) *CoverCache {
+	c := &CoverCache{dir: dir, maxBytes: maxBytes, owners: make(map[string]string)}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("[Covers] Failed to create %s: %v", dir, err)
+		return c
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("[Covers] Failed to read %s: %v", dir, err)
+		return c
+	}
+	removed := 0
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && coverFileName.MatchString(entry.Name()) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err == nil {
+			removed++
+		}
+	}
+	if removed > 0 {
+		log.Printf("[Covers] Removed %d stale file(s)", removed)
+	}
+
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	return c
+}
+
+// Put stores a cover as the one in use by owner (e.g. a player) and returns its
+// key. It replaces the owner's previous cover, which may be evicted from now on.
+// A cover already in the cache is not rewritten, only marked as recently used.
+func (c *CoverCache) Put(owner string, data []byte) (string, error) {
+	ext := coverExtension(data)
+	if ext == "" {
+		return "", ErrUnknownCoverFormat
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:]) + ext
+	path := filepath.Join(c.dir, key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err == nil {
+		c.owners[owner] = key
+		return key, nil
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(c.dir, ".cover-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	c.owners[owner] = key
+	c.evict()
+	return key, nil
+}
+
+// Release marks the cover of owner as no longer in use, once its player is gone
+// or shows another cover
+func (c *CoverCache) Release(owner string) {
+	c.mu.Lock()
+	delete(c.owners, owner)
+	c.mu.Unlock()
+}
+
+// evict removes the least recently used covers until the cache fits its cap.
+// Covers in use are never removed. Caller must hold mu.
+func (c *CoverCache) evict() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type cover struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	var covers []cover
+	var total int64
+	for _, entry := range entries {
+		if !coverFileName.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		covers = append(covers, cover{entry.Name(), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	if total <= c.maxBytes {
+		return
+	}
+	inUse := make(map[string]bool, len(c.owners))
+	for _, key := range c.owners {
+		inUse[key] = true
+	}
+
+	sort.Slice(covers, func(i, j int) bool {
+		return covers[i].modTime.Before(covers[j].modTime)
+	})
+	removed := 0
+	for _, cover := range covers {
+		if total <= c.maxBytes {
+			break
+		}
+		if inUse[cover.name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, cover.name)); err != nil {
+			continue
+		}
+		total -= cover.size
+		removed++
+	}
+	if removed > 0 {
+		log.Printf("[Covers] Evicted %d cover(s), %d KB left", removed, total>>10)
+	}
+}
+
+// coverExtension sniffs the image format of cover data ("" = not a supported image)
+func coverExtension(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return ".jpg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return ".png"
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return ".webp"
+	}
+	return ""
+}
+
+// IsCoverKey reports whether a player's cover is a key of the cover cache
+func IsCoverKey(cover string) bool {
+	return coverFileName.MatchString(cover)
+}
+
+// Path returns the file of a cached cover, or "" when key is not in the cache
+// (a nil cache has none)
+func (c *CoverCache) Path(key string) string {
+	if c == nil || !IsCoverKey(key) {
+		return ""
+	}
+	path := filepath.Join(c.dir, key)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// Location returns a cover as given to external tools: the file of a cached
+// cover, or the cover itself (a URL or path from the player)
+func (c *CoverCache) Location(cover string) string {
+	if IsCoverKey(cover) {
+		return c.Path(cover)
+	}
+	return cover
+}
+
+// LocalPath returns the player's cover as a local file path, or "" when the
+// cover is remote or missing. Besides cached covers only the file:// art URLs
+// of MPRIS players are trusted: any web page can send a WebNowPlaying cover, and
+// a local path from it must not be read and republished. The file must also
+// be an image.
+func (c *CoverCache) LocalPath(player *Player) string {
+	if player == nil {
+		return ""
+	}
+	if IsCoverKey(player.Cover) {
+		return c.Path(player.Cover)
+	}
+	if player.Source != SourceMPRIS || !strings.HasPrefix(player.Cover, "file://") {
+		return ""
+	}
+	u, err := url.Parse(player.Cover)
+	if err != nil {
+		return ""
+	}
+	path := filepath.FromSlash(u.Path)
+	if !filepath.IsAbs(path) || !isImageFile(path) {
+		return ""
+	}
+	return path
+}
+
+// isImageFile reports whether path is a regular file in a supported image format
+func isImageFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	header := make([]byte, 12)
+	n, _ := io.ReadFull(f, header)
+	return coverExtension(header[:n]) != ""
+}
+
+// ReadLocal returns the player's local cover file, or nil when the cover is
+// remote, missing or larger than limit
+func (c *CoverCache) ReadLocal(player *Player, limit int64) []byte {
+	path := c.LocalPath(player)
+	if path == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > limit {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return data
+}
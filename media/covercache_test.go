@@ -0,0 +1,54 @@
+package media
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCoverCacheLocalPath(t *testing.T) {
+	covers := NewCoverCache(t.TempDir(), DefaultCoverCacheSize)
+	key, err := covers.Put("test", testJPEG(4, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	image := filepath.Join(dir, "cover.jpg")
+	secret := filepath.Join(dir, "secret.txt")
+	os.WriteFile(image, testJPEG(5, 8), 0o644)
+	os.WriteFile(secret, []byte("password"), 0o644)
+	fileURL := func(path string) string {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	}
+
+	tests := []struct {
+		name   string
+		player *Player
+		want   string
+	}{
+		{"no player", nil, ""},
+		{"cached", &Player{Source: SourceWebNowPlaying, Cover: key}, covers.Path(key)},
+		{"evicted key", &Player{Source: SourceWebNowPlaying, Cover: "0000000000000000000000000000000000000000000000000000000000000000.jpg"}, ""},
+		{"remote", &Player{Source: SourceWebNowPlaying, Cover: "https://example.com/cover.jpg"}, ""},
+		{"WNP path", &Player{Source: SourceWebNowPlaying, Cover: image}, ""},
+		{"WNP file URL", &Player{Source: SourceWebNowPlaying, Cover: fileURL(image)}, ""},
+		{"MPRIS file URL", &Player{Source: SourceMPRIS, Cover: fileURL(image)}, image},
+		{"MPRIS path", &Player{Source: SourceMPRIS, Cover: image}, ""},
+		{"MPRIS non-image", &Player{Source: SourceMPRIS, Cover: fileURL(secret)}, ""},
+		{"MPRIS directory", &Player{Source: SourceMPRIS, Cover: fileURL(dir)}, ""},
+		{"MPRIS missing", &Player{Source: SourceMPRIS, Cover: fileURL(filepath.Join(dir, "gone.jpg"))}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := covers.LocalPath(tt.player); got != tt.want {
+				t.Errorf("LocalPath = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if data := covers.ReadLocal(&Player{Source: SourceWebNowPlaying, Cover: secret}, 1<<20); data != nil {
+		t.Errorf("read %q from a WebNowPlaying cover", data)
+	}
+}
@@ -0,0 +1,486 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDiscordClearAfterPause is how long a paused track stays in the presence
+	DefaultDiscordClearAfterPause = 30 * time.Second
+
+	discordRetryMin = 5 * time.Second
+	discordRetryMax = 2 * time.Minute
+	// discordDialTimeout bounds opening one IPC socket or pipe
+	discordDialTimeout = time.Second
+	// discordIOTimeout bounds a request and its reply, so a hung client is dropped
+	discordIOTimeout = 5 * time.Second
+	// discordMaxFrame caps frames read from the Discord client
+	discordMaxFrame = 1 << 20
+	// discordStartJitter keeps small position corrections from re-sending the presence
+	discordStartJitter = 2 * time.Second
+	// discordMaxText is the longest details/state string Discord accepts
+	discordMaxText = 128
+	// discordStopTimeout is how long Stop waits for the presence to be cleared
+	discordStopTimeout = 2 * time.Second
+)
+
+// Discord IPC opcodes
+const (
+	discordOpHandshake = 0
+	discordOpFrame     = 1
+	discordOpClose     = 2
+	discordOpPing      = 3
+	discordOpPong      = 4
+)
+
+// activityTypeListening shows "Listening to" instead of "Playing"
+const activityTypeListening = 2
+
+// DiscordConfig holds the Discord Rich Presence settings
+type DiscordConfig struct {
+	Enabled bool `json:"enabled"`
+	// ClientID is the ID of a Discord application; its name is shown as the activity name
+	ClientID string `json:"clientId"`
+	// AllowedPlayers limits the presence to these player names, case-insensitive (empty = all)
+	AllowedPlayers []string `json:"allowedPlayers"`
+	// ClearAfterPauseSeconds removes the presence after the track has been paused this long (0 = 30)
+	ClearAfterPauseSeconds int `json:"clearAfterPauseSeconds"`
+}
+
+// Validate checks the application ID
+func (c DiscordConfig) Validate() error {
+	if c.Enabled && c.ClientID == "" {
+		return fmt.Errorf("discord application ID is required")
+	}
+	if _, err := strconv.ParseUint(c.ClientID, 10, 64); c.ClientID != "" && err != nil {
+		return fmt.Errorf("invalid discord application ID: %s", c.ClientID)
+	}
+	if c.ClearAfterPauseSeconds < 0 {
+		return fmt.Errorf("pause timeout must not be negative")
+	}
+	return nil
+}
+
+func (c DiscordConfig) clearAfterPause() time.Duration {
+	if c.ClearAfterPauseSeconds <= 0 {
+		return DefaultDiscordClearAfterPause
+	}
+	return time.Duration(c.ClearAfterPauseSeconds) * time.Second
+}
+
+// allows reports whether the presence may show a player
+func (c DiscordConfig) allows(player *Player) bool {
+	if len(c.AllowedPlayers) == 0 {
+		return true
+	}
+	for _, name := range c.AllowedPlayers {
+		if strings.EqualFold(strings.TrimSpace(name), player.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscordStatus describes the Discord connection for the settings page
+type DiscordStatus struct {
+	Connected bool   `json:"connected"`
+	User      string `json:"user"`
+	LastError string `json:"lastError"`
+}
+
+// discordActivity is the activity sent with SET_ACTIVITY
+type discordActivity struct {
+	Type       int                        `json:"type"`
+	Details    string                     `json:"details,omitempty"`
+	State      string                     `json:"state,omitempty"`
+	Timestamps *discordActivityTimestamps `json:"timestamps,omitempty"`
+	Assets     *discordActivityAssets     `json:"assets,omitempty"`
+}
+
+type discordActivityTimestamps struct {
+	Start int64 `json:"start,omitempty"` // unix ms
+	End   int64 `json:"end,omitempty"`   // unix ms
+}
+
+type discordActivityAssets struct {
+	LargeImage string `json:"large_image,omitempty"`
+	LargeText  string `json:"large_text,omitempty"`
+}
+
+// DiscordPresence shows the displayed player as the user's Discord activity,
+// talking to the local Discord client over its IPC socket
+type DiscordPresence struct {
+	config DiscordConfig
+	stopCh chan struct{}
+	done   chan struct{}
+	wake   chan struct{}
+
+	mu          sync.Mutex
+	player      *Player // nil = nothing to show
+	pausedSince time.Time
+	pauseTimer  *time.Timer
+	conn        *discordConn // for Stop; owned by the loop
+	status      DiscordStatus
+}
+
+// NewDiscordPresence starts following the Discord client. It keeps retrying while
+// there is something to show, so Discord may be started later.
+func NewDiscordPresence(config DiscordConfig) *DiscordPresence {
+	d := &DiscordPresence{
+		config: config,
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+		wake:   make(chan struct{}, 1),
+	}
+	go d.run()
+	return d
+}
+
+// Status returns the connection state
+func (d *DiscordPresence) Status() DiscordStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+// Stop clears the presence and disconnects
+func (d *DiscordPresence) Stop() {
+	close(d.stopCh)
+	select {
+	case <-d.done:
+	case <-time.After(discordStopTimeout):
+		// Discord does not answer; closing the connection unblocks the loop
+		d.mu.Lock()
+		if d.conn != nil {
+			d.conn.Close()
+		}
+		d.mu.Unlock()
+		<-d.done
+	}
+
+	d.mu.Lock()
+	if d.pauseTimer != nil {
+		d.pauseTimer.Stop()
+	}
+	d.mu.Unlock()
+	log.Println("[Discord] Presence stopped")
+}
+
+// Update takes the displayed player (nil = nothing is shown)
+func (d *DiscordPresence) Update(player *Player) {
+	now := time.Now()
+
+	d.mu.Lock()
+	if player != nil && (player.Title == "" || !d.config.allows(player)) {
+		player = nil
+	}
+	d.player = player
+
+	// Paused players are cleared after a timeout
+	paused := player != nil && player.State != StatePlaying
+	switch {
+	case paused && d.pausedSince.IsZero():
+		d.pausedSince = now
+		d.pauseTimer = time.AfterFunc(d.config.clearAfterPause(), d.signal)
+	case !paused && !d.pausedSince.IsZero():
+		d.pausedSince = time.Time{}
+		d.pauseTimer.Stop()
+		d.pauseTimer = nil
+	}
+	d.mu.Unlock()
+
+	d.signal()
+}
+
+// signal wakes the presence loop
+func (d *DiscordPresence) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// activity returns the activity for the current player, or nil to clear it
+func (d *DiscordPresence) activity(now time.Time) *discordActivity {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	player := d.player
+	if player == nil {
+		return nil
+	}
+	paused := !d.pausedSince.IsZero()
+	if paused && now.Sub(d.pausedSince) >= d.config.clearAfterPause() {
+		return nil
+	}
+
+	activity := &discordActivity{
+		Type:    activityTypeListening,
+		Details: discordText(player.Title),
+		State:   discordText(player.Artist),
+	}
+	if paused {
+		activity.Details = discordText("⏸ " + player.Title)
+	} else {
+		start := now.Add(-time.Duration(player.EstimatedPositionAt(now) * float64(time.Second)))
+		activity.Timestamps = &discordActivityTimestamps{Start: start.UnixMilli()}
+		if player.Duration > 0 {
+			activity.Timestamps.End = start.Add(time.Duration(player.Duration) * time.Second).UnixMilli()
+		}
+	}
+	if player.Album != "" || strings.HasPrefix(player.Cover, "https://") {
+		activity.Assets = &discordActivityAssets{LargeText: discordText(player.Album)}
+		if strings.HasPrefix(player.Cover, "https://") && len(player.Cover) <= 256 {
+			activity.Assets.LargeImage = player.Cover
+		}
+	}
+	return activity
+}
+
+// discordText trims s to what Discord accepts; shorter strings are padded to two characters
+func discordText(s string) string {
+	runes := []rune(s)
+	if len(runes) > discordMaxText {
+		return string(runes[:discordMaxText-1]) + "…"
+	}
+	if len(runes) == 1 {
+		return s + " "
+	}
+	return s
+}
+
+// run connects when there is an activity to show and sends it whenever it changes
+func (d *DiscordPresence) run() {
+	defer close(d.done)
+
+	var (
+		conn    *discordConn
+		sent    *discordActivity
+		cleared = true
+		retry   = newBackoff(discordRetryMin, discordRetryMax)
+		retryCh <-chan time.Time
+	)
+	defer func() {
+		if conn != nil {
+			if !cleared {
+				conn.setActivity(nil)
+			}
+			conn.Close()
+		}
+	}()
+
+	for {
+		activity := d.activity(time.Now())
+		if conn == nil && activity != nil && retryCh == nil {
+			var err error
+			conn, err = dialDiscord(d.config.ClientID)
+			d.connected(conn, err)
+			if err != nil {
+				retryCh = time.After(retry.failed())
+			} else {
+				retry.reset()
+				sent, cleared = nil, true
+			}
+		}
+
+		if conn != nil && !discordSameActivity(activity, sent) && !(activity == nil && cleared) {
+			if err := conn.setActivity(activity); err != nil {
+				conn.Close()
+				conn = nil
+				d.connected(nil, err)
+				retryCh = time.After(discordRetryMin)
+			} else {
+				sent, cleared = activity, activity == nil
+			}
+		}
+
+		select {
+		case <-d.stopCh:
+			return
+		case <-d.wake:
+		case <-retryCh:
+			retryCh = nil
+		}
+	}
+}
+
+// discordSameActivity reports whether b shows the same as a; start times may drift a little
+func discordSameActivity(a, b *discordActivity) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Type != b.Type || a.Details != b.Details || a.State != b.State {
+		return false
+	}
+	if (a.Assets == nil) != (b.Assets == nil) || (a.Assets != nil && *a.Assets != *b.Assets) {
+		return false
+	}
+	if a.Timestamps == nil || b.Timestamps == nil {
+		return a.Timestamps == b.Timestamps
+	}
+	drift := time.Duration(a.Timestamps.Start-b.Timestamps.Start) * time.Millisecond
+	return drift.Abs() <= discordStartJitter && (a.Timestamps.End == 0) == (b.Timestamps.End == 0)
+}
+
+// connected records the connection state after a dial or a failed send
+func (d *DiscordPresence) connected(conn *discordConn, err error) {
+	d.mu.Lock()
+	d.conn = conn
+	d.status.Connected = conn != nil
+	isNew := false
+	if conn != nil {
+		d.status.User = conn.user
+		d.status.LastError = ""
+	} else {
+		isNew = recordConnError(&d.status.LastError, err)
+	}
+	d.mu.Unlock()
+
+	switch {
+	case conn != nil:
+		log.Printf("[Discord] Connected as %s", conn.user)
+	case isNew:
+		log.Printf("[Discord] Not connected: %v", err)
+	}
+}
+
+// discordConn is a connection to the Discord client's IPC socket or pipe
+type discordConn struct {
+	net.Conn
+	user  string
+	nonce uint64
+}
+
+// errDiscordNotRunning is returned when no Discord IPC socket accepts a connection
+var errDiscordNotRunning = errors.New("discord is not running")
+
+// dialDiscord connects to the first IPC socket that answers and performs the handshake
+func dialDiscord(clientID string) (*discordConn, error) {
+	var ipc net.Conn
+	for i := 0; i < 10 && ipc == nil; i++ {
+		ipc, _ = openDiscordIPC(i)
+	}
+	if ipc == nil {
+		return nil, errDiscordNotRunning
+	}
+
+	conn := &discordConn{Conn: ipc}
+	conn.SetDeadline(time.Now().Add(discordIOTimeout))
+	if err := conn.write(discordOpHandshake, map[string]interface{}{"v": 1, "client_id": clientID}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var ready struct {
+		Cmd  string `json:"cmd"`
+		Evt  string `json:"evt"`
+		Data struct {
+			User struct {
+				Username   string `json:"username"`
+				GlobalName string `json:"global_name"`
+			} `json:"user"`
+			Message string `json:"message"`
+		} `json:"data"`
+	}
+	if err := conn.read(&ready); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if ready.Evt != "READY" {
+		conn.Close()
+		return nil, fmt.Errorf("discord handshake failed: %s", ready.Data.Message)
+	}
+	conn.user = ready.Data.User.Username
+	if ready.Data.User.GlobalName != "" {
+		conn.user = ready.Data.User.GlobalName
+	}
+	return conn, nil
+}
+
+// setActivity shows activity on the user's profile (nil = clear it)
+func (c *discordConn) setActivity(activity *discordActivity) error {
+	c.nonce++
+	c.SetDeadline(time.Now().Add(discordIOTimeout))
+	err := c.write(discordOpFrame, map[string]interface{}{
+		"cmd":   "SET_ACTIVITY",
+		"args":  map[string]interface{}{"pid": os.Getpid(), "activity": activity},
+		"nonce": strconv.FormatUint(c.nonce, 10),
+	})
+	if err != nil {
+		return err
+	}
+
+	var reply struct {
+		Evt  string `json:"evt"`
+		Data struct {
+			Message string `json:"message"`
+		} `json:"data"`
+	}
+	if err := c.read(&reply); err != nil {
+		return err
+	}
+	if reply.Evt == "ERROR" {
+		return fmt.Errorf("discord rejected the activity: %s", reply.Data.Message)
+	}
+	return nil
+}
+
+// write sends one frame: opcode and length (little endian) followed by JSON
+func (c *discordConn) write(op uint32, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	frame := binary.LittleEndian.AppendUint32(nil, op)
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(len(data)))
+	_, err = c.Write(append(frame, data...))
+	return err
+}
+
+// read reads the next frame into v, answering pings on the way
+func (c *discordConn) read(v interface{}) error {
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(c, header[:]); err != nil {
+			return err
+		}
+		op := binary.LittleEndian.Uint32(header[:4])
+		length := binary.LittleEndian.Uint32(header[4:])
+		if length > discordMaxFrame {
+			return fmt.Errorf("discord frame too large: %d bytes", length)
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(c, data); err != nil {
+			return err
+		}
+
+		switch op {
+		case discordOpFrame:
+			return json.NewDecoder(bytes.NewReader(data)).Decode(v)
+		case discordOpPing:
+			if _, err := c.Write(append(binary.LittleEndian.AppendUint32(header[:0:0], discordOpPong), header[4:]...)); err != nil {
+				return err
+			}
+			if _, err := c.Write(data); err != nil {
+				return err
+			}
+		case discordOpClose:
+			var reason struct {
+				Message string `json:"message"`
+			}
+			json.Unmarshal(data, &reason)
+			return fmt.Errorf("discord closed the connection: %s", reason.Message)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package media
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// discordIPCDirs lists where Discord puts its socket: the runtime and temp
+// directories, including the Flatpak and Snap sandboxes inside them
+func discordIPCDirs() []string {
+	var dirs []string
+	for _, env := range []string{"XDG_RUNTIME_DIR", "TMPDIR", "TMP", "TEMP"} {
+		if dir := os.Getenv(env); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	dirs = append(dirs, "/tmp")
+
+	var all []string
+	for _, dir := range dirs {
+		all = append(all, dir, filepath.Join(dir, "app", "com.discordapp.Discord"), filepath.Join(dir, "snap.discord"))
+	}
+	return all
+}
+
+// openDiscordIPC connects to the unix socket of the Discord client with index i
+func openDiscordIPC(i int) (net.Conn, error) {
+	var lastErr error
+	for _, dir := range discordIPCDirs() {
+		conn, err := net.DialTimeout("unix", filepath.Join(dir, fmt.Sprintf("discord-ipc-%d", i)), discordDialTimeout)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,193 @@
+//go:build !windows
+
+package media
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeDiscord is a Discord client IPC socket in a private XDG_RUNTIME_DIR
+type fakeDiscord struct {
+	conns chan net.Conn
+}
+
+func newFakeDiscord(t *testing.T) *fakeDiscord {
+	dir := t.TempDir()
+	for _, env := range []string{"XDG_RUNTIME_DIR", "TMPDIR", "TMP", "TEMP"} {
+		t.Setenv(env, dir)
+	}
+	listener, err := net.Listen("unix", filepath.Join(dir, "discord-ipc-0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeDiscord{conns: make(chan net.Conn, 4)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			f.conns <- conn
+		}
+	}()
+	return f
+}
+
+// accept waits for the presence to connect and answers the handshake
+func (f *fakeDiscord) accept(t *testing.T) net.Conn {
+	t.Helper()
+	var conn net.Conn
+	select {
+	case conn = <-f.conns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("presence did not connect")
+	}
+
+	var handshake struct {
+		V        int    `json:"v"`
+		ClientID string `json:"client_id"`
+	}
+	if op := readDiscordFrame(t, conn, &handshake); op != discordOpHandshake || handshake.V != 1 || handshake.ClientID != "1234" {
+		t.Fatalf("handshake op %d: %+v", op, handshake)
+	}
+	writeDiscordFrame(t, conn, discordOpFrame, map[string]interface{}{
+		"cmd":  "DISPATCH",
+		"evt":  "READY",
+		"data": map[string]interface{}{"user": map[string]string{"username": "user", "global_name": "User"}},
+	})
+	return conn
+}
+
+// none checks that the presence does not connect for a moment
+func (f *fakeDiscord) none(t *testing.T) {
+	t.Helper()
+	select {
+	case <-f.conns:
+		t.Fatal("unexpected connection")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// discordRequest is a SET_ACTIVITY request as Discord receives it
+type discordRequest struct {
+	Cmd  string `json:"cmd"`
+	Args struct {
+		PID      int              `json:"pid"`
+		Activity *discordActivity `json:"activity"`
+	} `json:"args"`
+	Nonce string `json:"nonce"`
+}
+
+// nextActivity reads a SET_ACTIVITY request and confirms it
+func nextActivity(t *testing.T, conn net.Conn) *discordActivity {
+	t.Helper()
+	var req discordRequest
+	if op := readDiscordFrame(t, conn, &req); op != discordOpFrame || req.Cmd != "SET_ACTIVITY" || req.Nonce == "" {
+		t.Fatalf("got op %d: %+v", op, req)
+	}
+	writeDiscordFrame(t, conn, discordOpFrame, map[string]interface{}{"cmd": "SET_ACTIVITY", "nonce": req.Nonce})
+	return req.Args.Activity
+}
+
+func readDiscordFrame(t *testing.T, conn net.Conn, v interface{}) uint32 {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var header [8]byte
+	if _, err := io.ReadFull(conn, header[:]); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	data := make([]byte, binary.LittleEndian.Uint32(header[4:]))
+	if _, err := io.ReadFull(conn, data); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decode frame %s: %v", data, err)
+	}
+	return binary.LittleEndian.Uint32(header[:4])
+}
+
+func writeDiscordFrame(t *testing.T, conn net.Conn, op uint32, v interface{}) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := binary.LittleEndian.AppendUint32(nil, op)
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(len(data)))
+	if _, err := conn.Write(append(frame, data...)); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+}
+
+func TestDiscordPresence(t *testing.T) {
+	f := newFakeDiscord(t)
+	presence := NewDiscordPresence(DiscordConfig{
+		Enabled:                true,
+		ClientID:               "1234",
+		AllowedPlayers:         []string{"Spotify"},
+		ClearAfterPauseSeconds: 1,
+	})
+	t.Cleanup(presence.Stop)
+
+	// A player outside the allowlist is not shown, so nothing connects
+	presence.Update(&Player{ID: 1, Name: "Chrome", Title: "Video", State: StatePlaying})
+	f.none(t)
+
+	// Handshake and the first activity
+	song := &Player{ID: 2, Name: "spotify", Title: "Song", Artist: "Artist", Album: "Album", State: StatePlaying, Position: 30, Duration: 200, PlaybackRate: 1}
+	song.RebasePosition(30, time.Now())
+	presence.Update(song)
+	conn := f.accept(t)
+
+	activity := nextActivity(t, conn)
+	if activity == nil || activity.Type != activityTypeListening || activity.Details != "Song" || activity.State != "Artist" {
+		t.Fatalf("activity = %+v", activity)
+	}
+	if ts := activity.Timestamps; ts == nil || ts.End-ts.Start != 200000 || time.Since(time.UnixMilli(ts.Start)) < 29*time.Second {
+		t.Errorf("timestamps = %+v", ts)
+	}
+	if activity.Assets == nil || activity.Assets.LargeText != "Album" {
+		t.Errorf("assets = %+v", activity.Assets)
+	}
+	waitFor(t, func() bool { return presence.Status().User == "User" && presence.Status().Connected })
+
+	// A ping before the reply is answered with a pong carrying the same payload
+	paused := song.Clone()
+	paused.State = StatePaused
+	presence.Update(paused)
+	var req discordRequest
+	readDiscordFrame(t, conn, &req)
+	writeDiscordFrame(t, conn, discordOpPing, map[string]string{"nonce": "ping-1"})
+	var pong map[string]string
+	if op := readDiscordFrame(t, conn, &pong); op != discordOpPong || pong["nonce"] != "ping-1" {
+		t.Errorf("ping answered with op %d %v", op, pong)
+	}
+	writeDiscordFrame(t, conn, discordOpFrame, map[string]interface{}{"cmd": "SET_ACTIVITY", "nonce": req.Nonce})
+	if a := req.Args.Activity; a == nil || a.Details != "⏸ Song" || a.Timestamps != nil {
+		t.Errorf("paused activity = %+v", a)
+	}
+
+	// The paused track is cleared after the timeout
+	start := time.Now()
+	if activity := nextActivity(t, conn); activity != nil {
+		t.Errorf("expected the activity to be cleared, got %+v", activity)
+	}
+	if waited := time.Since(start); waited < 500*time.Millisecond {
+		t.Errorf("cleared after %v", waited)
+	}
+
+	// Switching to a player outside the allowlist keeps it cleared
+	presence.Update(&Player{ID: 1, Name: "Chrome", Title: "Video", State: StatePlaying})
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, err := conn.Read(make([]byte, 1)); n > 0 || err == nil {
+		t.Error("activity sent for a player outside the allowlist")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package media
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// openDiscordIPC connects to the named pipe of the Discord client with index i.
+// The pipe is opened for overlapped I/O, so reads and writes honour deadlines.
+func openDiscordIPC(i int) (net.Conn, error) {
+	timeout := discordDialTimeout
+	return winio.DialPipe(fmt.Sprintf(`\\.\pipe\discord-ipc-%d`, i), &timeout)
+}
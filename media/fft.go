@@ -8,9 +8,9 @@ import (
 )
 
 type FFTConfig struct {
-	FFTSize int
-	FreqMin float64
-	FreqMax float64
+	FFTSize int     `json:"fftSize"`
+	FreqMin float64 `json:"freqMin"`
+	FreqMax float64 `json:"freqMax"`
 }
 
 func DefaultFFTConfig() FFTConfig {
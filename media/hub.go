@@ -0,0 +1,347 @@
+package media
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PlayerHub merges the players of every source and elects the one to show
+type PlayerHub struct {
+	// deliverMu is held from the election of the active player until its callbacks
+	// return, so updates from concurrent sources arrive in election order
+	deliverMu sync.Mutex
+
+	mu             sync.RWMutex
+	sources        []PlayerSource
+	players        map[int]*Player      // latest snapshot per player
+	owners         map[int]PlayerSource // source that reported each player
+	activePlayerID int
+	rules          PlayerRules
+	pinnedPlayerID int
+	onUpdate       PlayerUpdateCallback
+	onCleared      func()
+	onPlayers      PlayersCallback
+	stopCh         chan struct{}
+}
+
+// NewPlayerHub creates a hub without sources and starts its sticky handover loop
+func NewPlayerHub(onUpdate PlayerUpdateCallback) *PlayerHub {
+	h := &PlayerHub{
+		players:  make(map[int]*Player),
+		owners:   make(map[int]PlayerSource),
+		onUpdate: onUpdate,
+		stopCh:   make(chan struct{}),
+	}
+	go h.reselectLoop()
+	return h
+}
+
+// SetPlayersCallback sets the callback invoked with the full player list on any change
+func (h *PlayerHub) SetPlayersCallback(onPlayers PlayersCallback) {
+	h.mu.Lock()
+	h.onPlayers = onPlayers
+	h.mu.Unlock()
+}
+
+// SetClearedCallback sets the callback invoked when the last player goes away
+func (h *PlayerHub) SetClearedCallback(onCleared func()) {
+	h.mu.Lock()
+	h.onCleared = onCleared
+	h.mu.Unlock()
+}
+
+// AddSource starts following the players of src
+func (h *PlayerHub) AddSource(src PlayerSource) {
+	h.mu.Lock()
+	h.sources = append(h.sources, src)
+	h.mu.Unlock()
+
+	log.Printf("[Players] Source added: %s", src.Name())
+	src.SetChangeCallback(func(changed []int) {
+		h.refresh(src, changed)
+	})
+	h.refresh(src, nil)
+}
+
+// RemoveSource forgets src and its players. The source is not stopped.
+func (h *PlayerHub) RemoveSource(src PlayerSource) {
+	src.SetChangeCallback(nil)
+
+	h.mu.Lock()
+	for i, s := range h.sources {
+		if s == src {
+			h.sources = append(h.sources[:i], h.sources[i+1:]...)
+			break
+		}
+	}
+	h.mu.Unlock()
+
+	log.Printf("[Players] Source removed: %s", src.Name())
+	h.refresh(src, nil)
+}
+
+// Stop stops the hub and every source still attached
+func (h *PlayerHub) Stop() {
+	close(h.stopCh)
+
+	h.mu.Lock()
+	sources := h.sources
+	h.sources = nil
+	h.mu.Unlock()
+
+	for _, src := range sources {
+		src.SetChangeCallback(nil)
+		src.Stop()
+	}
+}
+
+// refresh takes over the current players of src and re-elects the active player.
+// changed lists the player IDs the source reported as touched.
+func (h *PlayerHub) refresh(src PlayerSource, changed []int) {
+	h.deliverMu.Lock()
+	defer h.deliverMu.Unlock()
+
+	h.mu.Lock()
+	var players []*Player
+	for _, s := range h.sources {
+		if s == src {
+			players = src.GetPlayers()
+			break
+		}
+	}
+
+	for id, owner := range h.owners {
+		if owner == src {
+			delete(h.players, id)
+			delete(h.owners, id)
+		}
+	}
+	for _, player := range players {
+		if owner, ok := h.owners[player.ID]; ok {
+			log.Printf("[Players] Player %d from %s clashes with %s, ignored", player.ID, src.Name(), owner.Name())
+			continue
+		}
+		h.players[player.ID] = player
+		h.owners[player.ID] = src
+	}
+	if h.pinnedPlayerID != 0 && h.players[h.pinnedPlayerID] == nil {
+		h.pinnedPlayerID = 0
+	}
+
+	activeChanged, active := h.recalculateActivePlayer(time.Now())
+	touched := false
+	for _, id := range changed {
+		if id == h.activePlayerID {
+			touched = true
+			break
+		}
+	}
+	h.mu.Unlock()
+
+	// Notify about the active player ONLY if it changed or this update is for it
+	if active != nil && (activeChanged || touched) {
+		h.notifyUpdate(active)
+	} else if active == nil && activeChanged {
+		h.notifyCleared()
+	}
+	h.notifyPlayers()
+}
+
+// recalculateActivePlayer recalculates the active player based on priority algorithm from libwnp,
+// adjusted by the user's PlayerRules:
+// 1. A manually pinned player, then players matching the pinned name
+// 2. Playing player with volume > 0, then any playing player, then the rest
+// 3. Within the same class, Priority order by name, then highest activeAt
+// Ghost players with an empty title and blocklisted players are never shown.
+// With StickySeconds set, the current player is kept until the winner has played that long.
+// This prevents "flickering" when multiple players are active. Caller must hold mu.
+func (h *PlayerHub) recalculateActivePlayer(now time.Time) (changed bool, activePlayer *Player) {
+	newActiveID := h.selectActivePlayer(now)
+
+	changed = newActiveID != h.activePlayerID
+	if changed {
+		h.activePlayerID = newActiveID
+		log.Printf("[Players] Active player changed to: %d", newActiveID)
+	}
+
+	if player, ok := h.players[h.activePlayerID]; ok {
+		activePlayer = player.Snapshot(now)
+	}
+	return changed, activePlayer
+}
+
+// selectActivePlayer picks the player to show. Caller must hold mu.
+func (h *PlayerHub) selectActivePlayer(now time.Time) int {
+	if pinned := h.players[h.pinnedPlayerID]; pinned != nil && pinned.Title != "" {
+		return pinned.ID
+	}
+
+	var best *Player
+	bestPinned := false
+	for _, player := range h.players {
+		// Skip ghost players with empty title
+		if player.Title == "" || h.rules.blocks(player) {
+			continue
+		}
+
+		pinned := h.rules.pins(player)
+		if best == nil || (pinned && !bestPinned) || (pinned == bestPinned && h.rules.better(player, best)) {
+			best = player
+			bestPinned = pinned
+		}
+	}
+	if best == nil {
+		return 0
+	}
+
+	// Sticky: keep showing the current player until the new one has really taken over
+	current := h.players[h.activePlayerID]
+	if h.rules.StickySeconds > 0 && current != nil && current != best && !bestPinned &&
+		current.Title != "" && !h.rules.blocks(current) && !h.rules.playedLongEnough(best, now) {
+		return current.ID
+	}
+
+	return best.ID
+}
+
+// SetRules replaces the player selection rules and re-elects the active player
+func (h *PlayerHub) SetRules(rules PlayerRules) {
+	h.mu.Lock()
+	h.rules = rules.Normalize()
+	h.mu.Unlock()
+
+	h.reselectActivePlayer()
+}
+
+// PinPlayer always shows the given player while it exists (0 = unpin)
+func (h *PlayerHub) PinPlayer(playerID int) {
+	h.mu.Lock()
+	h.pinnedPlayerID = playerID
+	h.mu.Unlock()
+
+	log.Printf("[Players] Pinned player: %d", playerID)
+	h.reselectActivePlayer()
+}
+
+// reselectActivePlayer re-elects the active player and notifies if it changed
+func (h *PlayerHub) reselectActivePlayer() {
+	h.deliverMu.Lock()
+	defer h.deliverMu.Unlock()
+
+	h.mu.Lock()
+	changed, activePlayer := h.recalculateActivePlayer(time.Now())
+	h.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if activePlayer != nil {
+		h.notifyUpdate(activePlayer)
+	} else {
+		h.notifyCleared()
+	}
+}
+
+// reselectLoop lets sticky rules hand over to another player as time passes
+func (h *PlayerHub) reselectLoop() {
+	ticker := time.NewTicker(playerSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stopCh:
+			return
+		case <-ticker.C:
+			h.reselectActivePlayer()
+		}
+	}
+}
+
+// notifyUpdate calls the update callback with the active player
+func (h *PlayerHub) notifyUpdate(player *Player) {
+	if h.onUpdate != nil && player != nil {
+		h.onUpdate(player)
+	}
+}
+
+// notifyPlayers calls the players callback with the current player list
+func (h *PlayerHub) notifyPlayers() {
+	h.mu.RLock()
+	onPlayers := h.onPlayers
+	h.mu.RUnlock()
+
+	if onPlayers != nil {
+		onPlayers(h.GetPlayers())
+	}
+}
+
+// notifyCleared calls the cleared callback once no player is left to show
+func (h *PlayerHub) notifyCleared() {
+	h.mu.RLock()
+	onCleared := h.onCleared
+	h.mu.RUnlock()
+
+	log.Println("[Players] No active player left")
+	if onCleared != nil {
+		onCleared()
+	}
+}
+
+// SendEvent routes a raw command to the source that reported the player
+func (h *PlayerHub) SendEvent(playerID int, command string, data interface{}) (string, error) {
+	h.mu.RLock()
+	owner := h.owners[playerID]
+	h.mu.RUnlock()
+
+	if owner == nil {
+		return "", ErrNoPlayer
+	}
+	return owner.SendEvent(playerID, command, data)
+}
+
+// ActivePlayerID returns the ID of the displayed player (0 = none)
+func (h *PlayerHub) ActivePlayerID() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.activePlayerID
+}
+
+// GetActivePlayer returns the current active player
+func (h *PlayerHub) GetActivePlayer() *Player {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if player, ok := h.players[h.activePlayerID]; ok {
+		return player.Snapshot(time.Now())
+	}
+	return nil
+}
+
+// GetPlayer returns a snapshot of the player with the given ID, or nil
+func (h *PlayerHub) GetPlayer(playerID int) *Player {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if player, ok := h.players[playerID]; ok {
+		return player.Snapshot(time.Now())
+	}
+	return nil
+}
+
+// GetPlayers returns snapshots of the players of all sources ordered by ID
+func (h *PlayerHub) GetPlayers() []*Player {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	now := time.Now()
+	players := make([]*Player, 0, len(h.players))
+	for _, player := range h.players {
+		players = append(players, player.Snapshot(now))
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].ID < players[j].ID
+	})
+	return players
+}
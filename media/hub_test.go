@@ -0,0 +1,116 @@
+package media
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSource is a PlayerSource whose players are set by the test
+type fakeSource struct {
+	name string
+
+	mu       sync.Mutex
+	players  []*Player
+	onChange SourceChangeCallback
+}
+
+func (s *fakeSource) Name() string { return s.name }
+
+func (s *fakeSource) GetPlayer(playerID int) *Player {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, player := range s.players {
+		if player.ID == playerID {
+			return player.Clone()
+		}
+	}
+	return nil
+}
+
+func (s *fakeSource) GetPlayers() []*Player {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	players := make([]*Player, len(s.players))
+	for i, player := range s.players {
+		players[i] = player.Clone()
+	}
+	return players
+}
+
+func (s *fakeSource) SetChangeCallback(onChange SourceChangeCallback) {
+	s.mu.Lock()
+	s.onChange = onChange
+	s.mu.Unlock()
+}
+
+func (s *fakeSource) SendEvent(playerID int, command string, data interface{}) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (s *fakeSource) Stop() {}
+
+// set replaces the players and reports the change like a real source
+func (s *fakeSource) set(players ...*Player) {
+	s.mu.Lock()
+	s.players = players
+	onChange := s.onChange
+	s.mu.Unlock()
+
+	changed := make([]int, len(players))
+	for i, player := range players {
+		changed[i] = player.ID
+	}
+	if onChange != nil {
+		onChange(changed)
+	}
+}
+
+func TestPlayerHubDeliversInElectionOrder(t *testing.T) {
+	var mu sync.Mutex
+	var delivered *Player
+	hub := NewPlayerHub(func(player *Player) {
+		// A slow callback widens the window for overtaking deliveries
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		delivered = player
+		mu.Unlock()
+	})
+	defer hub.Stop()
+
+	sources := make([]*fakeSource, 4)
+	for i := range sources {
+		sources[i] = &fakeSource{name: fmt.Sprintf("fake%d", i)}
+		hub.AddSource(sources[i])
+	}
+
+	// Every source flips between a playing and a paused player, so the election
+	// keeps moving between them
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func(i int, src *fakeSource) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				state := StatePaused
+				if (n+i)%2 == 0 {
+					state = StatePlaying
+				}
+				src.set(&Player{ID: i + 1, Source: src.name, Title: fmt.Sprintf("Track %d", n), State: state, Volume: 50, ActiveAt: int64(n)})
+			}
+		}(i, src)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	active := hub.GetActivePlayer()
+	if delivered == nil || active == nil {
+		t.Fatalf("delivered %v, active %v", delivered, active)
+	}
+	if delivered.ID != active.ID || delivered.Title != active.Title || delivered.State != active.State {
+		t.Errorf("last delivered player %d %q (state %d), hub shows %d %q (state %d)",
+			delivered.ID, delivered.Title, delivered.State, active.ID, active.Title, active.State)
+	}
+}
@@ -0,0 +1,64 @@
+package media
+
+import "math"
+
+// LevelsCallback receives every analysis result (or silence frame) from the capture loop
+type LevelsCallback func(frame LevelFrame)
+
+// LevelFrame is one set of band levels together with the capture timing of the
+// newest samples in its analysis window, so levels can be aligned with playback time.
+type LevelFrame struct {
+	Levels []float32 `json:"levels"`
+	// RMS is the root mean square of the analysed samples (0-1, zero for silence)
+	RMS float32 `json:"rms"`
+	// QPCPosition is the QueryPerformanceCounter time of the newest analysed sample,
+	// in 100ns units as reported by IAudioCaptureClient::GetBuffer. Zero for silence.
+	QPCPosition uint64 `json:"qpcPosition"`
+	// DevicePosition is the stream position (in frames) of the newest analysed sample
+	DevicePosition uint64 `json:"devicePosition"`
+	// Timestamp is the wall-clock time of the newest analysed sample (unix ms)
+	Timestamp int64 `json:"timestamp"`
+	// Discontinuity is set on the first frame after a glitch reset the analysis window
+	Discontinuity bool `json:"discontinuity"`
+	Silence       bool `json:"silence"`
+}
+
+// CaptureStats is a snapshot of the capture pipeline health counters.
+// It is meant for diagnostics only — when the rays freeze it tells apart a failed
+// WASAPI session, a default device switch and a starved FFT buffer.
+type CaptureStats struct {
+	Running         bool   `json:"running"`
+	SessionOpen     bool   `json:"sessionOpen"`
+	CaptureMode     string `json:"captureMode"`
+	DeviceID        string `json:"deviceId"`
+	SampleRate      uint32 `json:"sampleRate"`
+	Channels        uint16 `json:"channels"`
+	BitsPerSample   uint16 `json:"bitsPerSample"`
+	FramesCaptured  uint64 `json:"framesCaptured"`
+	PacketsCaptured uint64 `json:"packetsCaptured"`
+	SilentPackets   uint64 `json:"silentPackets"`
+	GlitchPackets   uint64 `json:"glitchPackets"` // AUDCLNT_BUFFERFLAGS_DATA_DISCONTINUITY
+	AnalysesRun     uint64 `json:"analysesRun"`
+	SilenceFrames   uint64 `json:"silenceFrames"` // silence emitted instead of FFT levels
+	ReinitAttempts  uint64 `json:"reinitAttempts"`
+	ReinitFailures  uint64 `json:"reinitFailures"`
+	DeviceChanges   uint64 `json:"deviceChanges"`
+	BufferedSamples int    `json:"bufferedSamples"`
+	// AnalysisLatencyMs is the time from GetBuffer to the emitted levels for the last analysis
+	AnalysisLatencyMs float64 `json:"analysisLatencyMs"`
+	LastError         string  `json:"lastError"`
+	LastErrorAt       int64   `json:"lastErrorAt"` // unix ms
+	LastFrameAt       int64   `json:"lastFrameAt"` // unix ms
+}
+
+// RMS returns the root mean square of samples
+func RMS(samples []float32) float32 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return float32(math.Sqrt(sum / float64(len(samples))))
+}
@@ -0,0 +1,359 @@
+package media
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// lyricsMatchThreshold is the minimum similarity (0-1) of a fuzzy file name match
+	lyricsMatchThreshold = 0.8
+	// lyricsRescanInterval limits folder rescans after a lookup miss
+	lyricsRescanInterval = time.Minute
+)
+
+// ErrNoLyrics is returned when no lyrics file matches the track
+var ErrNoLyrics = errors.New("no lyrics found")
+
+// LyricWord is one word of an enhanced LRC line
+type LyricWord struct {
+	Time float64 `json:"time"` // seconds
+	Text string  `json:"text"`
+}
+
+// LyricLine is one timed line of lyrics
+type LyricLine struct {
+	Time  float64     `json:"time"` // seconds, offset applied
+	Text  string      `json:"text"`
+	Words []LyricWord `json:"words,omitempty"`
+}
+
+// Lyrics is a parsed LRC file
+type Lyrics struct {
+	Artist string      `json:"artist"`
+	Title  string      `json:"title"`
+	Album  string      `json:"album"`
+	Offset int         `json:"offset"` // ms, already applied to line times
+	Lines  []LyricLine `json:"lines"`
+}
+
+var (
+	lrcTimeTag = regexp.MustCompile(`^\[(\d+):(\d{1,2}(?:[.:]\d{1,3})?)\]`)
+	lrcMetaTag = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+	lrcWordTag = regexp.MustCompile(`<(\d+):(\d{1,2}(?:[.:]\d{1,3})?)>`)
+)
+
+// ParseLRC parses LRC lyrics. It supports several time tags per line, the
+// [ar]/[ti]/[al]/[offset] tags and enhanced <mm:ss.xx> word timing.
+// A positive offset shows lyrics earlier, as in the LRC format.
+func ParseLRC(r io.Reader) (*Lyrics, error) {
+	lyrics := &Lyrics{}
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" {
+			continue
+		}
+
+		var times []float64
+		for {
+			m := lrcTimeTag.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			times = append(times, parseLRCTime(m[1], m[2]))
+			line = line[len(m[0]):]
+		}
+
+		if len(times) == 0 {
+			if m := lrcMetaTag.FindStringSubmatch(line); m != nil {
+				lyrics.setTag(strings.ToLower(m[1]), strings.TrimSpace(m[2]))
+			}
+			continue
+		}
+
+		text, words := parseLRCWords(strings.TrimSpace(line), times[0])
+		for _, t := range times {
+			lyrics.Lines = append(lyrics.Lines, LyricLine{Time: t, Text: text, Words: words})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lyrics.Lines) == 0 {
+		return nil, ErrNoLyrics
+	}
+
+	sort.SliceStable(lyrics.Lines, func(i, j int) bool {
+		return lyrics.Lines[i].Time < lyrics.Lines[j].Time
+	})
+
+	if lyrics.Offset != 0 {
+		shift := float64(lyrics.Offset) / 1000
+		for i := range lyrics.Lines {
+			line := &lyrics.Lines[i]
+			line.Time = max(line.Time-shift, 0)
+			if len(line.Words) > 0 {
+				// Lines with several time tags share the word slice; shift a copy
+				words := make([]LyricWord, len(line.Words))
+				for j, w := range line.Words {
+					words[j] = LyricWord{Time: max(w.Time-shift, 0), Text: w.Text}
+				}
+				line.Words = words
+			}
+		}
+	}
+	return lyrics, nil
+}
+
+func (l *Lyrics) setTag(tag, value string) {
+	switch tag {
+	case "ar":
+		l.Artist = value
+	case "ti":
+		l.Title = value
+	case "al":
+		l.Album = value
+	case "offset":
+		if offset, err := strconv.Atoi(strings.TrimPrefix(value, "+")); err == nil {
+			l.Offset = offset
+		}
+	}
+}
+
+// parseLRCTime converts mm and ss(.xx) into seconds
+func parseLRCTime(minutes, seconds string) float64 {
+	m, _ := strconv.Atoi(minutes)
+	// Some files use mm:ss:xx instead of mm:ss.xx
+	s, _ := strconv.ParseFloat(strings.Replace(seconds, ":", ".", 1), 64)
+	return float64(m)*60 + s
+}
+
+// parseLRCWords strips enhanced word tags, returning the plain text and the timed words
+func parseLRCWords(line string, lineTime float64) (string, []LyricWord) {
+	tags := lrcWordTag.FindAllStringSubmatchIndex(line, -1)
+	if tags == nil {
+		return line, nil
+	}
+
+	words := make([]LyricWord, 0, len(tags)+1)
+	if prefix := line[:tags[0][0]]; strings.TrimSpace(prefix) != "" {
+		// Text before the first word tag starts with the line
+		words = append(words, LyricWord{Time: lineTime, Text: prefix})
+	}
+	for i, tag := range tags {
+		end := len(line)
+		if i+1 < len(tags) {
+			end = tags[i+1][0]
+		}
+		text := line[tag[1]:end]
+		if strings.TrimSpace(text) == "" {
+			// A trailing tag only marks the end of the last word
+			continue
+		}
+		words = append(words, LyricWord{
+			Time: parseLRCTime(line[tag[2]:tag[3]], line[tag[4]:tag[5]]),
+			Text: text,
+		})
+	}
+
+	text := strings.Join(strings.Fields(lrcWordTag.ReplaceAllString(line, "")), " ")
+	return text, words
+}
+
+// LineAt returns the index of the line active at position (seconds), or -1 before the first line
+func (l *Lyrics) LineAt(position float64) int {
+	return sort.Search(len(l.Lines), func(i int) bool {
+		return l.Lines[i].Time > position
+	}) - 1
+}
+
+var (
+	// trackFeatPattern matches "(feat. X)", "[ft. X]" and a trailing "feat. X"
+	trackFeatPattern = regexp.MustCompile(`(?i)\s*[(\[]\s*(feat\.?|ft\.?|featuring)\s[^)\]]*[)\]]|\s+(feat\.?|ft\.?|featuring)\s.*$`)
+	// trackRemasterPattern matches "(Remastered 2011)", "[2009 Remaster]" and "- Remastered Version"
+	trackRemasterPattern = regexp.MustCompile(`(?i)\s*[(\[][^)\]]*remaster[^)\]]*[)\]]|\s+-\s+[^-]*remaster.*$`)
+)
+
+// NormalizeTrackName lowercases a title or artist and drops "feat." credits, remaster
+// suffixes and punctuation so that names from players and file names compare equal
+func NormalizeTrackName(name string) string {
+	name = trackFeatPattern.ReplaceAllString(name, "")
+	name = trackRemasterPattern.ReplaceAllString(name, "")
+	name = strings.ReplaceAll(name, "&", " and ")
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// lyricsFile is an indexed .lrc file
+type lyricsFile struct {
+	path string
+	// key is the normalized "artist title" derived from the file and folder names
+	key string
+	// title is the normalized title alone, for files without an artist in the name
+	title string
+}
+
+// LyricsProvider finds and parses .lrc files in a set of folders
+type LyricsProvider struct {
+	mu        sync.Mutex
+	dirs      []string
+	files     []lyricsFile
+	scannedAt time.Time
+}
+
+// NewLyricsProvider creates a provider searching the given folders
+func NewLyricsProvider(dirs []string) *LyricsProvider {
+	return &LyricsProvider{dirs: dirs}
+}
+
+// SetDirs replaces the searched folders
+func (p *LyricsProvider) SetDirs(dirs []string) {
+	p.mu.Lock()
+	p.dirs = dirs
+	p.files = nil
+	p.scannedAt = time.Time{}
+	p.mu.Unlock()
+}
+
+// Find returns the lyrics of a track, or ErrNoLyrics
+func (p *LyricsProvider) Find(artist, title string) (*Lyrics, error) {
+	if title == "" {
+		return nil, ErrNoLyrics
+	}
+
+	p.mu.Lock()
+	if p.scannedAt.IsZero() {
+		p.scanLocked()
+	}
+	path := p.matchLocked(artist, title)
+	if path == "" && time.Since(p.scannedAt) > lyricsRescanInterval {
+		// New files may have been added since the last scan
+		p.scanLocked()
+		path = p.matchLocked(artist, title)
+	}
+	p.mu.Unlock()
+
+	if path == "" {
+		return nil, ErrNoLyrics
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lyrics, err := ParseLRC(f)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Lyrics] %s - %s: %s (%d lines)", artist, title, path, len(lyrics.Lines))
+	return lyrics, nil
+}
+
+// scanLocked indexes all .lrc files in the folders. Caller must hold mu.
+func (p *LyricsProvider) scanLocked() {
+	p.files = p.files[:0]
+	for _, dir := range p.dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".lrc") {
+				return nil
+			}
+			name := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
+
+			// "Artist - Title.lrc" or "Artist/Title.lrc"
+			artist, title, ok := strings.Cut(name, " - ")
+			if !ok {
+				artist, title = filepath.Base(filepath.Dir(path)), name
+			}
+			file := lyricsFile{path: path, title: NormalizeTrackName(title)}
+			file.key = trackKey(artist, file.title)
+			p.files = append(p.files, file)
+			return nil
+		})
+		if err != nil {
+			log.Printf("[Lyrics] Failed to scan %s: %v", dir, err)
+		}
+	}
+	p.scannedAt = time.Now()
+	log.Printf("[Lyrics] Indexed %d lyrics files", len(p.files))
+}
+
+// matchLocked returns the best matching file for the track. Caller must hold mu.
+func (p *LyricsProvider) matchLocked(artist, title string) string {
+	normTitle := NormalizeTrackName(title)
+	key := trackKey(artist, normTitle)
+
+	best, bestScore := "", 0.0
+	for _, file := range p.files {
+		score := similarity(file.key, key)
+		if artist == "" {
+			score = similarity(file.title, normTitle)
+		}
+		if score > bestScore {
+			best, bestScore = file.path, score
+		}
+	}
+	if bestScore < lyricsMatchThreshold {
+		return ""
+	}
+	return best
+}
+
+// trackKey joins an artist and a normalized title. The artist is normalized on
+// its own: a trailing "feat. X" would otherwise swallow the title.
+func trackKey(artist, normTitle string) string {
+	return strings.TrimSpace(NormalizeTrackName(artist) + " " + normTitle)
+}
+
+// similarity returns 1 - the normalized Levenshtein distance of a and b
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
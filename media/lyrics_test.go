@@ -0,0 +1,211 @@
+package media
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLRC(t *testing.T) {
+	tests := []struct {
+		name   string
+		lrc    string
+		offset int
+		lines  []LyricLine
+	}{
+		{
+			name: "tags and several times per line",
+			lrc: "\ufeff[ar:Artist]\n[ti: Title ]\n[al:Album]\n[length: 3:00]\n\n" +
+				"[00:12.34]First\n[00:05.00][01:00.50]Chorus\n[00:20:50]Colon hundredths\n[1:02]Short",
+			lines: []LyricLine{
+				{Time: 5, Text: "Chorus"},
+				{Time: 12.34, Text: "First"},
+				{Time: 20.5, Text: "Colon hundredths"},
+				{Time: 60.5, Text: "Chorus"},
+				{Time: 62, Text: "Short"},
+			},
+		},
+		{
+			name:   "positive offset shows lyrics earlier",
+			lrc:    "[offset:+500]\n[00:00.20]Start\n[00:10.00]Later",
+			offset: 500,
+			lines:  []LyricLine{{Time: 0, Text: "Start"}, {Time: 9.5, Text: "Later"}},
+		},
+		{
+			name:   "negative offset",
+			lrc:    "[00:10.00]Line\n[offset:-250]",
+			offset: -250,
+			lines:  []LyricLine{{Time: 10.25, Text: "Line"}},
+		},
+		{
+			name: "enhanced words",
+			lrc:  "[00:10.00]<00:10.00>Hello <00:10.50>world<00:11.00>\n[00:12.00]Oh <00:12.75>yes  <00:13.00>  no",
+			lines: []LyricLine{
+				{Time: 10, Text: "Hello world", Words: []LyricWord{{10, "Hello "}, {10.5, "world"}}},
+				{Time: 12, Text: "Oh yes no", Words: []LyricWord{{12, "Oh "}, {12.75, "yes  "}, {13, "  no"}}},
+			},
+		},
+		{
+			name:   "shared words are shifted once per line",
+			lrc:    "[offset:1000]\n[00:10.00][00:20.00]<00:10.00>A <00:10.50>B",
+			offset: 1000,
+			lines: []LyricLine{
+				{Time: 9, Text: "A B", Words: []LyricWord{{9, "A "}, {9.5, "B"}}},
+				{Time: 19, Text: "A B", Words: []LyricWord{{9, "A "}, {9.5, "B"}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lyrics, err := ParseLRC(strings.NewReader(tt.lrc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lyrics.Offset != tt.offset {
+				t.Errorf("offset = %d, want %d", lyrics.Offset, tt.offset)
+			}
+			if len(lyrics.Lines) != len(tt.lines) {
+				t.Fatalf("got %d lines, want %d: %+v", len(lyrics.Lines), len(tt.lines), lyrics.Lines)
+			}
+			for i, want := range tt.lines {
+				got := lyrics.Lines[i]
+				if !closeTo(got.Time, want.Time) || got.Text != want.Text || len(got.Words) != len(want.Words) {
+					t.Errorf("line %d = %+v, want %+v", i, got, want)
+					continue
+				}
+				for j, word := range want.Words {
+					if !closeTo(got.Words[j].Time, word.Time) || got.Words[j].Text != word.Text {
+						t.Errorf("line %d word %d = %+v, want %+v", i, j, got.Words[j], word)
+					}
+				}
+			}
+		})
+	}
+
+	lyrics, _ := ParseLRC(strings.NewReader("[ar:Artist]\n[ti: Title ]\n[al:Album]\n[00:01.00]x"))
+	if lyrics.Artist != "Artist" || lyrics.Title != "Title" || lyrics.Album != "Album" {
+		t.Errorf("tags = %q, %q, %q", lyrics.Artist, lyrics.Title, lyrics.Album)
+	}
+
+	for _, lrc := range []string{"", "[ar:Artist]\n[ti:Title]", "plain text without times"} {
+		if _, err := ParseLRC(strings.NewReader(lrc)); !errors.Is(err, ErrNoLyrics) {
+			t.Errorf("ParseLRC(%q) error = %v, want ErrNoLyrics", lrc, err)
+		}
+	}
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestLyricsLineAt(t *testing.T) {
+	lyrics := &Lyrics{Lines: []LyricLine{{Time: 5}, {Time: 10}, {Time: 10}, {Time: 20}}}
+	for position, want := range map[float64]int{0: -1, 4.99: -1, 5: 0, 9.9: 0, 10: 2, 19: 2, 20: 3, 300: 3} {
+		if got := lyrics.LineAt(position); got != want {
+			t.Errorf("LineAt(%v) = %d, want %d", position, got, want)
+		}
+	}
+}
+
+func TestNormalizeTrackName(t *testing.T) {
+	tests := map[string]string{
+		"Song":                           "song",
+		"Song (feat. Someone)":           "song",
+		"Song [ft. Someone Else]":        "song",
+		"Song (Featuring A & B) - Live":  "song live",
+		"Song feat. Someone":             "song",
+		"Song ft Someone":                "song",
+		"Feather":                        "feather",
+		"Soft Touch":                     "soft touch",
+		"Song (Remastered 2011)":         "song",
+		"Song [2009 Remaster]":           "song",
+		"Song - Remastered Version":      "song",
+		"Song - 2015 Remaster":           "song",
+		"Rock & Roll":                    "rock and roll",
+		"  AC/DC  ":                      "ac dc",
+		"Café Déjà-vu!":                  "café déjà vu",
+		"Кино — Группа крови":            "кино группа крови",
+		"Don't Stop Me Now (feat. Band)": "don t stop me now",
+	}
+	for name, want := range tests {
+		if got := NormalizeTrackName(name); got != want {
+			t.Errorf("NormalizeTrackName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"abc", "abc", 1},
+		{"abc", "", 0},
+		{"kitten", "sitting", 1 - 3.0/7},
+		{"abcde", "abcdx", 0.8}, // at the match threshold
+		{"abcde", "abxyz", 0.4},
+		{"déjà", "deja", 0.5}, // compared by rune
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.a, tt.b); !closeTo(got, tt.want) {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLyricsProviderFind(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Artist - Song Title.lrc":     "[00:01.00]song title",
+		"Band/Another Song.LRC":       "[00:01.00]another song",
+		"Other - Remote Place.lrc":    "[00:01.00]remote place",
+		"Singer ft. Guest - Duet.lrc": "[00:01.00]duet",
+		"notes.txt":                   "[00:01.00]not lyrics",
+		"Broken - Empty Lyrics.lrc":   "[ar:Broken]",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	provider := NewLyricsProvider([]string{dir})
+
+	tests := []struct {
+		artist, title string
+		want          string // first line, "" = no lyrics
+	}{
+		{"Artist", "Song Title", "song title"},
+		{"ARTIST", "Song Title (Remastered 2011)", "song title"},
+		{"Artist feat. Guest", "Song Title", "song title"},
+		{"Artst", "Song Title", "song title"}, // a typo stays above the threshold
+		{"Band", "Another Song (feat. X)", "another song"},
+		{"", "Another Song", "another song"},
+		{"", "Song Title", "song title"},
+		{"Other", "Remote Place - Remastered", "remote place"},
+		{"Singer", "Duet", "duet"},
+		{"Singer feat. Guest", "Duet", "duet"},
+		{"Someone", "Song Title", ""},
+		{"Completely", "Different", ""},
+		{"Artist", "", ""},
+		{"Broken", "Empty Lyrics", ""},
+	}
+	for _, tt := range tests {
+		lyrics, err := provider.Find(tt.artist, tt.title)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("Find(%q, %q) = %+v, want no lyrics", tt.artist, tt.title, lyrics.Lines)
+		case tt.want == "" && !errors.Is(err, ErrNoLyrics):
+			t.Errorf("Find(%q, %q) error = %v, want ErrNoLyrics", tt.artist, tt.title, err)
+		case tt.want != "" && err != nil:
+			t.Errorf("Find(%q, %q) error = %v", tt.artist, tt.title, err)
+		case tt.want != "" && lyrics.Lines[0].Text != tt.want:
+			t.Errorf("Find(%q, %q) = %q, want %q", tt.artist, tt.title, lyrics.Lines[0].Text, tt.want)
+		}
+	}
+}
@@ -0,0 +1,654 @@
+package media
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultMPDAddress is where MPD listens by default
+	DefaultMPDAddress = "localhost:6600"
+	// MPDPlayerID is the ID of the MPD player, above the MPRIS range
+	MPDPlayerID = 2 * MPRISPlayerIDBase
+
+	mpdDialTimeout    = 5 * time.Second
+	mpdCommandTimeout = 10 * time.Second
+	mpdRetryMin       = 2 * time.Second
+	mpdRetryMax       = time.Minute
+	// mpdMaxCover caps the cover art read from MPD
+	mpdMaxCover = 8 << 20
+	// mpdCoverOwner identifies the MPD player's cover in the cover cache
+	mpdCoverOwner = "mpd"
+)
+
+// MPDConfig holds the MPD connection settings
+type MPDConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Address  string `json:"address"` // host:port or a unix socket path
+	Password string `json:"password"`
+}
+
+// MPDStatus describes the MPD connection for the settings page
+type MPDStatus struct {
+	Connected bool   `json:"connected"`
+	Version   string `json:"version"`
+	LastError string `json:"lastError"`
+}
+
+// mpdError is an ACK reply from MPD
+type mpdError struct {
+	line string
+}
+
+func (e *mpdError) Error() string {
+	return "mpd: " + e.line
+}
+
+// MPDSource follows an MPD server as a single player. It waits for changes with
+// idle on one connection and sends commands on a second one.
+type MPDSource struct {
+	config   MPDConfig
+	covers   *CoverCache
+	eventSeq atomic.Uint64
+	stopCh   chan struct{}
+	done     chan struct{}
+
+	mu        sync.RWMutex
+	player    *Player // nil while disconnected
+	coverSong string  // song the cover was fetched for
+	status    MPDStatus
+	idleConn  *mpdConn
+	onChange  SourceChangeCallback
+
+	cmdMu   sync.Mutex
+	cmdConn *mpdConn
+}
+
+// NewMPDSource starts following the MPD server in config, storing cover art in
+// covers. It keeps reconnecting until stopped, so a server that is not running
+// yet is picked up later.
+func NewMPDSource(config MPDConfig, covers *CoverCache) *MPDSource {
+	if config.Address == "" {
+		config.Address = DefaultMPDAddress
+	}
+	s := &MPDSource{
+		config: config,
+		covers: covers,
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Name identifies the source
+func (s *MPDSource) Name() string {
+	return SourceMPD
+}
+
+// SetChangeCallback sets the callback invoked after the player changes
+func (s *MPDSource) SetChangeCallback(onChange SourceChangeCallback) {
+	s.mu.Lock()
+	s.onChange = onChange
+	s.mu.Unlock()
+}
+
+// Status returns the connection state
+func (s *MPDSource) Status() MPDStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Stop disconnects and waits for the idle loop to end
+func (s *MPDSource) Stop() {
+	close(s.stopCh)
+
+	s.mu.Lock()
+	if s.idleConn != nil {
+		s.idleConn.Close()
+	}
+	s.mu.Unlock()
+	<-s.done
+
+	s.cmdMu.Lock()
+	if s.cmdConn != nil {
+		s.cmdConn.Close()
+		s.cmdConn = nil
+	}
+	s.cmdMu.Unlock()
+	log.Println("[MPD] Source stopped")
+}
+
+// GetPlayer returns a snapshot of the MPD player, or nil
+func (s *MPDSource) GetPlayer(playerID int) *Player {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.player == nil || playerID != MPDPlayerID {
+		return nil
+	}
+	return s.player.Snapshot(time.Now())
+}
+
+// GetPlayers returns the MPD player while connected
+func (s *MPDSource) GetPlayers() []*Player {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.player == nil {
+		return nil
+	}
+	return []*Player{s.player.Snapshot(time.Now())}
+}
+
+// run connects, follows the server with idle and reconnects with backoff
+func (s *MPDSource) run() {
+	defer close(s.done)
+	reconnectLoop(s.stopCh, newBackoff(mpdRetryMin, mpdRetryMax), s.follow, s.disconnected)
+}
+
+// follow reads the state once and then after every change until the connection fails
+func (s *MPDSource) follow() (connected bool, err error) {
+	conn, err := dialMPD(s.config)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	select {
+	case <-s.stopCh:
+		s.mu.Unlock()
+		return false, nil
+	default:
+	}
+	s.idleConn = conn
+	s.status = MPDStatus{Connected: true, Version: conn.version}
+	s.mu.Unlock()
+
+	log.Printf("[MPD] Connected to %s (protocol %s)", s.config.Address, conn.version)
+
+	for {
+		if err := s.refresh(conn); err != nil {
+			return true, err
+		}
+		// Blocks until something changes
+		if _, err := conn.command("idle", "player", "mixer", "options"); err != nil {
+			select {
+			case <-s.stopCh:
+				return true, nil
+			default:
+			}
+			return true, err
+		}
+	}
+}
+
+// disconnected drops the player after the connection ended
+func (s *MPDSource) disconnected(err error) {
+	s.mu.Lock()
+	hadPlayer := s.player != nil
+	s.player = nil
+	s.coverSong = ""
+	s.idleConn = nil
+	s.status.Connected = false
+	isNew := recordConnError(&s.status.LastError, err)
+	s.mu.Unlock()
+	s.covers.Release(mpdCoverOwner)
+
+	if isNew {
+		log.Printf("[MPD] Disconnected from %s: %v", s.config.Address, err)
+	}
+	if hadPlayer {
+		s.notifyChange()
+	}
+}
+
+// refresh reads status and currentsong into the player
+func (s *MPDSource) refresh(conn *mpdConn) error {
+	status, err := conn.command("status")
+	if err != nil {
+		return err
+	}
+	song, err := conn.command("currentsong")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	player := s.player
+	if player == nil {
+		player = &Player{
+			ID:           MPDPlayerID,
+			Source:       s.Name(),
+			Name:         "MPD",
+			CreatedAt:    now.UnixMilli(),
+			PlaybackRate: 1,
+		}
+		s.player = player
+	}
+	wasPlaying := player.State == StatePlaying && player.playingSince != 0
+	applyMPDStatus(player, mpdPairs(status), mpdPairs(song), now)
+	markPlaying(player, wasPlaying, now)
+
+	file := mpdPairs(song)["file"]
+	fetchCover := file != s.coverSong
+	if fetchCover {
+		s.coverSong = file
+		player.Cover = ""
+	}
+	s.mu.Unlock()
+
+	s.notifyChange()
+
+	if fetchCover {
+		s.covers.Release(mpdCoverOwner)
+	}
+	if fetchCover && file != "" {
+		s.fetchCover(conn, file)
+	}
+	return nil
+}
+
+// fetchCover reads the song's cover art, trying the folder image before the embedded one
+func (s *MPDSource) fetchCover(conn *mpdConn, file string) {
+	var data []byte
+	var err error
+	for _, command := range []string{"albumart", "readpicture"} {
+		data, err = conn.binary(command, file)
+		if err == nil && len(data) > 0 {
+			break
+		}
+		var ack *mpdError
+		if err != nil && !errors.As(err, &ack) {
+			return // connection problem, follow() notices on the next command
+		}
+	}
+	if len(data) == 0 {
+		return
+	}
+
+	coverKey, err := s.covers.Put(mpdCoverOwner, data)
+	if err != nil {
+		log.Printf("[MPD] Failed to save cover: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	if s.player == nil || s.coverSong != file {
+		s.mu.Unlock()
+		return
+	}
+	s.player.Cover = coverKey
+	s.mu.Unlock()
+
+	s.notifyChange()
+}
+
+// notifyChange calls the change callback for the MPD player
+func (s *MPDSource) notifyChange() {
+	s.mu.RLock()
+	onChange := s.onChange
+	s.mu.RUnlock()
+
+	if onChange != nil {
+		onChange([]int{MPDPlayerID})
+	}
+}
+
+// applyMPDStatus maps the status and currentsong replies onto the player
+func applyMPDStatus(player *Player, status, song map[string]string, now time.Time) {
+	player.UpdatedAt = now.UnixMilli()
+
+	switch status["state"] {
+	case "play":
+		if player.State != StatePlaying {
+			player.ActiveAt = now.UnixMilli()
+		}
+		player.State = StatePlaying
+	case "pause":
+		player.State = StatePaused
+	default:
+		player.State = StateStopped
+	}
+
+	player.Title = song["Title"]
+	if player.Title == "" {
+		player.Title = song["Name"] // stream name
+	}
+	if player.Title == "" && song["file"] != "" {
+		base := path.Base(song["file"])
+		player.Title = strings.TrimSuffix(base, path.Ext(base))
+	}
+	player.Artist = song["Artist"]
+	if player.Artist == "" {
+		player.Artist = song["AlbumArtist"]
+	}
+	player.Album = song["Album"]
+
+	// duration/elapsed are precise (MPD 0.20+); "time" is "elapsed:total" in whole seconds
+	var elapsed, duration float64
+	if v, err := strconv.ParseFloat(status["elapsed"], 64); err == nil {
+		elapsed = v
+	}
+	if v, err := strconv.ParseFloat(status["duration"], 64); err == nil {
+		duration = v
+	} else if _, total, ok := strings.Cut(status["time"], ":"); ok {
+		duration, _ = strconv.ParseFloat(total, 64)
+	}
+	player.Duration = int(duration)
+	player.Position = int(elapsed)
+	player.RebasePosition(elapsed, now)
+
+	volume, err := strconv.Atoi(status["volume"])
+	player.CanSetVolume = err == nil && volume >= 0 // -1 without a mixer
+	if player.CanSetVolume {
+		player.Volume = volume
+	}
+
+	repeat := status["repeat"] == "1"
+	single := status["single"] == "1"
+	switch {
+	case repeat && single:
+		player.Repeat = RepeatOne
+	case repeat:
+		player.Repeat = RepeatAll
+	default:
+		player.Repeat = RepeatNone
+	}
+	player.Shuffle = status["random"] == "1"
+
+	hasSong := song["file"] != ""
+	player.CanSetState = true
+	player.CanSkipNext = hasSong
+	player.CanSkipPrevious = hasSong
+	player.CanSetPosition = hasSong && duration > 0
+	player.CanSetRepeat = true
+	player.CanSetShuffle = true
+}
+
+// SendEvent translates a WebNowPlaying command into MPD commands. They are
+// confirmed by the idle update that follows.
+func (s *MPDSource) SendEvent(playerID int, command string, data interface{}) (string, error) {
+	s.mu.RLock()
+	player := s.player
+	var paused bool
+	if player != nil {
+		paused = player.State == StatePaused
+	}
+	s.mu.RUnlock()
+
+	if player == nil || playerID != MPDPlayerID {
+		return "", ErrNoPlayer
+	}
+
+	value, _ := data.(int)
+	var commands [][]string
+	switch command {
+	case "STATE":
+		switch {
+		case value == 1 && paused:
+			commands = [][]string{{"pause", "0"}}
+		case value == 1:
+			commands = [][]string{{"play"}}
+		case value == 2:
+			commands = [][]string{{"pause", "1"}}
+		default:
+			commands = [][]string{{"stop"}}
+		}
+	case "SKIP_NEXT":
+		commands = [][]string{{"next"}}
+	case "SKIP_PREVIOUS":
+		commands = [][]string{{"previous"}}
+	case "SHUFFLE":
+		commands = [][]string{{"random", strconv.Itoa(value)}}
+	case "REPEAT":
+		repeat, single := "0", "0"
+		switch RepeatMode(value) {
+		case RepeatAll:
+			repeat = "1"
+		case RepeatOne:
+			repeat, single = "1", "1"
+		}
+		commands = [][]string{{"repeat", repeat}, {"single", single}}
+	case "POSITION":
+		commands = [][]string{{"seekcur", strconv.Itoa(value)}}
+	case "VOLUME":
+		commands = [][]string{{"setvol", strconv.Itoa(value)}}
+	case "RATING":
+		return "", fmt.Errorf("%s: %w", command, ErrNotSupported)
+	default:
+		return "", fmt.Errorf("unknown command: %s", command)
+	}
+
+	if err := s.send(commands); err != nil {
+		return "", err
+	}
+
+	log.Printf("[MPD] Sent %s(%v)", command, data)
+	return fmt.Sprintf("mpd_%d", s.eventSeq.Add(1)), nil
+}
+
+// send runs commands on the command connection, reconnecting once if it went stale
+func (s *MPDSource) send(commands [][]string) error {
+	s.cmdMu.Lock()
+	defer s.cmdMu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if s.cmdConn == nil {
+			conn, err := dialMPD(s.config)
+			if err != nil {
+				return err
+			}
+			s.cmdConn = conn
+		}
+
+		err := s.cmdConn.commandList(commands)
+		var ack *mpdError
+		if err == nil || errors.As(err, &ack) || attempt > 0 {
+			return err
+		}
+		// MPD closes idle client connections after a while
+		s.cmdConn.Close()
+		s.cmdConn = nil
+	}
+}
+
+// mpdConn is one connection speaking the MPD text protocol
+type mpdConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	version string
+}
+
+// dialMPD connects and authenticates. Addresses starting with / are unix sockets.
+func dialMPD(config MPDConfig) (*mpdConn, error) {
+	network := "tcp"
+	if strings.HasPrefix(config.Address, "/") {
+		network = "unix"
+	}
+	conn, err := net.DialTimeout(network, config.Address, mpdDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &mpdConn{conn: conn, reader: bufio.NewReader(conn)}
+	conn.SetDeadline(time.Now().Add(mpdCommandTimeout))
+	greeting, err := c.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	version, ok := strings.CutPrefix(greeting, "OK MPD ")
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("not an MPD server: %q", greeting)
+	}
+	c.version = version
+
+	if config.Password != "" {
+		if _, err := c.command("password", config.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Close closes the connection
+func (c *mpdConn) Close() error {
+	return c.conn.Close()
+}
+
+// command sends a command and returns its "key: value" lines.
+// idle is sent without a deadline, since it waits for the next change.
+func (c *mpdConn) command(name string, args ...string) ([]string, error) {
+	if err := c.write(name, args...); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case line == "OK":
+			return lines, nil
+		case strings.HasPrefix(line, "ACK "):
+			return nil, &mpdError{line: line}
+		}
+		lines = append(lines, line)
+	}
+}
+
+// commandList runs several commands at once, so MPD applies them together
+func (c *mpdConn) commandList(commands [][]string) error {
+	if len(commands) == 1 {
+		_, err := c.command(commands[0][0], commands[0][1:]...)
+		return err
+	}
+
+	if err := c.write("command_list_begin"); err != nil {
+		return err
+	}
+	for _, args := range commands {
+		if err := c.write(args[0], args[1:]...); err != nil {
+			return err
+		}
+	}
+	_, err := c.command("command_list_end")
+	return err
+}
+
+// binary reads a chunked binary response (albumart, readpicture) to the end
+func (c *mpdConn) binary(name, uri string) ([]byte, error) {
+	var data []byte
+	for {
+		if err := c.write(name, uri, strconv.Itoa(len(data))); err != nil {
+			return nil, err
+		}
+
+		size, chunk := -1, -1
+		for chunk < 0 {
+			line, err := c.readLine()
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case line == "OK":
+				return data, nil // readpicture without a picture
+			case strings.HasPrefix(line, "ACK "):
+				return nil, &mpdError{line: line}
+			}
+			key, value, _ := strings.Cut(line, ": ")
+			switch key {
+			case "size":
+				size, _ = strconv.Atoi(value)
+			case "binary":
+				chunk, _ = strconv.Atoi(value)
+			}
+		}
+		// Check before allocating: size is optional and chunk comes from the server
+		if size > mpdMaxCover || chunk > mpdMaxCover || len(data)+chunk > mpdMaxCover {
+			return nil, fmt.Errorf("cover too large: more than %d bytes", mpdMaxCover)
+		}
+
+		// The chunk is followed by a newline and OK
+		buf := make([]byte, chunk+1)
+		if _, err := io.ReadFull(c.reader, buf); err != nil {
+			return nil, err
+		}
+		data = append(data, buf[:chunk]...)
+		if line, err := c.readLine(); err != nil {
+			return nil, err
+		} else if line != "OK" {
+			return nil, fmt.Errorf("unexpected reply after binary data: %q", line)
+		}
+
+		if chunk == 0 || len(data) >= size {
+			return data, nil
+		}
+	}
+}
+
+func (c *mpdConn) write(name string, args ...string) error {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, arg := range args {
+		b.WriteString(" ")
+		b.WriteString(mpdQuote(arg))
+	}
+	b.WriteString("\n")
+
+	if name == "idle" {
+		c.conn.SetDeadline(time.Time{})
+	} else {
+		c.conn.SetDeadline(time.Now().Add(mpdCommandTimeout))
+	}
+	_, err := io.WriteString(c.conn, b.String())
+	return err
+}
+
+func (c *mpdConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+// mpdQuote quotes a command argument
+func mpdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	arg = strings.ReplaceAll(arg, `"`, `\"`)
+	return `"` + arg + `"`
+}
+
+// mpdPairs turns "key: value" lines into a map (the first value of a key wins)
+func mpdPairs(lines []string) map[string]string {
+	pairs := make(map[string]string, len(lines))
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		if _, seen := pairs[key]; !seen {
+			pairs[key] = value
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,293 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMPD speaks enough of the MPD protocol for MPDSource: the greeting,
+// password, status, currentsong, idle, albumart, readpicture and command lists
+type fakeMPD struct {
+	listener net.Listener
+	password string
+	chunk    int // binary chunk size
+	commands chan [][]string
+	done     chan struct{}
+
+	mu       sync.Mutex
+	status   []string
+	song     []string
+	albumart map[string][]byte
+	pictures map[string][]byte
+	version  int           // counts changes, so idle returns at once for one a client has not read
+	wake     chan struct{} // closed to end the pending idle commands
+}
+
+func newFakeMPD(t *testing.T, password string) *fakeMPD {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeMPD{
+		listener: listener,
+		password: password,
+		chunk:    4,
+		commands: make(chan [][]string, 16),
+		done:     make(chan struct{}),
+		albumart: make(map[string][]byte),
+		pictures: make(map[string][]byte),
+		wake:     make(chan struct{}),
+	}
+	t.Cleanup(func() {
+		close(f.done)
+		listener.Close()
+	})
+	go f.serve()
+	return f
+}
+
+// set replaces the status and current song and wakes up idle clients
+func (f *fakeMPD) set(status, song []string) {
+	f.mu.Lock()
+	f.status, f.song = status, song
+	f.version++
+	close(f.wake)
+	f.wake = make(chan struct{})
+	f.mu.Unlock()
+}
+
+// nextCommands waits for the next command (list) that changes the player
+func (f *fakeMPD) nextCommands(t *testing.T) [][]string {
+	t.Helper()
+	select {
+	case commands := <-f.commands:
+		return commands
+	case <-time.After(5 * time.Second):
+		t.Fatal("no command received")
+		return nil
+	}
+}
+
+func (f *fakeMPD) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeMPD) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "OK MPD 0.23.5\n")
+
+	authorized := f.password == ""
+	seen := -1          // version of the last status read
+	var list [][]string // command list being collected, nil outside one
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args := parseFakeMPDCommand(strings.TrimSuffix(line, "\n"))
+
+		switch {
+		case args[0] == "password":
+			if len(args) == 2 && args[1] == f.password {
+				authorized = true
+				fmt.Fprint(conn, "OK\n")
+			} else {
+				fmt.Fprint(conn, "ACK [3@0] {password} incorrect password\n")
+			}
+			continue
+		case !authorized:
+			fmt.Fprintf(conn, "ACK [4@0] {%s} you don't have permission for \"%s\"\n", args[0], args[0])
+			continue
+		case args[0] == "command_list_begin":
+			list = [][]string{}
+			continue
+		case args[0] == "command_list_end":
+			f.commands <- list
+			list = nil
+			fmt.Fprint(conn, "OK\n")
+			continue
+		case list != nil:
+			list = append(list, args)
+			continue
+		}
+
+		f.mu.Lock()
+		status, song, version, wake := f.status, f.song, f.version, f.wake
+		f.mu.Unlock()
+
+		switch args[0] {
+		case "status":
+			seen = version
+			fmt.Fprint(conn, strings.Join(append(status, "OK"), "\n")+"\n")
+		case "currentsong":
+			fmt.Fprint(conn, strings.Join(append(song, "OK"), "\n")+"\n")
+		case "idle":
+			if version != seen {
+				fmt.Fprint(conn, "changed: player\nOK\n")
+				continue
+			}
+			select {
+			case <-wake:
+				fmt.Fprint(conn, "changed: player\nOK\n")
+			case <-f.done:
+				return
+			}
+		case "albumart", "readpicture":
+			f.binary(conn, args)
+		default:
+			f.commands <- [][]string{args}
+			fmt.Fprint(conn, "OK\n")
+		}
+	}
+}
+
+// binary answers albumart and readpicture in chunks of f.chunk bytes
+func (f *fakeMPD) binary(conn net.Conn, args []string) {
+	f.mu.Lock()
+	images := f.albumart
+	if args[0] == "readpicture" {
+		images = f.pictures
+	}
+	data, ok := images[args[1]]
+	f.mu.Unlock()
+
+	if !ok {
+		if args[0] == "albumart" {
+			fmt.Fprint(conn, "ACK [50@0] {albumart} No file exists\n")
+		} else {
+			fmt.Fprint(conn, "OK\n") // readpicture without a picture
+		}
+		return
+	}
+	offset, _ := strconv.Atoi(args[2])
+	end := min(offset+f.chunk, len(data))
+	fmt.Fprintf(conn, "size: %d\ntype: image/jpeg\nbinary: %d\n", len(data), end-offset)
+	conn.Write(data[offset:end])
+	fmt.Fprint(conn, "\nOK\n")
+}
+
+var fakeMPDArg = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+
+// parseFakeMPDCommand splits a command line into the command and its quoted arguments
+func parseFakeMPDCommand(line string) []string {
+	name, rest, _ := strings.Cut(line, " ")
+	args := []string{name}
+	for _, match := range fakeMPDArg.FindAllString(rest, -1) {
+		arg, err := strconv.Unquote(match)
+		if err != nil {
+			arg = match
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+func testJPEG(fill byte, size int) []byte {
+	return append([]byte{0xFF, 0xD8, 0xFF}, bytes.Repeat([]byte{fill}, size)...)
+}
+
+func TestMPDSourceFollowsServer(t *testing.T) {
+	server := newFakeMPD(t, "secret")
+	folderCover := testJPEG(1, 10)
+	embeddedCover := testJPEG(2, 7)
+	server.albumart["music/a.flac"] = folderCover
+	server.pictures["music/b.flac"] = embeddedCover
+	server.set(
+		[]string{"volume: 40", "repeat: 1", "random: 1", "single: 1", "state: play", "elapsed: 12.500", "duration: 200.250"},
+		[]string{"file: music/a.flac", "Title: Song A", "AlbumArtist: Band", "Album: Album"},
+	)
+
+	covers := NewCoverCache(t.TempDir(), DefaultCoverCacheSize)
+	src := NewMPDSource(MPDConfig{Enabled: true, Address: server.listener.Addr().String(), Password: "secret"}, covers)
+	t.Cleanup(src.Stop)
+
+	// Greeting, password, status and currentsong
+	waitFor(t, func() bool {
+		player := src.GetPlayer(MPDPlayerID)
+		return player != nil && player.Cover != ""
+	})
+	player := src.GetPlayer(MPDPlayerID)
+	if player.Title != "Song A" || player.Artist != "Band" || player.Album != "Album" {
+		t.Errorf("track = %q by %q on %q", player.Title, player.Artist, player.Album)
+	}
+	if player.State != StatePlaying || player.Duration != 200 || player.Position != 12 || player.Volume != 40 {
+		t.Errorf("state %d, duration %d, position %d, volume %d", player.State, player.Duration, player.Position, player.Volume)
+	}
+	if player.Repeat != RepeatOne || !player.Shuffle || !player.CanSetVolume || !player.CanSetPosition {
+		t.Errorf("repeat %d, shuffle %v, can set volume %v, can seek %v", player.Repeat, player.Shuffle, player.CanSetVolume, player.CanSetPosition)
+	}
+	if status := src.Status(); !status.Connected || status.Version != "0.23.5" {
+		t.Errorf("status = %+v", status)
+	}
+
+	// The folder cover, read in chunks
+	if data, err := os.ReadFile(covers.Path(player.Cover)); err != nil || !bytes.Equal(data, folderCover) {
+		t.Errorf("cover %q = %v (%v)", player.Cover, data, err)
+	}
+
+	// idle returns on the change, and the next song has only an embedded picture
+	server.set(
+		[]string{"volume: -1", "repeat: 0", "random: 0", "single: 0", "state: pause", "time: 5:90"},
+		[]string{"file: music/b.flac", "Artist: Singer"},
+	)
+	waitFor(t, func() bool {
+		player := src.GetPlayer(MPDPlayerID)
+		return player != nil && player.Title == "b" && player.Cover != ""
+	})
+	player = src.GetPlayer(MPDPlayerID)
+	if player.State != StatePaused || player.Artist != "Singer" || player.Duration != 90 || player.CanSetVolume || player.Repeat != RepeatNone {
+		t.Errorf("after the change: state %d, artist %q, duration %d, can set volume %v, repeat %d",
+			player.State, player.Artist, player.Duration, player.CanSetVolume, player.Repeat)
+	}
+	if data, err := os.ReadFile(covers.Path(player.Cover)); err != nil || !bytes.Equal(data, embeddedCover) {
+		t.Errorf("embedded cover %q = %v (%v)", player.Cover, data, err)
+	}
+
+	// REPEAT needs two commands, sent as one list
+	if _, err := src.SendEvent(MPDPlayerID, "REPEAT", int(RepeatAll)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := server.nextCommands(t), [][]string{{"repeat", "1"}, {"single", "0"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("REPEAT sent %v, want %v", got, want)
+	}
+
+	if _, err := src.SendEvent(MPDPlayerID, "STATE", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := server.nextCommands(t), [][]string{{"pause", "0"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resume sent %v, want %v", got, want)
+	}
+}
+
+func TestMPDSourceWrongPassword(t *testing.T) {
+	server := newFakeMPD(t, "secret")
+	server.set([]string{"state: stop"}, nil)
+
+	src := NewMPDSource(MPDConfig{Enabled: true, Address: server.listener.Addr().String(), Password: "wrong"}, NewCoverCache(t.TempDir(), DefaultCoverCacheSize))
+	t.Cleanup(src.Stop)
+
+	waitFor(t, func() bool { return src.Status().LastError != "" })
+	if status := src.Status(); status.Connected || !strings.Contains(status.LastError, "incorrect password") {
+		t.Errorf("status = %+v", status)
+	}
+	if src.GetPlayer(MPDPlayerID) != nil {
+		t.Error("player without a connection")
+	}
+}
@@ -0,0 +1,13 @@
+package media
+
+import "errors"
+
+// ErrMPRISUnsupported is returned by StartMPRISBridge and StartMPRISSource where there is no D-Bus session bus
+var ErrMPRISUnsupported = errors.New("MPRIS is only supported on Linux")
+
+// MPRISPlayerIDBase offsets the IDs of native MPRIS players so they never clash
+// with the IDs WebNowPlaying assigns
+const MPRISPlayerIDBase = 1 << 20
+
+// PlayerCommandHandler sends a media command to a specific player
+type PlayerCommandHandler func(playerID int, cmd Command) error
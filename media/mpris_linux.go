@@ -0,0 +1,505 @@
+//go:build linux
+
+package media
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const (
+	mprisPath        = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	mprisRootIface   = "org.mpris.MediaPlayer2"
+	mprisPlayerIface = "org.mpris.MediaPlayer2.Player"
+	propsIface       = "org.freedesktop.DBus.Properties"
+	// mprisBusPrefix is followed by the WebNowPlaying player ID
+	mprisBusPrefix = "org.mpris.MediaPlayer2.roundsound.wnp"
+	// mprisSeekTolerance is how far (seconds) the position may drift from the
+	// extrapolation before it counts as a seek and Seeked is emitted
+	mprisSeekTolerance = 2
+)
+
+// mprisIntrospection describes the exported object for D-Bus introspection
+const mprisIntrospection = `<node>
+  <interface name="org.mpris.MediaPlayer2">
+    <method name="Raise"/>
+    <method name="Quit"/>
+    <property name="CanQuit" type="b" access="read"/>
+    <property name="CanRaise" type="b" access="read"/>
+    <property name="HasTrackList" type="b" access="read"/>
+    <property name="Identity" type="s" access="read"/>
+    <property name="SupportedUriSchemes" type="as" access="read"/>
+    <property name="SupportedMimeTypes" type="as" access="read"/>
+  </interface>
+  <interface name="org.mpris.MediaPlayer2.Player">
+    <method name="Next"/>
+    <method name="Previous"/>
+    <method name="Pause"/>
+    <method name="PlayPause"/>
+    <method name="Stop"/>
+    <method name="Play"/>
+    <method name="Seek"><arg name="Offset" type="x" direction="in"/></method>
+    <method name="SetPosition"><arg name="TrackId" type="o" direction="in"/><arg name="Position" type="x" direction="in"/></method>
+    <method name="OpenUri"><arg name="Uri" type="s" direction="in"/></method>
+    <signal name="Seeked"><arg name="Position" type="x"/></signal>
+    <property name="PlaybackStatus" type="s" access="read"/>
+    <property name="LoopStatus" type="s" access="readwrite"/>
+    <property name="Rate" type="d" access="readwrite"/>
+    <property name="Shuffle" type="b" access="readwrite"/>
+    <property name="Metadata" type="a{sv}" access="read"/>
+    <property name="Volume" type="d" access="readwrite"/>
+    <property name="Position" type="x" access="read"/>
+    <property name="MinimumRate" type="d" access="read"/>
+    <property name="MaximumRate" type="d" access="read"/>
+    <property name="CanGoNext" type="b" access="read"/>
+    <property name="CanGoPrevious" type="b" access="read"/>
+    <property name="CanPlay" type="b" access="read"/>
+    <property name="CanPause" type="b" access="read"/>
+    <property name="CanSeek" type="b" access="read"/>
+    <property name="CanControl" type="b" access="read"/>
+  </interface>` + introspect.IntrospectDataString + `
+  <interface name="org.freedesktop.DBus.Properties">
+    <method name="Get"><arg name="interface" type="s" direction="in"/><arg name="property" type="s" direction="in"/><arg name="value" type="v" direction="out"/></method>
+    <method name="GetAll"><arg name="interface" type="s" direction="in"/><arg name="properties" type="a{sv}" direction="out"/></method>
+    <method name="Set"><arg name="interface" type="s" direction="in"/><arg name="property" type="s" direction="in"/><arg name="value" type="v" direction="in"/></method>
+    <signal name="PropertiesChanged"><arg name="interface" type="s"/><arg name="changed_properties" type="a{sv}"/><arg name="invalidated_properties" type="as"/></signal>
+  </interface>
+</node>`
+
+// BusDialer opens a new D-Bus connection. Every exported player needs its own
+// connection because all MPRIS services use the same object path.
+type BusDialer func() (*dbus.Conn, error)
+
+// MPRISBridge publishes WebNowPlaying players as MPRIS services on the session bus,
+// so media keys and desktop widgets can show and control them
+type MPRISBridge struct {
+	mu      sync.Mutex
+	dial    BusDialer
+	handler PlayerCommandHandler
+	covers  *CoverCache
+	players map[int]*mprisPlayer
+	closed  bool
+}
+
+// StartMPRISBridge creates a bridge on the user's session bus. Cached covers are
+// resolved in covers.
+func StartMPRISBridge(covers *CoverCache, handler PlayerCommandHandler) (*MPRISBridge, error) {
+	// Fail early when there is no session bus at all
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+	return NewMPRISBridge(func() (*dbus.Conn, error) {
+		return dbus.ConnectSessionBus()
+	}, covers, handler), nil
+}
+
+// NewMPRISBridge creates a bridge opening its connections with dial
+// (e.g. dbus.Connect on a private dbus-daemon address)
+func NewMPRISBridge(dial BusDialer, covers *CoverCache, handler PlayerCommandHandler) *MPRISBridge {
+	return &MPRISBridge{
+		dial:    dial,
+		handler: handler,
+		covers:  covers,
+		players: make(map[int]*mprisPlayer),
+	}
+}
+
+// BusName returns the MPRIS service name of a player
+func (b *MPRISBridge) BusName(playerID int) string {
+	return fmt.Sprintf("%s%d", mprisBusPrefix, playerID)
+}
+
+// Sync exports new players, updates existing ones and removes the ones that are gone
+func (b *MPRISBridge) Sync(players []*Player) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	now := time.Now()
+	seen := make(map[int]bool, len(players))
+	for _, player := range players {
+		// Native MPRIS players are on the bus already
+		if player.Source == SourceMPRIS {
+			continue
+		}
+		seen[player.ID] = true
+		if p, ok := b.players[player.ID]; ok {
+			p.update(player, now)
+			continue
+		}
+		p, err := b.export(player)
+		if err != nil {
+			log.Printf("[MPRIS] Failed to export player %d: %v", player.ID, err)
+			continue
+		}
+		b.players[player.ID] = p
+	}
+
+	for id, p := range b.players {
+		if !seen[id] {
+			p.close()
+			delete(b.players, id)
+		}
+	}
+}
+
+// Close removes all services
+func (b *MPRISBridge) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, p := range b.players {
+		p.close()
+		delete(b.players, id)
+	}
+	b.closed = true
+}
+
+// export connects a player to the bus and claims its service name. Caller must hold mu.
+func (b *MPRISBridge) export(player *Player) (*mprisPlayer, error) {
+	conn, err := b.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &mprisPlayer{
+		id:      player.ID,
+		conn:    conn,
+		busName: b.BusName(player.ID),
+		handler: b.handler,
+		covers:  b.covers,
+		player:  player.Clone(),
+		trackID: 1,
+	}
+	p.lastPlayer = p.playerProps(time.Now())
+	p.lastRoot = p.rootProps()
+
+	exports := []struct {
+		value   any
+		mapping map[string]string
+		iface   string
+	}{
+		{mprisRoot{p}, nil, mprisRootIface},
+		// Seek is renamed on the Go side so it is not mistaken for io.Seeker
+		{mprisControls{p}, map[string]string{"SeekOffset": "Seek"}, mprisPlayerIface},
+		{mprisProperties{p}, nil, propsIface},
+		{introspect.Introspectable(mprisIntrospection), nil, "org.freedesktop.DBus.Introspectable"},
+	}
+	for _, e := range exports {
+		if err := conn.ExportWithMap(e.value, e.mapping, mprisPath, e.iface); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	reply, err := conn.RequestName(p.busName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, fmt.Errorf("bus name %s is taken", p.busName)
+	}
+
+	log.Printf("[MPRIS] Exported player %d (%s) as %s", player.ID, player.Name, p.busName)
+	return p, nil
+}
+
+// mprisPlayer is one exported WebNowPlaying player
+type mprisPlayer struct {
+	id      int
+	conn    *dbus.Conn
+	busName string
+	handler PlayerCommandHandler
+	covers  *CoverCache
+
+	mu     sync.Mutex
+	player *Player
+	// trackID changes with the track so clients can match SetPosition calls
+	trackID    int
+	lastPlayer map[string]dbus.Variant
+	lastRoot   map[string]dbus.Variant
+}
+
+// snapshot returns the latest player state
+func (p *mprisPlayer) snapshot() *Player {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.player
+}
+
+// update stores the new state and emits PropertiesChanged and Seeked as needed
+func (p *mprisPlayer) update(player *Player, now time.Time) {
+	p.mu.Lock()
+	previous := p.player
+	if previous.Title != player.Title || previous.Artist != player.Artist || previous.Album != player.Album {
+		p.trackID++
+	}
+	expected := previous.EstimatedPositionAt(now)
+	p.player = player.Clone()
+	actual := p.player.EstimatedPositionAt(now)
+	sameTrack := previous.Title == player.Title && previous.Artist == player.Artist
+
+	changedPlayer := diffProps(p.lastPlayer, p.playerProps(now))
+	changedRoot := diffProps(p.lastRoot, p.rootProps())
+	for name, value := range changedPlayer {
+		p.lastPlayer[name] = value
+	}
+	for name, value := range changedRoot {
+		p.lastRoot[name] = value
+	}
+	p.mu.Unlock()
+
+	if len(changedPlayer) > 0 {
+		p.emitChanged(mprisPlayerIface, changedPlayer)
+	}
+	if len(changedRoot) > 0 {
+		p.emitChanged(mprisRootIface, changedRoot)
+	}
+	if sameTrack && (actual-expected > mprisSeekTolerance || expected-actual > mprisSeekTolerance) {
+		p.conn.Emit(mprisPath, mprisPlayerIface+".Seeked", int64(actual*1e6))
+	}
+}
+
+func (p *mprisPlayer) emitChanged(iface string, changed map[string]dbus.Variant) {
+	if err := p.conn.Emit(mprisPath, propsIface+".PropertiesChanged", iface, changed, []string{}); err != nil {
+		log.Printf("[MPRIS] Failed to emit PropertiesChanged for %s: %v", p.busName, err)
+	}
+}
+
+// close releases the service name and the connection
+func (p *mprisPlayer) close() {
+	p.conn.ReleaseName(p.busName)
+	p.conn.Close()
+	log.Printf("[MPRIS] Removed %s", p.busName)
+}
+
+// diffProps returns the entries of next that differ from prev. Position is never
+// announced: clients extrapolate it and are told about jumps by Seeked.
+func diffProps(prev, next map[string]dbus.Variant) map[string]dbus.Variant {
+	changed := make(map[string]dbus.Variant)
+	for name, value := range next {
+		if name == "Position" {
+			continue
+		}
+		if old, ok := prev[name]; !ok || !reflect.DeepEqual(old.Value(), value.Value()) {
+			changed[name] = value
+		}
+	}
+	return changed
+}
+
+// rootProps returns the org.mpris.MediaPlayer2 properties. Caller must hold mu.
+func (p *mprisPlayer) rootProps() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"CanQuit":             dbus.MakeVariant(false),
+		"CanRaise":            dbus.MakeVariant(false),
+		"HasTrackList":        dbus.MakeVariant(false),
+		"Identity":            dbus.MakeVariant("Round Sound: " + p.player.Name),
+		"SupportedUriSchemes": dbus.MakeVariant([]string{}),
+		"SupportedMimeTypes":  dbus.MakeVariant([]string{}),
+	}
+}
+
+// playerProps returns the org.mpris.MediaPlayer2.Player properties. Caller must hold mu.
+func (p *mprisPlayer) playerProps(now time.Time) map[string]dbus.Variant {
+	player := p.player
+	return map[string]dbus.Variant{
+		"PlaybackStatus": dbus.MakeVariant(mprisPlaybackStatus(player.State)),
+		"LoopStatus":     dbus.MakeVariant(mprisLoopStatus(player.Repeat)),
+		"Rate":           dbus.MakeVariant(1.0),
+		"Shuffle":        dbus.MakeVariant(player.Shuffle),
+		"Metadata":       dbus.MakeVariant(p.metadata()),
+		"Volume":         dbus.MakeVariant(float64(player.Volume) / 100),
+		"Position":       dbus.MakeVariant(int64(player.EstimatedPositionAt(now) * 1e6)),
+		"MinimumRate":    dbus.MakeVariant(1.0),
+		"MaximumRate":    dbus.MakeVariant(1.0),
+		"CanGoNext":      dbus.MakeVariant(player.CanSkipNext),
+		"CanGoPrevious":  dbus.MakeVariant(player.CanSkipPrevious),
+		"CanPlay":        dbus.MakeVariant(player.CanSetState),
+		"CanPause":       dbus.MakeVariant(player.CanSetState),
+		"CanSeek":        dbus.MakeVariant(player.CanSetPosition),
+		"CanControl":     dbus.MakeVariant(true),
+	}
+}
+
+// metadata returns the MPRIS metadata of the current track. Caller must hold mu.
+func (p *mprisPlayer) metadata() map[string]dbus.Variant {
+	player := p.player
+	metadata := map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(p.trackPath()),
+		"xesam:title":   dbus.MakeVariant(player.Title),
+	}
+	if player.Artist != "" {
+		metadata["xesam:artist"] = dbus.MakeVariant([]string{player.Artist})
+	}
+	if player.Album != "" {
+		metadata["xesam:album"] = dbus.MakeVariant(player.Album)
+	}
+	if player.Duration > 0 {
+		metadata["mpris:length"] = dbus.MakeVariant(int64(player.Duration) * 1e6)
+	}
+	if artURL := p.covers.Location(player.Cover); artURL != "" {
+		if !strings.Contains(artURL, "://") {
+			artURL = "file://" + artURL
+		}
+		metadata["mpris:artUrl"] = dbus.MakeVariant(artURL)
+	}
+	return metadata
+}
+
+// trackPath is the mpris:trackid of the current track. Caller must hold mu.
+func (p *mprisPlayer) trackPath() dbus.ObjectPath {
+	return dbus.ObjectPath(fmt.Sprintf("/org/roundsound/player%d/track%d", p.id, p.trackID))
+}
+
+func mprisPlaybackStatus(state StateMode) string {
+	switch state {
+	case StatePlaying:
+		return "Playing"
+	case StatePaused:
+		return "Paused"
+	}
+	return "Stopped"
+}
+
+func mprisLoopStatus(repeat RepeatMode) string {
+	switch repeat {
+	case RepeatOne:
+		return "Track"
+	case RepeatAll:
+		return "Playlist"
+	}
+	return "None"
+}
+
+// send runs a command on the player and converts the error for D-Bus
+func (p *mprisPlayer) send(cmd Command) *dbus.Error {
+	if err := p.handler(p.id, cmd); err != nil {
+		log.Printf("[MPRIS] %s on %s failed: %v", cmd, p.busName, err)
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// mprisRoot implements org.mpris.MediaPlayer2
+type mprisRoot struct{ p *mprisPlayer }
+
+func (r mprisRoot) Raise() *dbus.Error { return nil }
+func (r mprisRoot) Quit() *dbus.Error  { return nil }
+
+// mprisControls implements org.mpris.MediaPlayer2.Player
+type mprisControls struct{ p *mprisPlayer }
+
+func (c mprisControls) Next() *dbus.Error     { return c.p.send(Command{Kind: CommandNext}) }
+func (c mprisControls) Previous() *dbus.Error { return c.p.send(Command{Kind: CommandPrevious}) }
+func (c mprisControls) Pause() *dbus.Error    { return c.p.send(Command{Kind: CommandPause}) }
+func (c mprisControls) Play() *dbus.Error     { return c.p.send(Command{Kind: CommandPlay}) }
+func (c mprisControls) Stop() *dbus.Error     { return c.p.send(Command{Kind: CommandPause}) }
+
+func (c mprisControls) PlayPause() *dbus.Error {
+	return c.p.send(Command{Kind: CommandTogglePlayPause})
+}
+
+// SeekOffset implements Seek: it moves relative to the current position (offset in microseconds)
+func (c mprisControls) SeekOffset(offset int64) *dbus.Error {
+	position := c.p.snapshot().EstimatedPositionAt(time.Now()) + float64(offset)/1e6
+	return c.p.send(Command{Kind: CommandSeek, Value: int(max(position, 0))})
+}
+
+// SetPosition seeks to an absolute position (microseconds) if trackID is still current
+func (c mprisControls) SetPosition(trackID dbus.ObjectPath, position int64) *dbus.Error {
+	c.p.mu.Lock()
+	current := c.p.trackPath()
+	duration := c.p.player.Duration
+	c.p.mu.Unlock()
+
+	seconds := int(position / 1e6)
+	if trackID != current || position < 0 || (duration > 0 && seconds > duration) {
+		return nil
+	}
+	return c.p.send(Command{Kind: CommandSeek, Value: seconds})
+}
+
+func (c mprisControls) OpenUri(uri string) *dbus.Error {
+	return dbus.NewError("org.mpris.MediaPlayer2.Player.Error.NotSupported", []any{"OpenUri is not supported"})
+}
+
+// mprisProperties implements org.freedesktop.DBus.Properties with a live Position
+type mprisProperties struct{ p *mprisPlayer }
+
+func (m mprisProperties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	m.p.mu.Lock()
+	defer m.p.mu.Unlock()
+	switch iface {
+	case mprisRootIface:
+		return m.p.rootProps(), nil
+	case mprisPlayerIface:
+		return m.p.playerProps(time.Now()), nil
+	}
+	return nil, dbus.MakeFailedError(fmt.Errorf("unknown interface %s", iface))
+}
+
+func (m mprisProperties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	props, dbusErr := m.GetAll(iface)
+	if dbusErr != nil {
+		return dbus.Variant{}, dbusErr
+	}
+	value, ok := props[name]
+	if !ok {
+		return dbus.Variant{}, dbus.MakeFailedError(fmt.Errorf("unknown property %s", name))
+	}
+	return value, nil
+}
+
+// Set translates writes of Volume, Shuffle, LoopStatus and Rate into commands
+func (m mprisProperties) Set(iface, name string, value dbus.Variant) *dbus.Error {
+	if iface != mprisPlayerIface {
+		return dbus.MakeFailedError(fmt.Errorf("property %s.%s is read-only", iface, name))
+	}
+	player := m.p.snapshot()
+
+	switch name {
+	case "Volume":
+		volume, ok := value.Value().(float64)
+		if !ok {
+			return dbus.MakeFailedError(fmt.Errorf("Volume must be a double"))
+		}
+		return m.p.send(Command{Kind: CommandSetVolume, Value: int(min(max(volume, 0), 1)*100 + 0.5)})
+
+	case "Shuffle":
+		shuffle, ok := value.Value().(bool)
+		if !ok {
+			return dbus.MakeFailedError(fmt.Errorf("Shuffle must be a boolean"))
+		}
+		if shuffle == player.Shuffle {
+			return nil
+		}
+		return m.p.send(Command{Kind: CommandToggleShuffle})
+
+	case "LoopStatus":
+		status, _ := value.Value().(string)
+		modes := map[string]RepeatMode{"None": RepeatNone, "Track": RepeatOne, "Playlist": RepeatAll}
+		mode, ok := modes[status]
+		if !ok {
+			return dbus.MakeFailedError(fmt.Errorf("invalid LoopStatus %q", status))
+		}
+		return m.p.send(Command{Kind: CommandSetRepeat, Value: int(mode)})
+
+	case "Rate":
+		// Only normal speed is supported; MinimumRate = MaximumRate = 1
+		return nil
+	}
+	return dbus.MakeFailedError(fmt.Errorf("property %s is read-only", name))
+}
@@ -0,0 +1,179 @@
+//go:build linux
+
+package media
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// startPrivateBus runs a dbus-daemon for the test and returns its address
+func startPrivateBus(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read dbus-daemon address: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+// recordedCommands collects the commands the bridge sends
+type recordedCommands chan Command
+
+func (r recordedCommands) handler(playerID int, cmd Command) error {
+	r <- cmd
+	return nil
+}
+
+func (r recordedCommands) next(t *testing.T) Command {
+	t.Helper()
+	select {
+	case cmd := <-r:
+		return cmd
+	case <-time.After(5 * time.Second):
+		t.Fatal("no command received")
+		return Command{}
+	}
+}
+
+func TestMPRISBridge(t *testing.T) {
+	address := startPrivateBus(t)
+	dial := func() (*dbus.Conn, error) { return dbus.Connect(address) }
+
+	commands := make(recordedCommands, 16)
+	bridge := NewMPRISBridge(dial, nil, commands.handler)
+	t.Cleanup(bridge.Close)
+
+	player := &Player{
+		ID:             7,
+		Source:         SourceWebNowPlaying,
+		Name:           "YouTube Music",
+		Title:          "Song",
+		Artist:         "Artist",
+		Album:          "Album",
+		State:          StatePaused,
+		Position:       50,
+		Duration:       200,
+		Volume:         40,
+		CanSetState:    true,
+		CanSetPosition: true,
+		CanSetVolume:   true,
+		PlaybackRate:   1,
+	}
+	player.RebasePosition(50, time.Now())
+	bridge.Sync([]*Player{player})
+
+	client, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	obj := client.Object(bridge.BusName(7), mprisPath)
+
+	// Properties
+	status, err := obj.GetProperty(mprisPlayerIface + ".PlaybackStatus")
+	if err != nil || status.Value() != "Paused" {
+		t.Fatalf("PlaybackStatus = %v (%v)", status, err)
+	}
+	metadataValue, err := obj.GetProperty(mprisPlayerIface + ".Metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := metadataValue.Value().(map[string]dbus.Variant)
+	if metadata["xesam:title"].Value() != "Song" || metadata["mpris:length"].Value() != int64(200e6) {
+		t.Errorf("Metadata = %v", metadata)
+	}
+	trackID := metadata["mpris:trackid"].Value().(dbus.ObjectPath)
+
+	// Methods
+	call := func(method string, args ...any) {
+		t.Helper()
+		if err := obj.Call(mprisPlayerIface+"."+method, 0, args...).Err; err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+	}
+
+	call("Play")
+	if cmd := commands.next(t); cmd.Kind != CommandPlay {
+		t.Errorf("Play sent %s", cmd)
+	}
+
+	call("Seek", int64(10e6))
+	if cmd := commands.next(t); cmd.Kind != CommandSeek || cmd.Value != 60 {
+		t.Errorf("Seek +10 s sent %s", cmd)
+	}
+
+	call("SetPosition", trackID, int64(120e6))
+	if cmd := commands.next(t); cmd.Kind != CommandSeek || cmd.Value != 120 {
+		t.Errorf("SetPosition 120 s sent %s", cmd)
+	}
+
+	// SetPosition for another track and past the end is ignored
+	call("SetPosition", dbus.ObjectPath("/org/roundsound/player7/track99"), int64(10e6))
+	call("SetPosition", trackID, int64(300e6))
+
+	// Property setters
+	if err := obj.SetProperty(mprisPlayerIface+".Volume", dbus.MakeVariant(0.25)); err != nil {
+		t.Fatalf("set Volume: %v", err)
+	}
+	if cmd := commands.next(t); cmd.Kind != CommandSetVolume || cmd.Value != 25 {
+		t.Errorf("Volume 0.25 sent %s", cmd)
+	}
+
+	if err := obj.SetProperty(mprisPlayerIface+".LoopStatus", dbus.MakeVariant("Track")); err != nil {
+		t.Fatalf("set LoopStatus: %v", err)
+	}
+	if cmd := commands.next(t); cmd.Kind != CommandSetRepeat || cmd.Value != int(RepeatOne) {
+		t.Errorf("LoopStatus Track sent %s", cmd)
+	}
+
+	if err := obj.SetProperty(mprisPlayerIface+".LoopStatus", dbus.MakeVariant("Sometimes")); err == nil {
+		t.Error("invalid LoopStatus accepted")
+	}
+
+	select {
+	case cmd := <-commands:
+		t.Errorf("unexpected %s", cmd)
+	default:
+	}
+
+	// Updates reach clients, and players that are gone lose their service
+	updated := player.Clone()
+	updated.State = StatePlaying
+	bridge.Sync([]*Player{updated})
+	status, err = obj.GetProperty(mprisPlayerIface + ".PlaybackStatus")
+	if err != nil || status.Value() != "Playing" {
+		t.Errorf("PlaybackStatus after update = %v (%v)", status, err)
+	}
+
+	bridge.Sync(nil)
+	var hasOwner bool
+	if err := client.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, bridge.BusName(7)).Store(&hasOwner); err != nil {
+		t.Fatal(err)
+	}
+	if hasOwner {
+		t.Error("service still registered after the player is gone")
+	}
+}
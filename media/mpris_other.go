@@ -0,0 +1,45 @@
+//go:build !linux
+
+package media
+
+// MPRISBridge is a placeholder outside Linux
+type MPRISBridge struct{}
+
+// StartMPRISBridge is not available outside Linux
+func StartMPRISBridge(covers *CoverCache, handler PlayerCommandHandler) (*MPRISBridge, error) {
+	return nil, ErrMPRISUnsupported
+}
+
+// Sync does nothing outside Linux
+func (b *MPRISBridge) Sync(players []*Player) {}
+
+// Close does nothing outside Linux
+func (b *MPRISBridge) Close() {}
+
+// MPRISSource is a placeholder outside Linux
+type MPRISSource struct{}
+
+// StartMPRISSource is not available outside Linux
+func StartMPRISSource() (*MPRISSource, error) {
+	return nil, ErrMPRISUnsupported
+}
+
+// Name identifies the source
+func (s *MPRISSource) Name() string { return SourceMPRIS }
+
+// GetPlayer returns nil outside Linux
+func (s *MPRISSource) GetPlayer(playerID int) *Player { return nil }
+
+// GetPlayers returns no players outside Linux
+func (s *MPRISSource) GetPlayers() []*Player { return nil }
+
+// SetChangeCallback does nothing outside Linux
+func (s *MPRISSource) SetChangeCallback(onChange SourceChangeCallback) {}
+
+// SendEvent fails outside Linux
+func (s *MPRISSource) SendEvent(playerID int, command string, data interface{}) (string, error) {
+	return "", ErrMPRISUnsupported
+}
+
+// Stop does nothing outside Linux
+func (s *MPRISSource) Stop() {}
@@ -0,0 +1,519 @@
+//go:build linux
+
+package media
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	// mprisNamePrefix starts the bus name of every MPRIS player
+	mprisNamePrefix = "org.mpris.MediaPlayer2."
+	busIface        = "org.freedesktop.DBus"
+)
+
+// MPRISSource follows the native media players on the session bus (Spotify, VLC,
+// mpv with mpv-mpris, ...) and controls them through MPRIS
+type MPRISSource struct {
+	conn     *dbus.Conn
+	signals  chan *dbus.Signal
+	eventSeq atomic.Uint64
+
+	mu       sync.RWMutex
+	clients  map[string]*mprisClient // by bus name
+	nextID   int
+	onChange SourceChangeCallback
+}
+
+// mprisClient is one player found on the bus
+type mprisClient struct {
+	busName string
+	owner   string // unique connection name signals are sent from
+	obj     dbus.BusObject
+	player  *Player
+	trackID dbus.ObjectPath
+	caps    map[string]bool // Can* properties and which optional properties exist
+}
+
+// StartMPRISSource connects to the user's session bus and starts following its players
+func StartMPRISSource() (*MPRISSource, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	source, err := NewMPRISSource(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return source, nil
+}
+
+// NewMPRISSource follows the players on conn. The source owns conn and closes it on Stop.
+func NewMPRISSource(conn *dbus.Conn) (*MPRISSource, error) {
+	s := &MPRISSource{
+		conn:    conn,
+		signals: make(chan *dbus.Signal, 64),
+		clients: make(map[string]*mprisClient),
+		nextID:  MPRISPlayerIDBase,
+	}
+
+	matches := [][]dbus.MatchOption{
+		{dbus.WithMatchInterface(busIface), dbus.WithMatchMember("NameOwnerChanged"), dbus.WithMatchArg0Namespace("org.mpris.MediaPlayer2")},
+		{dbus.WithMatchInterface(propsIface), dbus.WithMatchMember("PropertiesChanged"), dbus.WithMatchObjectPath(mprisPath)},
+		{dbus.WithMatchInterface(mprisPlayerIface), dbus.WithMatchMember("Seeked"), dbus.WithMatchObjectPath(mprisPath)},
+	}
+	for _, match := range matches {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			return nil, err
+		}
+	}
+	conn.Signal(s.signals)
+
+	var names []string
+	if err := conn.BusObject().Call(busIface+".ListNames", 0).Store(&names); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if isForeignMPRISName(name) {
+			s.add(name)
+		}
+	}
+
+	go s.signalLoop()
+	return s, nil
+}
+
+// isForeignMPRISName reports whether name is an MPRIS player not published by our own bridge
+func isForeignMPRISName(name string) bool {
+	return strings.HasPrefix(name, mprisNamePrefix) && !strings.HasPrefix(name, mprisBusPrefix)
+}
+
+// Name identifies the source
+func (s *MPRISSource) Name() string {
+	return SourceMPRIS
+}
+
+// SetChangeCallback sets the callback invoked after players are added, updated or removed
+func (s *MPRISSource) SetChangeCallback(onChange SourceChangeCallback) {
+	s.mu.Lock()
+	s.onChange = onChange
+	s.mu.Unlock()
+}
+
+// Stop disconnects from the bus, which also ends the signal loop
+func (s *MPRISSource) Stop() {
+	s.conn.Close()
+	log.Println("[MPRIS] Player source stopped")
+}
+
+// GetPlayer returns a snapshot of the player with the given ID, or nil
+func (s *MPRISSource) GetPlayer(playerID int) *Player {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if client := s.clientByID(playerID); client != nil {
+		return client.player.Snapshot(time.Now())
+	}
+	return nil
+}
+
+// GetPlayers returns snapshots of all players on the bus ordered by ID
+func (s *MPRISSource) GetPlayers() []*Player {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	players := make([]*Player, 0, len(s.clients))
+	for _, client := range s.clients {
+		players = append(players, client.player.Snapshot(now))
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].ID < players[j].ID
+	})
+	return players
+}
+
+// clientByID finds a player by ID. Caller must hold mu.
+func (s *MPRISSource) clientByID(playerID int) *mprisClient {
+	for _, client := range s.clients {
+		if client.player.ID == playerID {
+			return client
+		}
+	}
+	return nil
+}
+
+// clientByOwner finds the player a signal was sent from. Caller must hold mu.
+func (s *MPRISSource) clientByOwner(owner string) *mprisClient {
+	for _, client := range s.clients {
+		if client.owner == owner {
+			return client
+		}
+	}
+	return nil
+}
+
+// add reads a newly appeared player and reports it
+func (s *MPRISSource) add(busName string) {
+	var owner string
+	if err := s.conn.BusObject().Call(busIface+".GetNameOwner", 0, busName).Store(&owner); err != nil {
+		log.Printf("[MPRIS] Failed to resolve %s: %v", busName, err)
+		return
+	}
+
+	obj := s.conn.Object(busName, mprisPath)
+	var props map[string]dbus.Variant
+	if err := obj.Call(propsIface+".GetAll", 0, mprisPlayerIface).Store(&props); err != nil {
+		log.Printf("[MPRIS] Failed to read %s: %v", busName, err)
+		return
+	}
+
+	name := strings.TrimPrefix(busName, mprisNamePrefix)
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		name = name[:i] // drop ".instance1234"
+	}
+	if identity, err := obj.GetProperty(mprisRootIface + ".Identity"); err == nil {
+		if v, ok := identity.Value().(string); ok && v != "" {
+			name = v
+		}
+	}
+
+	now := time.Now()
+	client := &mprisClient{
+		busName: busName,
+		owner:   owner,
+		obj:     obj,
+		caps:    make(map[string]bool),
+		player: &Player{
+			Source:       SourceMPRIS,
+			Name:         name,
+			Repeat:       RepeatNone,
+			CreatedAt:    now.UnixMilli(),
+			UpdatedAt:    now.UnixMilli(),
+			PlaybackRate: 1,
+		},
+	}
+	client.apply(props, now)
+	markPlaying(client.player, false, now)
+
+	s.mu.Lock()
+	if _, ok := s.clients[busName]; ok {
+		s.mu.Unlock()
+		return
+	}
+	s.nextID++
+	client.player.ID = s.nextID
+	s.clients[busName] = client
+	s.mu.Unlock()
+
+	log.Printf("[MPRIS] Player added: %d (%s) - %s", client.player.ID, busName, client.player.Title)
+	s.notifyChange(client.player.ID)
+}
+
+// remove drops a player whose bus name went away
+func (s *MPRISSource) remove(busName string) {
+	s.mu.Lock()
+	client, ok := s.clients[busName]
+	delete(s.clients, busName)
+	s.mu.Unlock()
+
+	if ok {
+		log.Printf("[MPRIS] Player removed: %d (%s)", client.player.ID, busName)
+		s.notifyChange(client.player.ID)
+	}
+}
+
+// signalLoop handles bus signals until the connection is closed
+func (s *MPRISSource) signalLoop() {
+	for signal := range s.signals {
+		switch signal.Name {
+		case busIface + ".NameOwnerChanged":
+			if len(signal.Body) < 3 {
+				continue
+			}
+			name, _ := signal.Body[0].(string)
+			newOwner, _ := signal.Body[2].(string)
+			if !isForeignMPRISName(name) {
+				continue
+			}
+			s.remove(name)
+			if newOwner != "" {
+				s.add(name)
+			}
+
+		case propsIface + ".PropertiesChanged":
+			if len(signal.Body) < 3 {
+				continue
+			}
+			iface, _ := signal.Body[0].(string)
+			changed, _ := signal.Body[1].(map[string]dbus.Variant)
+			invalidated, _ := signal.Body[2].([]string)
+			if iface == mprisPlayerIface {
+				s.propertiesChanged(signal.Sender, changed, invalidated)
+			}
+
+		case mprisPlayerIface + ".Seeked":
+			if len(signal.Body) < 1 {
+				continue
+			}
+			if position, ok := signal.Body[0].(int64); ok {
+				s.seeked(signal.Sender, position)
+			}
+		}
+	}
+}
+
+// propertiesChanged applies a PropertiesChanged signal of a player
+func (s *MPRISSource) propertiesChanged(owner string, changed map[string]dbus.Variant, invalidated []string) {
+	s.mu.RLock()
+	client := s.clientByOwner(owner)
+	s.mu.RUnlock()
+	if client == nil {
+		return
+	}
+
+	// Invalidated properties are only announced by name, and Position is never
+	// announced at all: ask for them when the state or the track changes
+	var fetch []string
+	fetch = append(fetch, invalidated...)
+	if _, ok := changed["PlaybackStatus"]; ok {
+		fetch = append(fetch, "Position")
+	}
+	if _, ok := changed["Metadata"]; ok {
+		fetch = append(fetch, "Position")
+	}
+	if len(fetch) > 0 {
+		if changed == nil {
+			changed = make(map[string]dbus.Variant)
+		}
+		for _, name := range fetch {
+			if value, err := client.obj.GetProperty(mprisPlayerIface + "." + name); err == nil {
+				changed[name] = value
+			}
+		}
+	}
+
+	s.mu.Lock()
+	wasPlaying := client.player.State == StatePlaying && client.player.playingSince != 0
+	client.apply(changed, time.Now())
+	markPlaying(client.player, wasPlaying, time.Now())
+	id := client.player.ID
+	s.mu.Unlock()
+
+	s.notifyChange(id)
+}
+
+// seeked re-anchors the position after the player jumped
+func (s *MPRISSource) seeked(owner string, position int64) {
+	s.mu.Lock()
+	client := s.clientByOwner(owner)
+	if client == nil {
+		s.mu.Unlock()
+		return
+	}
+	client.apply(map[string]dbus.Variant{"Position": dbus.MakeVariant(position)}, time.Now())
+	id := client.player.ID
+	s.mu.Unlock()
+
+	s.notifyChange(id)
+}
+
+// notifyChange calls the change callback with the touched player
+func (s *MPRISSource) notifyChange(playerID int) {
+	s.mu.RLock()
+	onChange := s.onChange
+	s.mu.RUnlock()
+
+	if onChange != nil {
+		onChange([]int{playerID})
+	}
+}
+
+// apply maps MPRIS player properties onto the player
+func (c *mprisClient) apply(props map[string]dbus.Variant, now time.Time) {
+	p := c.player
+	estimated := p.EstimatedPositionAt(now)
+	position, hasPosition := -1.0, false
+
+	for name, value := range props {
+		switch v := value.Value().(type) {
+		case string:
+			switch name {
+			case "PlaybackStatus":
+				switch v {
+				case "Playing":
+					if p.State != StatePlaying {
+						p.ActiveAt = now.UnixMilli()
+					}
+					p.State = StatePlaying
+				case "Paused":
+					p.State = StatePaused
+				default:
+					p.State = StateStopped
+				}
+			case "LoopStatus":
+				c.caps["LoopStatus"] = true
+				switch v {
+				case "Track":
+					p.Repeat = RepeatOne
+				case "Playlist":
+					p.Repeat = RepeatAll
+				default:
+					p.Repeat = RepeatNone
+				}
+			}
+		case bool:
+			if name == "Shuffle" {
+				c.caps["Shuffle"] = true
+				p.Shuffle = v
+			} else {
+				c.caps[name] = v
+			}
+		case float64:
+			switch name {
+			case "Volume":
+				c.caps["Volume"] = true
+				p.Volume = int(min(max(v, 0), 1)*100 + 0.5)
+			case "Rate":
+				if v > 0 {
+					p.PlaybackRate = v
+				}
+			}
+		case int64:
+			if name == "Position" {
+				position, hasPosition = float64(v)/1e6, true
+			}
+		case map[string]dbus.Variant:
+			if name == "Metadata" {
+				c.applyMetadata(v)
+			}
+		}
+	}
+
+	p.CanSetState = c.caps["CanPlay"] || c.caps["CanPause"]
+	p.CanSkipNext = c.caps["CanGoNext"]
+	p.CanSkipPrevious = c.caps["CanGoPrevious"]
+	p.CanSetPosition = c.caps["CanSeek"] && c.trackID != ""
+	p.CanSetVolume = c.caps["CanControl"] && c.caps["Volume"]
+	p.CanSetShuffle = c.caps["CanControl"] && c.caps["Shuffle"]
+	p.CanSetRepeat = c.caps["CanControl"] && c.caps["LoopStatus"]
+	p.UpdatedAt = now.UnixMilli()
+
+	if hasPosition {
+		p.Position = int(position)
+		p.RebasePosition(position, now)
+	} else if _, ok := props["PlaybackStatus"]; ok {
+		p.RebasePosition(estimated, now)
+	}
+}
+
+// applyMetadata maps the xesam/mpris track metadata onto the player
+func (c *mprisClient) applyMetadata(metadata map[string]dbus.Variant) {
+	p := c.player
+	p.Title, p.Artist, p.Album, p.Cover, p.Duration = "", "", "", "", 0
+	c.trackID = ""
+
+	for key, value := range metadata {
+		switch key {
+		case "xesam:title":
+			p.Title, _ = value.Value().(string)
+		case "xesam:artist":
+			switch v := value.Value().(type) {
+			case []string:
+				p.Artist = strings.Join(v, ", ")
+			case string:
+				p.Artist = v
+			}
+		case "xesam:album":
+			p.Album, _ = value.Value().(string)
+		case "mpris:artUrl":
+			p.Cover, _ = value.Value().(string)
+		case "mpris:length":
+			switch v := value.Value().(type) {
+			case int64:
+				p.Duration = int(v / 1e6)
+			case uint64:
+				p.Duration = int(v / 1e6)
+			}
+		case "mpris:trackid":
+			switch v := value.Value().(type) {
+			case dbus.ObjectPath:
+				c.trackID = v
+			case string: // some players send a plain string
+				if dbus.ObjectPath(v).IsValid() {
+					c.trackID = dbus.ObjectPath(v)
+				}
+			}
+		}
+	}
+}
+
+// SendEvent translates a WebNowPlaying command into an MPRIS call. MPRIS calls
+// are synchronous, so the returned event ID never gets a result of its own and
+// the change is confirmed by the next property update.
+func (s *MPRISSource) SendEvent(playerID int, command string, data interface{}) (string, error) {
+	s.mu.RLock()
+	client := s.clientByID(playerID)
+	var trackID dbus.ObjectPath
+	if client != nil {
+		trackID = client.trackID
+	}
+	s.mu.RUnlock()
+
+	if client == nil {
+		return "", ErrNoPlayer
+	}
+
+	value, _ := data.(int)
+	var err error
+	switch command {
+	case "STATE":
+		method := "Stop"
+		switch value {
+		case 1:
+			method = "Play"
+		case 2:
+			method = "Pause"
+		}
+		err = client.obj.Call(mprisPlayerIface+"."+method, 0).Err
+	case "SKIP_NEXT":
+		err = client.obj.Call(mprisPlayerIface+".Next", 0).Err
+	case "SKIP_PREVIOUS":
+		err = client.obj.Call(mprisPlayerIface+".Previous", 0).Err
+	case "SHUFFLE":
+		err = client.obj.SetProperty(mprisPlayerIface+".Shuffle", dbus.MakeVariant(value == 1))
+	case "REPEAT":
+		loop := "None"
+		switch RepeatMode(value) {
+		case RepeatAll:
+			loop = "Playlist"
+		case RepeatOne:
+			loop = "Track"
+		}
+		err = client.obj.SetProperty(mprisPlayerIface+".LoopStatus", dbus.MakeVariant(loop))
+	case "POSITION":
+		err = client.obj.Call(mprisPlayerIface+".SetPosition", 0, trackID, int64(value)*1e6).Err
+	case "VOLUME":
+		err = client.obj.SetProperty(mprisPlayerIface+".Volume", dbus.MakeVariant(float64(value)/100))
+	case "RATING":
+		return "", fmt.Errorf("%s: %w", command, ErrNotSupported)
+	default:
+		return "", fmt.Errorf("unknown command: %s", command)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("[MPRIS] Sent %s(%v) to %s", command, data, client.busName)
+	return fmt.Sprintf("mpris_%d", s.eventSeq.Add(1)), nil
+}
@@ -0,0 +1,763 @@
+package media
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMQTTBroker is where an MQTT broker listens by default
+	DefaultMQTTBroker = "localhost:1883"
+	// DefaultMQTTTopicPrefix is the default root of the published topics
+	DefaultMQTTTopicPrefix = "round-sound"
+	// DefaultMQTTDiscoveryPrefix is the Home Assistant discovery prefix
+	DefaultMQTTDiscoveryPrefix = "homeassistant"
+
+	mqttDialTimeout  = 5 * time.Second
+	mqttWriteTimeout = 10 * time.Second
+	mqttKeepAlive    = 30 * time.Second
+	mqttRetryMin     = 2 * time.Second
+	mqttRetryMax     = time.Minute
+	// mqttLevelInterval is how often the averaged audio level is published
+	mqttLevelInterval = time.Second
+	// mqttMaxPacket caps packets read from the broker
+	mqttMaxPacket = 1 << 20
+	// mqttMaxCover skips album art too large to publish
+	mqttMaxCover = 2 << 20
+)
+
+// MQTT control packet types (MQTT 3.1.1)
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttSubscribe  = 8
+	mqttSuback     = 9
+	mqttPingreq    = 12
+	mqttPingresp   = 13
+	mqttDisconnect = 14
+)
+
+// MQTTConfig holds the MQTT broker settings
+type MQTTConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Broker   string `json:"broker"` // host:port
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// TopicPrefix is the root of the state and command topics
+	TopicPrefix string `json:"topicPrefix"`
+	// Discovery announces the entities to Home Assistant under DiscoveryPrefix
+	Discovery       bool   `json:"discovery"`
+	DiscoveryPrefix string `json:"discoveryPrefix"`
+	// Retain keeps the last state on the broker for clients that subscribe later
+	Retain bool `json:"retain"`
+}
+
+// Normalize fills in the default broker and topic prefixes
+func (c MQTTConfig) Normalize() MQTTConfig {
+	c.Broker = strings.TrimSpace(c.Broker)
+	if c.Broker == "" {
+		c.Broker = DefaultMQTTBroker
+	}
+	c.TopicPrefix = strings.Trim(strings.TrimSpace(c.TopicPrefix), "/")
+	if c.TopicPrefix == "" {
+		c.TopicPrefix = DefaultMQTTTopicPrefix
+	}
+	c.DiscoveryPrefix = strings.Trim(strings.TrimSpace(c.DiscoveryPrefix), "/")
+	if c.DiscoveryPrefix == "" {
+		c.DiscoveryPrefix = DefaultMQTTDiscoveryPrefix
+	}
+	return c
+}
+
+// Validate checks the broker address and the topic prefixes
+func (c MQTTConfig) Validate() error {
+	if _, port, err := net.SplitHostPort(c.Broker); err != nil || port == "" {
+		return fmt.Errorf("invalid broker address: %s (expected host:port)", c.Broker)
+	}
+	for _, prefix := range []string{c.TopicPrefix, c.DiscoveryPrefix} {
+		if strings.ContainsAny(prefix, "+#") {
+			return fmt.Errorf("topic prefix must not contain wildcards: %s", prefix)
+		}
+	}
+	return nil
+}
+
+// MQTTStatus describes the broker connection for the settings page
+type MQTTStatus struct {
+	Connected bool   `json:"connected"`
+	LastError string `json:"lastError"`
+}
+
+// MQTTCommand is a message received on a command topic. Name is "" for
+// <prefix>/command (the payload names the action) or the last topic level for
+// <prefix>/command/<name> (e.g. "volume" with the value as payload).
+type MQTTCommand struct {
+	Name    string
+	Payload string
+}
+
+// MQTTCommandCallback is called for every received command
+type MQTTCommandCallback func(cmd MQTTCommand)
+
+// MQTTPublisher publishes the displayed player, sound presence and a level summary
+// to an MQTT broker, announces them to Home Assistant and receives commands.
+//
+// State topics below the prefix: availability (online/offline), state (JSON),
+// playback (playing/paused/idle), title, artist, album, track, player, duration,
+// position, volume (0-100), volume_level (0-1), albumart (base64), sound (ON/OFF)
+// and level (0-100).
+type MQTTPublisher struct {
+	config    MQTTConfig
+	deviceID  string
+	hostname  string
+	covers    *CoverCache
+	onCommand MQTTCommandCallback
+	stopCh    chan struct{}
+	done      chan struct{}
+
+	// Publishing never blocks the caller: the latest payload per topic is kept
+	// and sent by the writer goroutine of the connection
+	mu     sync.Mutex
+	status MQTTStatus
+	// last holds the latest payload per topic, republished after a reconnect
+	last map[string][]byte
+	// pending lists the topics changed since the writer last sent them
+	pending []string
+	wake    chan struct{} // tells the writer that topics are pending
+
+	levelMu    sync.Mutex
+	levelSum   float64
+	levelCount int
+	levelAt    time.Time
+
+	// artCover is the cover art was encoded from; cache files never change, so
+	// the encoding is reused until the cover does
+	artMu    sync.Mutex
+	artCover string
+	art      string
+}
+
+// NewMQTTPublisher starts publishing to the broker in config, reading album art
+// from covers. It keeps reconnecting until stopped, so a broker that is not
+// running yet is picked up later.
+func NewMQTTPublisher(config MQTTConfig, covers *CoverCache, onCommand MQTTCommandCallback) *MQTTPublisher {
+	config = config.Normalize()
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "pc"
+	}
+	deviceID := "round_sound_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(hostname))
+
+	p := &MQTTPublisher{
+		config:    config,
+		deviceID:  deviceID,
+		hostname:  hostname,
+		covers:    covers,
+		onCommand: onCommand,
+		stopCh:    make(chan struct{}),
+		done:      make(chan struct{}),
+		last:      make(map[string][]byte),
+		wake:      make(chan struct{}, 1),
+	}
+	go p.run()
+	return p
+}
+
+// Status returns the connection state
+func (p *MQTTPublisher) Status() MQTTStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// Stop announces the device as offline, disconnects and waits for the loop to end
+func (p *MQTTPublisher) Stop() {
+	close(p.stopCh)
+	<-p.done
+	log.Println("[MQTT] Publisher stopped")
+}
+
+// PublishPlayer publishes the displayed player (nil = nothing is shown)
+func (p *MQTTPublisher) PublishPlayer(player *Player) {
+	now := time.Now()
+	values := map[string]string{
+		"playback":     "idle",
+		"title":        "",
+		"artist":       "",
+		"album":        "",
+		"track":        "",
+		"player":       "",
+		"duration":     "0",
+		"position":     "0",
+		"volume":       "0",
+		"volume_level": "0",
+	}
+	state := []byte("null")
+	if player != nil && player.Title != "" {
+		snapshot := player.Snapshot(now)
+		if data, err := json.Marshal(snapshot); err == nil {
+			state = data
+		}
+
+		switch player.State {
+		case StatePlaying:
+			values["playback"] = "playing"
+		case StatePaused:
+			values["playback"] = "paused"
+		}
+		values["title"] = player.Title
+		values["artist"] = player.Artist
+		values["album"] = player.Album
+		values["track"] = player.Title
+		if player.Artist != "" {
+			values["track"] = player.Artist + " - " + player.Title
+		}
+		values["player"] = player.Name
+		values["duration"] = strconv.Itoa(player.Duration)
+		values["position"] = strconv.Itoa(int(snapshot.EstimatedPosition))
+		values["volume"] = strconv.Itoa(player.Volume)
+		values["volume_level"] = strconv.FormatFloat(float64(player.Volume)/100, 'f', 2, 64)
+	}
+
+	p.publish("state", state)
+	for name, value := range values {
+		p.publish(name, []byte(value))
+	}
+
+	// Album art only for covers we already have; it is cleared otherwise
+	art := ""
+	if player != nil {
+		art = p.albumArt(player)
+	}
+	p.publish("albumart", []byte(art))
+}
+
+// albumArt returns the base64 local cover of a player ("" = none or too large)
+func (p *MQTTPublisher) albumArt(player *Player) string {
+	p.artMu.Lock()
+	defer p.artMu.Unlock()
+
+	if player.Cover != p.artCover {
+		p.artCover = player.Cover
+		p.art = ""
+		if data := p.covers.ReadLocal(player, mqttMaxCover); data != nil {
+			p.art = base64.StdEncoding.EncodeToString(data)
+		}
+	}
+	return p.art
+}
+
+// PublishAudio publishes sound presence at once and the level (0-1) averaged over
+// mqttLevelInterval as 0-100
+func (p *MQTTPublisher) PublishAudio(sounding bool, level float64) {
+	if sounding {
+		p.publish("sound", []byte("ON"))
+	} else {
+		p.publish("sound", []byte("OFF"))
+	}
+
+	p.levelMu.Lock()
+	p.levelSum += level
+	p.levelCount++
+	now := time.Now()
+	if now.Sub(p.levelAt) < mqttLevelInterval {
+		p.levelMu.Unlock()
+		return
+	}
+	average := p.levelSum / float64(p.levelCount)
+	p.levelSum, p.levelCount, p.levelAt = 0, 0, now
+	p.levelMu.Unlock()
+
+	percent := int(math.Round(math.Min(math.Max(average, 0), 1) * 100))
+	p.publish("level", []byte(strconv.Itoa(percent)))
+}
+
+// topic returns the full topic of a state topic name
+func (p *MQTTPublisher) topic(name string) string {
+	return p.config.TopicPrefix + "/" + name
+}
+
+// publish queues payload for a state topic when it changed since the last publish.
+// A topic that changes again before the writer gets to it is sent once.
+func (p *MQTTPublisher) publish(name string, payload []byte) {
+	p.mu.Lock()
+	if last, ok := p.last[name]; ok && string(last) == string(payload) {
+		p.mu.Unlock()
+		return
+	}
+	p.last[name] = payload
+	if !slices.Contains(p.pending, name) {
+		p.pending = append(p.pending, name)
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default: // the writer is already woken up
+	}
+}
+
+// takePending returns the pending topics with their latest payloads
+func (p *MQTTPublisher) takePending() map[string][]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	messages := make(map[string][]byte, len(p.pending))
+	for _, name := range p.pending {
+		messages[name] = p.last[name]
+	}
+	p.pending = p.pending[:0]
+	return messages
+}
+
+// run keeps a connection to the broker until stopped
+func (p *MQTTPublisher) run() {
+	defer close(p.done)
+	reconnectLoop(p.stopCh, newBackoff(mqttRetryMin, mqttRetryMax), p.follow, p.disconnected)
+}
+
+// follow connects, announces the device and reads commands until the connection fails
+func (p *MQTTPublisher) follow() (connected bool, err error) {
+	conn, err := dialMQTT(p.config, "round-sound-"+p.deviceID, p.topic("availability"))
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	select {
+	case <-p.stopCh:
+		return false, nil
+	default:
+	}
+	// Everything published so far goes out with the announcement
+	p.mu.Lock()
+	last := make(map[string][]byte, len(p.last))
+	for name, payload := range p.last {
+		last[name] = payload
+	}
+	p.pending = p.pending[:0]
+	p.mu.Unlock()
+
+	if err := p.announce(conn, last); err != nil {
+		return true, err
+	}
+
+	p.mu.Lock()
+	p.status = MQTTStatus{Connected: true}
+	p.mu.Unlock()
+
+	log.Printf("[MQTT] Connected to %s as %s", p.config.Broker, p.deviceID)
+
+	go p.write(conn)
+	for {
+		header, body, err := conn.readPacket()
+		if err != nil {
+			select {
+			case <-p.stopCh:
+				return true, nil
+			default:
+			}
+			return true, err
+		}
+		if header>>4 == mqttPublish {
+			p.received(conn, header, body)
+		}
+	}
+}
+
+// announce publishes availability, discovery and the last state and subscribes
+// to the command topics
+func (p *MQTTPublisher) announce(conn *mqttConn, last map[string][]byte) error {
+	if err := conn.publish(p.topic("availability"), []byte("online"), true); err != nil {
+		return err
+	}
+	if p.config.Discovery {
+		for topic, config := range p.discovery() {
+			data, err := json.Marshal(config)
+			if err != nil {
+				return err
+			}
+			if err := conn.publish(topic, data, true); err != nil {
+				return err
+			}
+		}
+	}
+	for name, payload := range last {
+		if err := conn.publish(p.topic(name), payload, p.config.Retain); err != nil {
+			return err
+		}
+	}
+	return conn.subscribe(p.topic("command"), p.topic("command/+"))
+}
+
+// write sends pending topics and keep-alive pings while the connection is up.
+// On stop it announces the device as offline and disconnects.
+func (p *MQTTPublisher) write(conn *mqttConn) {
+	ticker := time.NewTicker(mqttKeepAlive / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-conn.closed:
+			return
+		case <-p.stopCh:
+			conn.publish(p.topic("availability"), []byte("offline"), true)
+			conn.writePacket(mqttDisconnect<<4, nil)
+			conn.Close()
+			return
+		case <-ticker.C:
+			if err := conn.writePacket(mqttPingreq<<4, nil); err != nil {
+				conn.Close()
+				return
+			}
+		case <-p.wake:
+			for name, payload := range p.takePending() {
+				if err := conn.publish(p.topic(name), payload, p.config.Retain); err != nil {
+					// The read loop reconnects and republishes everything
+					log.Printf("[MQTT] Failed to publish %s: %v", name, err)
+					conn.Close()
+					return
+				}
+			}
+		}
+	}
+}
+
+// received acknowledges an incoming PUBLISH and passes it on as a command
+func (p *MQTTPublisher) received(conn *mqttConn, header byte, body []byte) {
+	topic, rest, err := mqttReadString(body)
+	if err != nil {
+		return
+	}
+	if qos := (header >> 1) & 3; qos > 0 {
+		if len(rest) < 2 {
+			return
+		}
+		if qos == 1 {
+			conn.writePacket(mqttPuback<<4, rest[:2])
+		}
+		rest = rest[2:]
+	}
+
+	cmd := MQTTCommand{Payload: strings.TrimSpace(string(rest))}
+	if name, ok := strings.CutPrefix(topic, p.topic("command/")); ok {
+		cmd.Name = name
+	} else if topic != p.topic("command") {
+		return
+	}
+	log.Printf("[MQTT] Command %q: %q", cmd.Name, cmd.Payload)
+	if p.onCommand != nil {
+		go p.onCommand(cmd) // commands may take a while; keep reading
+	}
+}
+
+// disconnected records why the connection ended
+func (p *MQTTPublisher) disconnected(err error) {
+	p.mu.Lock()
+	p.status.Connected = false
+	isNew := recordConnError(&p.status.LastError, err)
+	p.mu.Unlock()
+
+	if isNew {
+		log.Printf("[MQTT] Disconnected from %s: %v", p.config.Broker, err)
+	}
+}
+
+// discovery returns the Home Assistant discovery configs by topic: sensors for the
+// track and playback state, a sound binary sensor, a level sensor, a volume number,
+// transport buttons and a media_player for the mqtt_media_player custom integration
+func (p *MQTTPublisher) discovery() map[string]map[string]interface{} {
+	device := map[string]interface{}{
+		"identifiers":  []string{p.deviceID},
+		"name":         "Round Sound (" + p.hostname + ")",
+		"manufacturer": "Round Sound",
+		"model":        "Round Sound widget",
+	}
+	availability := p.topic("availability")
+	command := p.topic("command")
+
+	entity := func(component, object, name string, fields map[string]interface{}) (string, map[string]interface{}) {
+		fields["name"] = name
+		fields["unique_id"] = p.deviceID + "_" + object
+		fields["object_id"] = p.deviceID + "_" + object
+		fields["availability_topic"] = availability
+		fields["device"] = device
+		return fmt.Sprintf("%s/%s/%s/%s/config", p.config.DiscoveryPrefix, component, p.deviceID, object), fields
+	}
+
+	configs := make(map[string]map[string]interface{})
+	add := func(topic string, config map[string]interface{}) {
+		configs[topic] = config
+	}
+
+	add(entity("sensor", "track", "Track", map[string]interface{}{
+		"state_topic":           p.topic("track"),
+		"json_attributes_topic": p.topic("state"),
+		"icon":                  "mdi:music",
+	}))
+	add(entity("sensor", "playback", "Playback", map[string]interface{}{
+		"state_topic": p.topic("playback"),
+		"icon":        "mdi:play-pause",
+	}))
+	add(entity("binary_sensor", "sound", "Sound", map[string]interface{}{
+		"state_topic":  p.topic("sound"),
+		"device_class": "sound",
+	}))
+	add(entity("sensor", "level", "Audio level", map[string]interface{}{
+		"state_topic":         p.topic("level"),
+		"unit_of_measurement": "%",
+		"state_class":         "measurement",
+		"icon":                "mdi:waveform",
+	}))
+	add(entity("number", "volume", "Volume", map[string]interface{}{
+		"state_topic":   p.topic("volume"),
+		"command_topic": p.topic("command/volume"),
+		"min":           0,
+		"max":           100,
+		"step":          1,
+		"icon":          "mdi:volume-high",
+	}))
+	for _, button := range []struct{ action, name, icon string }{
+		{"play_pause", "Play/Pause", "mdi:play-pause"},
+		{"next", "Next", "mdi:skip-next"},
+		{"previous", "Previous", "mdi:skip-previous"},
+	} {
+		add(entity("button", button.action, button.name, map[string]interface{}{
+			"command_topic": command,
+			"payload_press": button.action,
+			"icon":          button.icon,
+		}))
+	}
+
+	add(fmt.Sprintf("%s/media_player/%s/config", p.config.DiscoveryPrefix, p.deviceID), map[string]interface{}{
+		"name":                      "Round Sound (" + p.hostname + ")",
+		"unique_id":                 p.deviceID,
+		"availability":              map[string]interface{}{"topic": availability},
+		"state_state_topic":         p.topic("playback"),
+		"state_title_topic":         p.topic("title"),
+		"state_artist_topic":        p.topic("artist"),
+		"state_album_topic":         p.topic("album"),
+		"state_duration_topic":      p.topic("duration"),
+		"state_position_topic":      p.topic("position"),
+		"state_volume_topic":        p.topic("volume_level"),
+		"state_albumart_topic":      p.topic("albumart"),
+		"command_volume_topic":      p.topic("command/volume_level"),
+		"command_play_topic":        command,
+		"command_play_payload":      "play",
+		"command_pause_topic":       command,
+		"command_pause_payload":     "pause",
+		"command_playpause_topic":   command,
+		"command_playpause_payload": "play_pause",
+		"command_next_topic":        command,
+		"command_next_payload":      "next",
+		"command_previous_topic":    command,
+		"command_previous_payload":  "previous",
+		"device":                    device,
+	})
+	return configs
+}
+
+// mqttConn is a minimal MQTT 3.1.1 client connection: QoS 0 publish and subscribe
+type mqttConn struct {
+	net.Conn
+	reader    *bufio.Reader
+	packetID  uint16
+	writeMu   sync.Mutex // the writer and the read loop (PUBACK) both write
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// dialMQTT connects and logs in; the will marks the device offline if the app dies
+func dialMQTT(config MQTTConfig, clientID, willTopic string) (*mqttConn, error) {
+	raw, err := net.DialTimeout("tcp", config.Broker, mqttDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &mqttConn{Conn: raw, reader: bufio.NewReader(raw), closed: make(chan struct{})}
+
+	flags := byte(0x02 | 0x04 | 0x20) // clean session, will, retained will
+	if config.Username != "" {
+		flags |= 0x80
+		if config.Password != "" {
+			flags |= 0x40
+		}
+	}
+	body := mqttAppendString(nil, "MQTT")
+	body = append(body, 4, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(mqttKeepAlive/time.Second))
+	body = mqttAppendString(body, clientID)
+	body = mqttAppendString(body, willTopic)
+	body = mqttAppendString(body, "offline")
+	if flags&0x80 != 0 {
+		body = mqttAppendString(body, config.Username)
+	}
+	if flags&0x40 != 0 {
+		body = mqttAppendString(body, config.Password)
+	}
+	if err := conn.writePacket(mqttConnect<<4, body); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	header, reply, err := conn.readPacket()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if header>>4 != mqttConnack || len(reply) < 2 {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt: unexpected reply to CONNECT: %#x", header)
+	}
+	if code := reply[1]; code != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt: connection refused: %s", mqttConnackReason(code))
+	}
+	return conn, nil
+}
+
+// mqttConnackReason describes a CONNACK return code
+func mqttConnackReason(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "client identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	}
+	return fmt.Sprintf("code %d", code)
+}
+
+// Close closes the connection once
+func (c *mqttConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		err = c.Conn.Close()
+	})
+	return err
+}
+
+// publish sends a QoS 0 message
+func (c *mqttConn) publish(topic string, payload []byte, retain bool) error {
+	header := byte(mqttPublish << 4)
+	if retain {
+		header |= 1
+	}
+	return c.writePacket(header, append(mqttAppendString(nil, topic), payload...))
+}
+
+// subscribe asks for the topics at QoS 0. The SUBACK is read by the read loop.
+func (c *mqttConn) subscribe(topics ...string) error {
+	c.packetID++
+	if c.packetID == 0 {
+		c.packetID = 1
+	}
+	body := binary.BigEndian.AppendUint16(nil, c.packetID)
+	for _, topic := range topics {
+		body = mqttAppendString(body, topic)
+		body = append(body, 0)
+	}
+	return c.writePacket(mqttSubscribe<<4|0x02, body)
+}
+
+// writePacket writes a packet with its remaining length
+func (c *mqttConn) writePacket(header byte, body []byte) error {
+	packet := []byte{header}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.SetWriteDeadline(time.Now().Add(mqttWriteTimeout))
+	_, err := c.Write(packet)
+	return err
+}
+
+// readPacket reads one packet. The broker must answer within 1.5 keep-alive periods.
+func (c *mqttConn) readPacket() (header byte, body []byte, err error) {
+	c.SetReadDeadline(time.Now().Add(mqttKeepAlive * 3 / 2))
+
+	header, err = c.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		digit, err := c.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7f) * multiplier
+		if digit&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("mqtt: malformed remaining length")
+		}
+		multiplier *= 128
+	}
+	if length > mqttMaxPacket {
+		return 0, nil, fmt.Errorf("mqtt: packet too large: %d bytes", length)
+	}
+	body = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// mqttAppendString appends a length-prefixed UTF-8 string
+func mqttAppendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// mqttReadString reads a length-prefixed string and returns the rest
+func mqttReadString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
@@ -0,0 +1,161 @@
+package media
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+// brokerHook accepts one user and records the CONNECT packets
+type brokerHook struct {
+	mochi.HookBase
+	connects chan packets.Packet
+}
+
+func (h *brokerHook) ID() string { return "test" }
+
+func (h *brokerHook) Provides(b byte) bool {
+	return bytes.Contains([]byte{mochi.OnConnectAuthenticate, mochi.OnACLCheck}, []byte{b})
+}
+
+func (h *brokerHook) OnConnectAuthenticate(cl *mochi.Client, pk packets.Packet) bool {
+	h.connects <- pk
+	return string(pk.Connect.Username) == "user" && string(pk.Connect.Password) == "pass"
+}
+
+func (h *brokerHook) OnACLCheck(cl *mochi.Client, topic string, write bool) bool {
+	return true
+}
+
+// startBroker runs an in-process MQTT broker and returns it with its address
+func startBroker(t *testing.T) (*mochi.Server, *brokerHook, string) {
+	t.Helper()
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	hook := &brokerHook{connects: make(chan packets.Packet, 4)}
+	if err := server.AddHook(hook, nil); err != nil {
+		t.Fatal(err)
+	}
+	tcp := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	if err := server.AddListener(tcp); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server, hook, tcp.Address()
+}
+
+// retained returns the retained payload of a topic ("" = none)
+func retained(server *mochi.Server, topic string) string {
+	for _, pk := range server.Topics.Messages(topic) {
+		return string(pk.Payload)
+	}
+	return ""
+}
+
+func TestMQTTPublisher(t *testing.T) {
+	server, hook, address := startBroker(t)
+
+	commands := make(chan MQTTCommand, 4)
+	config := MQTTConfig{Enabled: true, Broker: address, Username: "user", Password: "pass", Discovery: true, Retain: true}
+	publisher := NewMQTTPublisher(config.Normalize(), NewCoverCache(t.TempDir(), DefaultCoverCacheSize), func(cmd MQTTCommand) {
+		commands <- cmd
+	})
+	stopped := false
+	t.Cleanup(func() {
+		if !stopped {
+			publisher.Stop()
+		}
+	})
+
+	// Published before the connection is up: sent with the announcement
+	publisher.PublishPlayer(&Player{ID: 1, Name: "Spotify", Title: "Song", Artist: "Artist", Album: "Album", State: StatePlaying, Duration: 200, Volume: 40})
+
+	// CONNECT carries the credentials and a retained offline will
+	var connect packets.Packet
+	select {
+	case connect = <-hook.connects:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no CONNECT received")
+	}
+	c := connect.Connect
+	if c.ClientIdentifier != "round-sound-"+publisher.deviceID || !c.Clean || c.Keepalive != 30 {
+		t.Errorf("client %q, clean %v, keep-alive %d", c.ClientIdentifier, c.Clean, c.Keepalive)
+	}
+	if !c.WillFlag || !c.WillRetain || c.WillTopic != "round-sound/availability" || string(c.WillPayload) != "offline" {
+		t.Errorf("will %v %q %q (retain %v)", c.WillFlag, c.WillTopic, c.WillPayload, c.WillRetain)
+	}
+
+	// Retained state and discovery
+	waitFor(t, func() bool { return retained(server, "round-sound/track") == "Artist - Song" })
+	for topic, want := range map[string]string{
+		"round-sound/availability": "online",
+		"round-sound/playback":     "playing",
+		"round-sound/volume":       "40",
+		"round-sound/volume_level": "0.40",
+	} {
+		if got := retained(server, topic); got != want {
+			t.Errorf("%s = %q, want %q", topic, got, want)
+		}
+	}
+	var state Player
+	if err := json.Unmarshal([]byte(retained(server, "round-sound/state")), &state); err != nil || state.Title != "Song" {
+		t.Errorf("state = %+v (%v)", state, err)
+	}
+
+	var volume map[string]interface{}
+	discovery := "homeassistant/number/" + publisher.deviceID + "/volume/config"
+	if err := json.Unmarshal([]byte(retained(server, discovery)), &volume); err != nil {
+		t.Fatalf("%s: %v", discovery, err)
+	}
+	if volume["command_topic"] != "round-sound/command/volume" || volume["availability_topic"] != "round-sound/availability" {
+		t.Errorf("volume discovery = %v", volume)
+	}
+	if retained(server, "homeassistant/media_player/"+publisher.deviceID+"/config") == "" {
+		t.Error("media_player discovery missing")
+	}
+
+	// Nothing shown: the track and the volume are reset
+	publisher.PublishPlayer(nil)
+	waitFor(t, func() bool { return retained(server, "round-sound/playback") == "idle" })
+	waitFor(t, func() bool {
+		return retained(server, "round-sound/track") == "" && retained(server, "round-sound/volume") == "0" &&
+			retained(server, "round-sound/volume_level") == "0" && retained(server, "round-sound/state") == "null"
+	})
+
+	// Commands
+	waitFor(t, func() bool { return len(server.Topics.Subscribers("round-sound/command/volume").Subscriptions) > 0 })
+	for _, want := range []MQTTCommand{{Payload: "next"}, {Name: "volume", Payload: "55"}} {
+		topic := "round-sound/command"
+		if want.Name != "" {
+			topic += "/" + want.Name
+		}
+		if err := server.Publish(topic, []byte(want.Payload), false, 0); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-commands:
+			if got != want {
+				t.Errorf("%s: got %+v, want %+v", topic, got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: no command received", topic)
+		}
+	}
+
+	// Stop marks the device offline
+	publisher.Stop()
+	stopped = true
+	waitFor(t, func() bool { return retained(server, "round-sound/availability") == "offline" })
+}
@@ -0,0 +1,14 @@
+package media
+
+import "errors"
+
+// ErrNotificationsUnsupported is returned where no desktop notification service is known
+var ErrNotificationsUnsupported = errors.New("desktop notifications are only supported on Windows and Linux")
+
+// Notification is a desktop notification about a track
+type Notification struct {
+	Summary string
+	Body    string
+	// ImagePath is a local image shown as the thumbnail ("" = none)
+	ImagePath string
+}
@@ -0,0 +1,67 @@
+//go:build linux
+
+package media
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	notificationsBusName = "org.freedesktop.Notifications"
+	notificationsPath    = "/org/freedesktop/Notifications"
+	// notificationTimeoutMs is how long a track notification stays on screen
+	notificationTimeoutMs = 5000
+)
+
+// DesktopNotifier shows notifications through the freedesktop Notifications service
+type DesktopNotifier struct {
+	conn *dbus.Conn
+
+	mu     sync.Mutex
+	lastID uint32 // replaced by the next notification, so quick skips do not pile up
+}
+
+// NewDesktopNotifier connects to the session bus
+func NewDesktopNotifier() (*DesktopNotifier, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	return &DesktopNotifier{conn: conn}, nil
+}
+
+// Show shows n in place of the previous notification
+func (n *DesktopNotifier) Show(notification Notification) error {
+	hints := map[string]dbus.Variant{
+		"category":  dbus.MakeVariant("x-gnome.music"),
+		"urgency":   dbus.MakeVariant(byte(0)),
+		"transient": dbus.MakeVariant(true),
+	}
+	if notification.ImagePath != "" {
+		hints["image-path"] = dbus.MakeVariant((&url.URL{Scheme: "file", Path: notification.ImagePath}).String())
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var id uint32
+	err := n.conn.Object(notificationsBusName, notificationsPath).Call(
+		notificationsBusName+".Notify", 0,
+		"Round Sound", n.lastID, "audio-x-generic",
+		notification.Summary, notification.Body,
+		[]string{}, hints, int32(notificationTimeoutMs),
+	).Store(&id)
+	if err != nil {
+		return err
+	}
+	n.lastID = id
+	return nil
+}
+
+// Close disconnects from the session bus
+func (n *DesktopNotifier) Close() {
+	n.conn.Close()
+}
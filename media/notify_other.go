@@ -0,0 +1,19 @@
+//go:build !linux && !windows
+
+package media
+
+// DesktopNotifier is a placeholder outside Windows and Linux
+type DesktopNotifier struct{}
+
+// NewDesktopNotifier is not available outside Windows and Linux
+func NewDesktopNotifier() (*DesktopNotifier, error) {
+	return nil, ErrNotificationsUnsupported
+}
+
+// Show does nothing outside Windows and Linux
+func (n *DesktopNotifier) Show(notification Notification) error {
+	return ErrNotificationsUnsupported
+}
+
+// Close does nothing outside Windows and Linux
+func (n *DesktopNotifier) Close() {}
@@ -0,0 +1,60 @@
+//go:build windows
+
+package media
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"git.sr.ht/~jackmordaunt/go-toast/wintoast"
+)
+
+// toastAppID is the AUMID the toasts are shown under. It is registered for the
+// current user on start, so Windows shows "Round Sound" as the sender.
+const toastAppID = "Round Sound"
+
+// toastActivatorGUID is the COM class Windows would call back when a toast is
+// clicked. It only has to be unique to the app.
+const toastActivatorGUID = "{F1B06889-A560-4CDC-8A94-212ADBADDE36}"
+
+// DesktopNotifier shows Windows toast notifications through the Windows Runtime
+type DesktopNotifier struct{}
+
+// NewDesktopNotifier registers the app's AUMID and returns the toast notifier
+func NewDesktopNotifier() (*DesktopNotifier, error) {
+	err := wintoast.SetAppData(wintoast.AppData{
+		AppID: toastAppID,
+		GUID:  toastActivatorGUID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("register toast app ID: %w", err)
+	}
+	return &DesktopNotifier{}, nil
+}
+
+// Show shows n as a toast
+func (n *DesktopNotifier) Show(notification Notification) error {
+	var image string
+	if notification.ImagePath != "" {
+		u := url.URL{Scheme: "file", Path: "/" + filepath.ToSlash(notification.ImagePath)}
+		image = fmt.Sprintf(`<image placement="appLogoOverride" src="%s"/>`, xmlEscape(u.String()))
+	}
+	toast := fmt.Sprintf(
+		`<toast duration="short"><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text>%s</binding></visual><audio silent="true"/></toast>`,
+		xmlEscape(notification.Summary), xmlEscape(notification.Body), image,
+	)
+	return wintoast.Push(toast)
+}
+
+// Close does nothing for toasts
+func (n *DesktopNotifier) Close() {}
+
+// xmlEscape escapes s for XML text and attribute values
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,258 @@
+package media
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultOSCHost is where OSC packets go by default (software on the same PC)
+	DefaultOSCHost = "127.0.0.1"
+	// DefaultOSCPort is the default OSC input port of Resolume
+	DefaultOSCPort = 7000
+	// DefaultOSCRateHz is the default rate of band and RMS messages
+	DefaultOSCRateHz = 30
+	// MaxOSCRateHz caps the rate of band and RMS messages
+	MaxOSCRateHz = 120
+
+	// oscBeatBands is how many of the lowest bands count as bass for beat detection
+	oscBeatBands = 6
+	// oscBeatRatio is how far bass energy must rise above its running average
+	oscBeatRatio = 1.4
+	// oscBeatFloor ignores "beats" in very quiet passages
+	oscBeatFloor = 0.08
+	// oscBeatGap caps the beat rate at 300 BPM
+	oscBeatGap = 200 * time.Millisecond
+	// oscBeatSmoothing is the weight of a new frame in the running bass average
+	oscBeatSmoothing = 0.05
+)
+
+// OSCAddresses are the address patterns of the sent messages. An empty pattern
+// turns its message off.
+type OSCAddresses struct {
+	// Bands gets every band level (0-1) as float arguments of one message
+	Bands string `json:"bands"`
+	// Band gets one message per band; {n} is replaced with the band number from 1
+	Band string `json:"band"`
+	// RMS gets the RMS of the analysed samples (0-1)
+	RMS string `json:"rms"`
+	// Beat gets 1.0 on each detected bass beat
+	Beat string `json:"beat"`
+	// Track gets title, artist, album, player name and duration (s) on every track change
+	Track string `json:"track"`
+	// Playing gets 1.0 while the displayed player plays and 0.0 otherwise
+	Playing string `json:"playing"`
+}
+
+// OSCConfig holds the OSC output settings
+type OSCConfig struct {
+	Enabled bool   `json:"enabled"`
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	// RateHz limits band and RMS messages per second (0 = 30); beats and track changes are sent at once
+	RateHz    int          `json:"rateHz"`
+	Addresses OSCAddresses `json:"addresses"`
+}
+
+// DefaultOSCAddresses returns the address patterns of a new config
+func DefaultOSCAddresses() OSCAddresses {
+	return OSCAddresses{
+		Bands:   "/roundsound/bands",
+		RMS:     "/roundsound/rms",
+		Beat:    "/roundsound/beat",
+		Track:   "/roundsound/track",
+		Playing: "/roundsound/playing",
+	}
+}
+
+// Normalize fills in the default host, port and rate. Addresses are kept as
+// they are, so turning every message off sticks.
+func (c OSCConfig) Normalize() OSCConfig {
+	c.Host = strings.TrimSpace(c.Host)
+	if c.Host == "" {
+		c.Host = DefaultOSCHost
+	}
+	if c.Port == 0 {
+		c.Port = DefaultOSCPort
+	}
+	if c.RateHz <= 0 {
+		c.RateHz = DefaultOSCRateHz
+	}
+	c.RateHz = min(c.RateHz, MaxOSCRateHz)
+	return c
+}
+
+// Validate checks the port and the address patterns
+func (c OSCConfig) Validate() error {
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("invalid OSC port: %d", c.Port)
+	}
+	addresses := c.Addresses
+	for _, address := range []string{addresses.Bands, addresses.Band, addresses.RMS, addresses.Beat, addresses.Track, addresses.Playing} {
+		if address != "" && (!strings.HasPrefix(address, "/") || strings.ContainsAny(address, " #*,?[]")) {
+			return fmt.Errorf("invalid OSC address: %q", address)
+		}
+	}
+	return nil
+}
+
+// OSCSender forwards band levels, RMS, beats and track changes to VJ and lighting
+// software over OSC (UDP)
+type OSCSender struct {
+	config OSCConfig
+	conn   net.Conn
+
+	mu       sync.Mutex
+	lastSent time.Time
+	bassAvg  float64
+	lastBeat time.Time
+	track    string
+	playing  int // -1 = not sent yet
+}
+
+// NewOSCSender resolves the target and prepares the UDP socket
+func NewOSCSender(config OSCConfig) (*OSCSender, error) {
+	config = config.Normalize()
+	conn, err := net.Dial("udp", net.JoinHostPort(config.Host, strconv.Itoa(config.Port)))
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[OSC] Sending to %s:%d at %d Hz", config.Host, config.Port, config.RateHz)
+	return &OSCSender{config: config, conn: conn, playing: -1}, nil
+}
+
+// Close closes the socket
+func (s *OSCSender) Close() {
+	s.conn.Close()
+}
+
+// SendLevels sends a beat at once when frame has one, and the bands and RMS at most RateHz times per second
+func (s *OSCSender) SendLevels(frame LevelFrame) {
+	levels, rms := frame.Levels, frame.RMS
+	if frame.Silence {
+		levels, rms = make([]float32, len(frame.Levels)), 0 // silence frames carry idle levels
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	beat := s.detectBeat(levels, now)
+	due := now.Sub(s.lastSent) >= time.Second/time.Duration(s.config.RateHz)
+	if due {
+		s.lastSent = now
+	}
+	s.mu.Unlock()
+
+	addresses := s.config.Addresses
+	if beat && addresses.Beat != "" {
+		s.send(addresses.Beat, float32(1))
+	}
+	if !due {
+		return
+	}
+
+	if addresses.Bands != "" {
+		args := make([]interface{}, len(levels))
+		for i, level := range levels {
+			args[i] = level
+		}
+		s.send(addresses.Bands, args...)
+	}
+	if addresses.Band != "" {
+		for i, level := range levels {
+			s.send(strings.ReplaceAll(addresses.Band, "{n}", strconv.Itoa(i+1)), level)
+		}
+	}
+	if addresses.RMS != "" {
+		s.send(addresses.RMS, rms)
+	}
+}
+
+// detectBeat reports a beat when the bass energy jumps above its running average. Caller must hold mu.
+func (s *OSCSender) detectBeat(levels []float32, now time.Time) bool {
+	n := min(oscBeatBands, len(levels))
+	if n == 0 {
+		return false
+	}
+	var bass float64
+	for _, level := range levels[:n] {
+		bass += float64(level)
+	}
+	bass /= float64(n)
+
+	beat := bass > oscBeatFloor && bass > s.bassAvg*oscBeatRatio && now.Sub(s.lastBeat) >= oscBeatGap
+	if beat {
+		s.lastBeat = now
+	}
+	s.bassAvg += (bass - s.bassAvg) * oscBeatSmoothing
+	return beat
+}
+
+// SendPlayer sends the track and the playing state when they changed (nil = nothing is shown)
+func (s *OSCSender) SendPlayer(player *Player) {
+	track, playing := "", 0
+	if player != nil && player.Title != "" {
+		track = strings.Join([]string{player.Title, player.Artist, player.Album, player.Name}, "\x00")
+		if player.State == StatePlaying {
+			playing = 1
+		}
+	}
+
+	s.mu.Lock()
+	trackChanged := track != s.track
+	playingChanged := playing != s.playing
+	s.track, s.playing = track, playing
+	s.mu.Unlock()
+
+	addresses := s.config.Addresses
+	if trackChanged && addresses.Track != "" {
+		if player != nil && track != "" {
+			s.send(addresses.Track, player.Title, player.Artist, player.Album, player.Name, int32(player.Duration))
+		} else {
+			s.send(addresses.Track, "", "", "", "", int32(0))
+		}
+	}
+	if playingChanged && addresses.Playing != "" {
+		s.send(addresses.Playing, float32(playing))
+	}
+}
+
+// send encodes and sends one OSC message; UDP errors (nobody listening) are ignored
+func (s *OSCSender) send(address string, args ...interface{}) {
+	s.conn.Write(encodeOSCMessage(address, args...))
+}
+
+// encodeOSCMessage encodes an OSC 1.0 message with float32, int32 and string arguments
+func encodeOSCMessage(address string, args ...interface{}) []byte {
+	tags := []byte{','}
+	var data []byte
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case float32:
+			tags = append(tags, 'f')
+			data = binary.BigEndian.AppendUint32(data, math.Float32bits(v))
+		case int32:
+			tags = append(tags, 'i')
+			data = binary.BigEndian.AppendUint32(data, uint32(v))
+		case string:
+			tags = append(tags, 's')
+			data = appendOSCString(data, v)
+		}
+	}
+
+	msg := appendOSCString(nil, address)
+	msg = appendOSCString(msg, string(tags))
+	return append(msg, data...)
+}
+
+// appendOSCString appends a NUL-terminated string padded to 4 bytes
+func appendOSCString(b []byte, s string) []byte {
+	b = append(b, s...)
+	return append(b, make([]byte, 4-len(s)%4)...)
+}
@@ -0,0 +1,237 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// oscMessage is a decoded OSC message
+type oscMessage struct {
+	Address string
+	Args    []interface{}
+}
+
+// decodeOSC decodes an OSC 1.0 message, checking the padding of every field
+func decodeOSC(t *testing.T, packet []byte) oscMessage {
+	t.Helper()
+	if len(packet)%4 != 0 {
+		t.Fatalf("packet of %d bytes is not 4-byte aligned: %q", len(packet), packet)
+	}
+	readString := func() string {
+		end := bytes.IndexByte(packet, 0)
+		if end < 0 {
+			t.Fatalf("unterminated string in %q", packet)
+		}
+		s := string(packet[:end])
+		size := (end/4 + 1) * 4
+		if size > len(packet) || !bytes.Equal(packet[end:size], make([]byte, size-end)) {
+			t.Fatalf("string %q is not NUL padded: %q", s, packet)
+		}
+		packet = packet[size:]
+		return s
+	}
+
+	msg := oscMessage{Address: readString()}
+	tags := readString()
+	if len(tags) == 0 || tags[0] != ',' {
+		t.Fatalf("type tags %q", tags)
+	}
+	for _, tag := range tags[1:] {
+		switch tag {
+		case 'f':
+			msg.Args = append(msg.Args, math.Float32frombits(binary.BigEndian.Uint32(packet)))
+			packet = packet[4:]
+		case 'i':
+			msg.Args = append(msg.Args, int32(binary.BigEndian.Uint32(packet)))
+			packet = packet[4:]
+		case 's':
+			msg.Args = append(msg.Args, readString())
+		default:
+			t.Fatalf("unknown type tag %q", tag)
+		}
+	}
+	if len(packet) != 0 {
+		t.Fatalf("%d bytes after the arguments", len(packet))
+	}
+	return msg
+}
+
+func TestEncodeOSCMessage(t *testing.T) {
+	got := encodeOSCMessage("/abc", float32(1), int32(-2), "hey", "four")
+	want := []byte("/abc\x00\x00\x00\x00" + // a 4-byte string still gets a NUL
+		",fiss\x00\x00\x00" +
+		"\x3f\x80\x00\x00" +
+		"\xff\xff\xff\xfe" +
+		"hey\x00" +
+		"four\x00\x00\x00\x00")
+	if !bytes.Equal(got, want) {
+		t.Errorf("encoded\n%q\nwant\n%q", got, want)
+	}
+
+	if got := encodeOSCMessage("/x"); !bytes.Equal(got, []byte("/x\x00\x00,\x00\x00\x00")) {
+		t.Errorf("no arguments: %q", got)
+	}
+}
+
+// oscListener receives the packets of an OSCSender
+type oscListener struct {
+	conn *net.UDPConn
+}
+
+func newOSCListener(t *testing.T) (*oscListener, int) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &oscListener{conn: conn}, conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// next waits for the next message
+func (l *oscListener) next(t *testing.T) oscMessage {
+	t.Helper()
+	buf := make([]byte, 2048)
+	l.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := l.conn.Read(buf)
+	if err != nil {
+		t.Fatalf("no OSC message: %v", err)
+	}
+	return decodeOSC(t, buf[:n])
+}
+
+// none checks that nothing arrives for a moment
+func (l *oscListener) none(t *testing.T) {
+	t.Helper()
+	buf := make([]byte, 2048)
+	l.conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, err := l.conn.Read(buf); err == nil {
+		t.Errorf("unexpected message %+v", decodeOSC(t, buf[:n]))
+	}
+}
+
+func TestOSCSender(t *testing.T) {
+	listener, port := newOSCListener(t)
+	sender, err := NewOSCSender(OSCConfig{
+		Host:   "127.0.0.1",
+		Port:   port,
+		RateHz: 10,
+		Addresses: OSCAddresses{
+			Bands:   "/rs/bands",
+			Band:    "/rs/band/{n}/level",
+			RMS:     "/rs/rms",
+			Beat:    "/rs/beat",
+			Track:   "/rs/track",
+			Playing: "/rs/playing",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sender.Close)
+
+	// The first loud frame is a beat, then bands, one message per band and RMS
+	sender.SendLevels(LevelFrame{Levels: []float32{0.5, 0.25}, RMS: 0.125})
+	want := []oscMessage{
+		{"/rs/beat", []interface{}{float32(1)}},
+		{"/rs/bands", []interface{}{float32(0.5), float32(0.25)}},
+		{"/rs/band/1/level", []interface{}{float32(0.5)}},
+		{"/rs/band/2/level", []interface{}{float32(0.25)}},
+		{"/rs/rms", []interface{}{float32(0.125)}},
+	}
+	for _, w := range want {
+		if got := listener.next(t); !reflect.DeepEqual(got, w) {
+			t.Errorf("got %+v, want %+v", got, w)
+		}
+	}
+
+	// Within 1/RateHz nothing more is sent
+	sender.SendLevels(LevelFrame{Levels: []float32{0.5, 0.25}, RMS: 0.125})
+	listener.none(t)
+
+	// Silence sends zero levels once the interval has passed
+	time.Sleep(100 * time.Millisecond)
+	sender.SendLevels(LevelFrame{Levels: []float32{0.5, 0.25}, RMS: 0.5, Silence: true})
+	if got := listener.next(t); got.Address != "/rs/bands" || !reflect.DeepEqual(got.Args, []interface{}{float32(0), float32(0)}) {
+		t.Errorf("silence bands = %+v", got)
+	}
+	listener.next(t)
+	listener.next(t)
+	if got := listener.next(t); got.Address != "/rs/rms" || got.Args[0] != float32(0) {
+		t.Errorf("silence RMS = %+v", got)
+	}
+
+	// Track and playing state are sent on change only
+	player := &Player{Name: "Spotify", Title: "Song", Artist: "Artist", Album: "Album", State: StatePlaying, Duration: 200}
+	sender.SendPlayer(player)
+	if got, w := listener.next(t), (oscMessage{"/rs/track", []interface{}{"Song", "Artist", "Album", "Spotify", int32(200)}}); !reflect.DeepEqual(got, w) {
+		t.Errorf("track = %+v, want %+v", got, w)
+	}
+	if got := listener.next(t); got.Address != "/rs/playing" || got.Args[0] != float32(1) {
+		t.Errorf("playing = %+v", got)
+	}
+	sender.SendPlayer(player.Clone())
+	listener.none(t)
+
+	sender.SendPlayer(nil)
+	if got, w := listener.next(t), (oscMessage{"/rs/track", []interface{}{"", "", "", "", int32(0)}}); !reflect.DeepEqual(got, w) {
+		t.Errorf("cleared track = %+v, want %+v", got, w)
+	}
+	if got := listener.next(t); got.Address != "/rs/playing" || got.Args[0] != float32(0) {
+		t.Errorf("cleared playing = %+v", got)
+	}
+}
+
+func TestOSCSenderSkipsEmptyAddresses(t *testing.T) {
+	listener, port := newOSCListener(t)
+	sender, err := NewOSCSender(OSCConfig{Host: "127.0.0.1", Port: port, Addresses: OSCAddresses{RMS: "/rs/rms"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sender.Close)
+
+	sender.SendLevels(LevelFrame{Levels: []float32{0.9, 0.9}, RMS: 0.5})
+	sender.SendPlayer(&Player{Title: "Song", State: StatePlaying})
+	if got := listener.next(t); got.Address != "/rs/rms" {
+		t.Errorf("got %+v", got)
+	}
+	listener.none(t)
+}
+
+func TestOSCDetectBeat(t *testing.T) {
+	var s OSCSender
+	start := time.Unix(1000, 0)
+	quiet := []float32{0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.9} // the 7th band is not bass
+	loud := []float32{0.3, 0.3, 0.3, 0.3, 0.3, 0.3, 0}
+
+	// Steady bass builds up the average without beats
+	s.bassAvg = 0.1
+	for i := 0; i < 20; i++ {
+		if s.detectBeat(quiet, start.Add(time.Duration(i)*10*time.Millisecond)) {
+			t.Fatalf("beat on steady bass at frame %d", i)
+		}
+	}
+
+	steps := []struct {
+		levels []float32
+		at     time.Duration
+		beat   bool
+	}{
+		{loud, time.Second, true},
+		{loud, time.Second + 100*time.Millisecond, false},    // inside the 200 ms gap
+		{quiet, 2 * time.Second, false},                      // below the average
+		{loud, 2*time.Second + 10*time.Millisecond, true},    // the average is still low
+		{[]float32{0.05, 0.05}, 3 * time.Second, false},      // under the floor
+		{nil, 4 * time.Second, false},                        // no bands
+		{[]float32{1, 1, 1, 1, 1, 1}, 5 * time.Second, true}, // a big jump
+	}
+	for i, step := range steps {
+		if got := s.detectBeat(step.levels, start.Add(step.at)); got != step.beat {
+			t.Errorf("step %d: beat %v, want %v (average %.3f)", i, got, step.beat, s.bassAvg)
+		}
+	}
+}
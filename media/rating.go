@@ -0,0 +1,78 @@
+package media
+
+import "fmt"
+
+// Rating values as sent and reported by WebNowPlaying
+const (
+	RatingValueNone    = 0
+	RatingValueDislike = 1
+	RatingValueLike    = 5
+	RatingValueMaxStar = 5
+)
+
+// IsLiked reports whether the current track is liked (or rated 5 stars on a scale)
+func (p *Player) IsLiked() bool {
+	return p.Rating == RatingValueLike
+}
+
+// IsDisliked reports whether the current track is disliked
+func (p *Player) IsDisliked() bool {
+	return p.RatingSystem == RatingLikeDislike && p.Rating == RatingValueDislike
+}
+
+// RatingFor maps a rating command onto the player's rating system and returns the
+// WebNowPlaying rating value to send:
+//   - RatingLike: like (5) and clear (0)
+//   - RatingLikeDislike: like (5), dislike (1) and clear (0)
+//   - RatingScale: 0-5 stars; like is 5 stars, dislike is 1 star
+//
+// Star ratings are only accepted by RatingScale players.
+// Players without a rating system, or commands the system cannot express, yield ErrNotSupported.
+func RatingFor(player *Player, cmd Command) (int, error) {
+	if !player.CanSetRating || player.RatingSystem == RatingNone {
+		return 0, fmt.Errorf("%s: %w", cmd.Kind, ErrNotSupported)
+	}
+
+	var rating int
+	switch cmd.Kind {
+	case CommandLike:
+		rating = RatingValueLike
+	case CommandDislike:
+		rating = RatingValueDislike
+	case CommandClearRating:
+		rating = RatingValueNone
+	case CommandToggleLike:
+		rating = RatingValueLike
+		if player.IsLiked() {
+			rating = RatingValueNone
+		}
+	case CommandSetStars, CommandSetRating:
+		if cmd.Kind == CommandSetStars && player.RatingSystem != RatingScale {
+			return 0, fmt.Errorf("%s: %w", cmd.Kind, ErrNotSupported)
+		}
+		if cmd.Value < 0 || cmd.Value > RatingValueMaxStar {
+			return 0, fmt.Errorf("invalid rating: %d", cmd.Value)
+		}
+		rating = cmd.Value
+	default:
+		return 0, fmt.Errorf("unknown rating command: %s", cmd.Kind)
+	}
+
+	if !ratingAllowed(player.RatingSystem, rating) {
+		return 0, fmt.Errorf("%s(%d) with rating system %d: %w", cmd.Kind, rating, player.RatingSystem, ErrNotSupported)
+	}
+	return rating, nil
+}
+
+// ratingAllowed reports whether the rating system can express the value
+func ratingAllowed(system RatingSystem, rating int) bool {
+	switch system {
+	case RatingLike:
+		return rating == RatingValueNone || rating == RatingValueLike
+	case RatingLikeDislike:
+		return rating == RatingValueNone || rating == RatingValueDislike || rating == RatingValueLike
+	case RatingScale:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package media
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRatingFor(t *testing.T) {
+	const (
+		ok          = ""
+		unsupported = "unsupported"
+		invalid     = "invalid"
+	)
+	tests := []struct {
+		system  RatingSystem
+		current int // rating of the track before the command
+		cmd     Command
+		want    int
+		err     string
+	}{
+		// Like only
+		{RatingLike, 0, Command{Kind: CommandLike}, RatingValueLike, ok},
+		{RatingLike, 0, Command{Kind: CommandDislike}, 0, unsupported},
+		{RatingLike, 5, Command{Kind: CommandClearRating}, RatingValueNone, ok},
+		{RatingLike, 0, Command{Kind: CommandToggleLike}, RatingValueLike, ok},
+		{RatingLike, 5, Command{Kind: CommandToggleLike}, RatingValueNone, ok},
+		{RatingLike, 0, Command{Kind: CommandSetStars, Value: 5}, 0, unsupported},
+		{RatingLike, 0, Command{Kind: CommandSetRating, Value: 5}, 5, ok},
+		{RatingLike, 0, Command{Kind: CommandSetRating, Value: 3}, 0, unsupported},
+
+		// Like and dislike
+		{RatingLikeDislike, 0, Command{Kind: CommandLike}, RatingValueLike, ok},
+		{RatingLikeDislike, 0, Command{Kind: CommandDislike}, RatingValueDislike, ok},
+		{RatingLikeDislike, 1, Command{Kind: CommandClearRating}, RatingValueNone, ok},
+		{RatingLikeDislike, 1, Command{Kind: CommandToggleLike}, RatingValueLike, ok},
+		{RatingLikeDislike, 5, Command{Kind: CommandToggleLike}, RatingValueNone, ok},
+		{RatingLikeDislike, 0, Command{Kind: CommandSetStars, Value: 1}, 0, unsupported},
+		{RatingLikeDislike, 0, Command{Kind: CommandSetRating, Value: 1}, 1, ok},
+		{RatingLikeDislike, 0, Command{Kind: CommandSetRating, Value: 3}, 0, unsupported},
+
+		// Stars
+		{RatingScale, 0, Command{Kind: CommandLike}, 5, ok},
+		{RatingScale, 0, Command{Kind: CommandDislike}, 1, ok},
+		{RatingScale, 4, Command{Kind: CommandClearRating}, 0, ok},
+		{RatingScale, 4, Command{Kind: CommandToggleLike}, 5, ok},
+		{RatingScale, 5, Command{Kind: CommandToggleLike}, 0, ok},
+		{RatingScale, 0, Command{Kind: CommandSetStars, Value: 3}, 3, ok},
+		{RatingScale, 3, Command{Kind: CommandSetStars, Value: 0}, 0, ok},
+		{RatingScale, 0, Command{Kind: CommandSetStars, Value: 6}, 0, invalid},
+		{RatingScale, 0, Command{Kind: CommandSetStars, Value: -1}, 0, invalid},
+		{RatingScale, 0, Command{Kind: CommandSetRating, Value: 2}, 2, ok},
+		{RatingScale, 0, Command{Kind: CommandPlay}, 0, invalid},
+
+		// No rating system
+		{RatingNone, 0, Command{Kind: CommandLike}, 0, unsupported},
+		{RatingNone, 0, Command{Kind: CommandDislike}, 0, unsupported},
+		{RatingNone, 0, Command{Kind: CommandClearRating}, 0, unsupported},
+		{RatingNone, 0, Command{Kind: CommandSetStars, Value: 3}, 0, unsupported},
+	}
+	for _, tt := range tests {
+		player := &Player{CanSetRating: true, RatingSystem: tt.system, Rating: tt.current}
+		got, err := RatingFor(player, tt.cmd)
+		switch {
+		case tt.err == ok && (err != nil || got != tt.want):
+			t.Errorf("system %d, rated %d, %s = %d, %v; want %d", tt.system, tt.current, tt.cmd, got, err, tt.want)
+		case tt.err == unsupported && !errors.Is(err, ErrNotSupported):
+			t.Errorf("system %d, %s error = %v, want ErrNotSupported", tt.system, tt.cmd, err)
+		case tt.err == invalid && (err == nil || errors.Is(err, ErrNotSupported)):
+			t.Errorf("system %d, %s error = %v, want an invalid command", tt.system, tt.cmd, err)
+		}
+	}
+
+	// A player that cannot set ratings supports none, whatever its system
+	for _, kind := range []CommandKind{CommandLike, CommandDislike, CommandClearRating, CommandToggleLike, CommandSetStars} {
+		player := &Player{RatingSystem: RatingScale}
+		if _, err := RatingFor(player, Command{Kind: kind, Value: 3}); !errors.Is(err, ErrNotSupported) {
+			t.Errorf("%s without CanSetRating: %v", kind, err)
+		}
+	}
+}
+
+func TestPlayerRatingState(t *testing.T) {
+	tests := []struct {
+		system          RatingSystem
+		rating          int
+		liked, disliked bool
+	}{
+		{RatingLike, 5, true, false},
+		{RatingLike, 0, false, false},
+		{RatingLikeDislike, 1, false, true},
+		{RatingLikeDislike, 5, true, false},
+		{RatingScale, 1, false, false}, // one star is no dislike
+		{RatingScale, 5, true, false},
+	}
+	for _, tt := range tests {
+		p := &Player{RatingSystem: tt.system, Rating: tt.rating}
+		if p.IsLiked() != tt.liked || p.IsDisliked() != tt.disliked {
+			t.Errorf("system %d, rating %d: liked %v, disliked %v", tt.system, tt.rating, p.IsLiked(), p.IsDisliked())
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package media
+
+import "time"
+
+// backoff is the delay between reconnect attempts. It doubles after every failed
+// attempt up to max and starts over at min once a connection was established.
+type backoff struct {
+	min, max time.Duration
+	next     time.Duration
+}
+
+func newBackoff(min, max time.Duration) backoff {
+	return backoff{min: min, max: max, next: min}
+}
+
+// failed returns the delay before the next attempt and doubles the one after it
+func (b *backoff) failed() time.Duration {
+	delay := b.next
+	b.next = min(b.next*2, b.max)
+	return delay
+}
+
+// reset starts over after a successful connection
+func (b *backoff) reset() {
+	b.next = b.min
+}
+
+// reconnectLoop keeps a connection to a server until stop is closed. follow
+// connects and returns once the connection ended, reporting whether it was
+// established; disconnected records why it ended.
+func reconnectLoop(stop <-chan struct{}, retry backoff, follow func() (connected bool, err error), disconnected func(err error)) {
+	for {
+		connected, err := follow()
+		disconnected(err)
+		delay := retry.min
+		if connected {
+			retry.reset()
+		} else {
+			delay = retry.failed()
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// recordConnError stores err as the last error of a connection status and reports
+// whether it is new, so a failing server is logged once, not on every retry
+func recordConnError(lastError *string, err error) bool {
+	if err == nil {
+		return false
+	}
+	repeated := err.Error() == *lastError
+	*lastError = err.Error()
+	return !repeated
+}
@@ -0,0 +1,358 @@
+package media
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// WAV format tags used for recordings
+const (
+	wavFormatPCM        = 1
+	wavFormatIEEEFloat  = 3
+	wavFormatExtensible = 0xFFFE
+
+	wavHeaderSize = 44
+	// wavMaxDataBytes is the most PCM a WAV file can describe: the RIFF size
+	// (36 + data) is a uint32
+	wavMaxDataBytes = math.MaxUint32 - 36
+)
+
+// Recording sidecar entry types
+const (
+	recordTypeHeader = "header"
+	recordTypePacket = "packet"
+	recordTypeFrame  = "frame"
+)
+
+// pcmFormat describes raw captured PCM as it is written to the WAV file
+type pcmFormat struct {
+	FormatTag     uint16 `json:"formatTag"`
+	Channels      uint16 `json:"channels"`
+	SampleRate    uint32 `json:"sampleRate"`
+	BitsPerSample uint16 `json:"bitsPerSample"`
+	BlockAlign    uint16 `json:"blockAlign"`
+}
+
+// PlayerMeta is the subset of Player stored alongside every recorded frame
+type PlayerMeta struct {
+	ID       int       `json:"id"`
+	Name     string    `json:"name"`
+	Title    string    `json:"title"`
+	Artist   string    `json:"artist"`
+	Album    string    `json:"album"`
+	State    StateMode `json:"state"`
+	Position int       `json:"position"`
+	Duration int       `json:"duration"`
+}
+
+// recordEntry is one JSONL line of the recording sidecar
+type recordEntry struct {
+	Type string `json:"type"`
+	// T is the offset from the start of the recording in microseconds
+	T int64 `json:"t"`
+
+	// header
+	StartedAt int64      `json:"startedAt,omitempty"`
+	DeviceID  string     `json:"deviceId,omitempty"`
+	Format    *pcmFormat `json:"format,omitempty"`
+	FFT       *FFTConfig `json:"fft,omitempty"`
+
+	// packet
+	Frames         uint32 `json:"frames,omitempty"`
+	Flags          uint32 `json:"flags,omitempty"`
+	DevicePosition uint64 `json:"devicePosition,omitempty"`
+	QPCPosition    uint64 `json:"qpcPosition,omitempty"`
+
+	// frame
+	Frame  *LevelFrame `json:"frame,omitempty"`
+	Player *PlayerMeta `json:"player,omitempty"`
+}
+
+// RecordingStatus describes the current (or last) recording
+type RecordingStatus struct {
+	Active      bool    `json:"active"`
+	WAVPath     string  `json:"wavPath"`
+	FramesPath  string  `json:"framesPath"`
+	Seconds     float64 `json:"seconds"`
+	MaxSeconds  float64 `json:"maxSeconds"`
+	Packets     int     `json:"packets"`
+	LevelFrames int     `json:"levelFrames"`
+	Error       string  `json:"error"`
+}
+
+// capturedPacket is a raw WASAPI packet handed to the recorder
+type capturedPacket struct {
+	format         pcmFormat
+	deviceID       string
+	fft            FFTConfig
+	data           []byte // nil for silent packets
+	frames         uint32
+	flags          uint32
+	devicePosition uint64
+	qpcPosition    uint64
+}
+
+// Recorder writes captured loopback PCM to a WAV file and the emitted level frames
+// (with the current player metadata) to a JSONL sidecar next to it.
+// The pair can be replayed with NewFileAudioCapture.
+type Recorder struct {
+	mu          sync.Mutex
+	wavFile     *os.File
+	jsonFile    *os.File
+	jsonWriter  *bufio.Writer
+	encoder     *json.Encoder
+	format      *pcmFormat
+	dataBytes   uint32
+	startedAt   time.Time
+	maxDuration time.Duration
+	playerInfo  func() *Player
+	status      RecordingStatus
+	done        chan struct{}
+}
+
+// NewRecorder creates <basePath>.wav and <basePath>.jsonl. Recording stops by itself
+// once maxDuration of audio has been written, or earlier when the WAV file would
+// outgrow its 4 GiB size fields.
+func NewRecorder(basePath string, maxDuration time.Duration, playerInfo func() *Player) (*Recorder, error) {
+	wavPath := basePath + ".wav"
+	framesPath := basePath + ".jsonl"
+
+	wavFile, err := os.Create(wavPath)
+	if err != nil {
+		return nil, fmt.Errorf("create wav: %w", err)
+	}
+	// Placeholder header, patched with real sizes on Close
+	if _, err := wavFile.Write(make([]byte, wavHeaderSize)); err != nil {
+		wavFile.Close()
+		return nil, fmt.Errorf("write wav header: %w", err)
+	}
+
+	jsonFile, err := os.Create(framesPath)
+	if err != nil {
+		wavFile.Close()
+		return nil, fmt.Errorf("create frames sidecar: %w", err)
+	}
+
+	r := &Recorder{
+		wavFile:     wavFile,
+		jsonFile:    jsonFile,
+		jsonWriter:  bufio.NewWriter(jsonFile),
+		startedAt:   time.Now(),
+		maxDuration: maxDuration,
+		playerInfo:  playerInfo,
+		done:        make(chan struct{}),
+		status: RecordingStatus{
+			Active:     true,
+			WAVPath:    wavPath,
+			FramesPath: framesPath,
+			MaxSeconds: maxDuration.Seconds(),
+		},
+	}
+	r.encoder = json.NewEncoder(r.jsonWriter)
+
+	log.Printf("[Recorder] Recording started: %s (max %s)", wavPath, maxDuration)
+	return r, nil
+}
+
+// Done is closed when the recording has stopped (manually or by the duration cap)
+func (r *Recorder) Done() <-chan struct{} {
+	return r.done
+}
+
+// Status returns the current recording status
+func (r *Recorder) Status() RecordingStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// offset returns microseconds since the recording started
+func (r *Recorder) offset() int64 {
+	return time.Since(r.startedAt).Microseconds()
+}
+
+// writePacket appends raw PCM to the WAV file and a packet entry to the sidecar
+func (r *Recorder) writePacket(p capturedPacket) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.status.Active {
+		return
+	}
+
+	if r.format == nil {
+		format := p.format
+		fft := p.fft
+		r.format = &format
+		r.writeEntry(recordEntry{
+			Type:      recordTypeHeader,
+			T:         r.offset(),
+			StartedAt: r.startedAt.UnixMilli(),
+			DeviceID:  p.deviceID,
+			Format:    &format,
+			FFT:       &fft,
+		})
+	} else if *r.format != p.format {
+		// A WAV file has a single format — the endpoint changed under us
+		r.stopLocked(fmt.Errorf("capture format changed (%d Hz/%d ch -> %d Hz/%d ch)",
+			r.format.SampleRate, r.format.Channels, p.format.SampleRate, p.format.Channels))
+		return
+	}
+
+	size := int(p.frames) * int(p.format.BlockAlign)
+	if uint64(r.dataBytes)+uint64(size) > wavMaxDataBytes {
+		log.Printf("[Recorder] WAV size limit reached after %.1fs", r.status.Seconds)
+		r.stopLocked(nil)
+		return
+	}
+	data := p.data
+	if data == nil {
+		data = make([]byte, size) // silent packet
+	}
+	if _, err := r.wavFile.Write(data[:size]); err != nil {
+		r.stopLocked(fmt.Errorf("write wav: %w", err))
+		return
+	}
+	r.dataBytes += uint32(size)
+
+	r.writeEntry(recordEntry{
+		Type:           recordTypePacket,
+		T:              r.offset(),
+		Frames:         p.frames,
+		Flags:          p.flags,
+		DevicePosition: p.devicePosition,
+		QPCPosition:    p.qpcPosition,
+	})
+
+	r.status.Packets++
+	r.status.Seconds = float64(r.dataBytes) / float64(r.format.BlockAlign) / float64(r.format.SampleRate)
+	if r.maxDuration > 0 && r.status.Seconds >= r.maxDuration.Seconds() {
+		log.Printf("[Recorder] Max duration reached (%s)", r.maxDuration)
+		r.stopLocked(nil)
+	}
+}
+
+// writeFrame appends an emitted level frame with the current player metadata
+func (r *Recorder) writeFrame(frame LevelFrame) {
+	var meta *PlayerMeta
+	if r.playerInfo != nil {
+		if p := r.playerInfo(); p != nil {
+			meta = &PlayerMeta{
+				ID:       p.ID,
+				Name:     p.Name,
+				Title:    p.Title,
+				Artist:   p.Artist,
+				Album:    p.Album,
+				State:    p.State,
+				Position: p.Position,
+				Duration: p.Duration,
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Frames before the first packet have no timeline to attach to
+	if !r.status.Active || r.format == nil {
+		return
+	}
+
+	r.writeEntry(recordEntry{
+		Type:   recordTypeFrame,
+		T:      r.offset(),
+		Frame:  &frame,
+		Player: meta,
+	})
+	r.status.LevelFrames++
+}
+
+// writeEntry encodes one sidecar line; must be called with r.mu held
+func (r *Recorder) writeEntry(e recordEntry) {
+	if err := r.encoder.Encode(e); err != nil {
+		r.stopLocked(fmt.Errorf("write frames sidecar: %w", err))
+	}
+}
+
+// Close stops the recording and finalizes both files
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.status.Active {
+		if r.status.Error != "" {
+			return fmt.Errorf("%s", r.status.Error)
+		}
+		return nil
+	}
+	return r.stopLocked(nil)
+}
+
+// stopLocked finalizes the recording; must be called with r.mu held
+func (r *Recorder) stopLocked(cause error) error {
+	if !r.status.Active {
+		return nil
+	}
+	r.status.Active = false
+	defer close(r.done)
+
+	if cause != nil {
+		r.status.Error = cause.Error()
+		log.Printf("[Recorder] Recording stopped: %v", cause)
+	}
+
+	var firstErr error
+	if err := r.jsonWriter.Flush(); err != nil {
+		firstErr = err
+	}
+	if err := r.jsonFile.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if err := r.finalizeWAV(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if err := r.wavFile.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+
+	log.Printf("[Recorder] Recording saved: %s (%.1fs, %d packets, %d frames)",
+		r.status.WAVPath, r.status.Seconds, r.status.Packets, r.status.LevelFrames)
+
+	if firstErr != nil && r.status.Error == "" {
+		r.status.Error = firstErr.Error()
+	}
+	return firstErr
+}
+
+// finalizeWAV rewrites the RIFF header with the final data size
+func (r *Recorder) finalizeWAV() error {
+	format := pcmFormat{FormatTag: wavFormatIEEEFloat, Channels: 2, SampleRate: 48000, BitsPerSample: 32, BlockAlign: 8}
+	if r.format != nil {
+		format = *r.format
+	}
+
+	header := make([]byte, wavHeaderSize)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 36+r.dataBytes)
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], format.FormatTag)
+	binary.LittleEndian.PutUint16(header[22:], format.Channels)
+	binary.LittleEndian.PutUint32(header[24:], format.SampleRate)
+	binary.LittleEndian.PutUint32(header[28:], format.SampleRate*uint32(format.BlockAlign))
+	binary.LittleEndian.PutUint16(header[32:], format.BlockAlign)
+	binary.LittleEndian.PutUint16(header[34:], format.BitsPerSample)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], r.dataBytes)
+
+	_, err := r.wavFile.WriteAt(header, 0)
+	return err
+}
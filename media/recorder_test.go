@@ -0,0 +1,169 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPCMFormat is 48 kHz stereo float, as WASAPI usually delivers it
+var testPCMFormat = pcmFormat{FormatTag: wavFormatIEEEFloat, Channels: 2, SampleRate: 48000, BitsPerSample: 32, BlockAlign: 8}
+
+// testPacket returns a captured packet of frames stereo float samples
+func testPacket(frames uint32, value float32, devicePosition uint64) capturedPacket {
+	data := make([]byte, 0, int(frames)*8)
+	for i := 0; i < int(frames)*2; i++ {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(value))
+	}
+	return capturedPacket{
+		format:         testPCMFormat,
+		deviceID:       "test-device",
+		fft:            DefaultFFTConfig(),
+		data:           data,
+		frames:         frames,
+		devicePosition: devicePosition,
+		qpcPosition:    devicePosition * 100,
+	}
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "rec")
+	player := &Player{ID: 3, Name: "Spotify", Title: "Song", Artist: "Artist", State: StatePlaying, Position: 12, Duration: 200}
+	r, err := NewRecorder(base, time.Minute, func() *Player { return player })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A frame before the first packet has no timeline and is dropped
+	r.writeFrame(LevelFrame{RMS: 0.1})
+	first := testPacket(480, 0.5, 0)
+	r.writePacket(first)
+	r.writeFrame(LevelFrame{Levels: []float32{0.2, 0.4}, RMS: 0.3, DevicePosition: 480})
+	silent := testPacket(240, 0, 480)
+	silent.data, silent.flags = nil, 2
+	r.writePacket(silent)
+	r.writePacket(testPacket(480, -0.25, 720))
+	r.writeFrame(LevelFrame{Levels: []float32{0.1, 0.1}, RMS: 0.05, DevicePosition: 1200})
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-r.Done():
+	default:
+		t.Error("Done not closed")
+	}
+	status := r.Status()
+	if status.Active || status.Packets != 3 || status.LevelFrames != 2 || status.Seconds != 0.025 {
+		t.Errorf("status = %+v", status)
+	}
+
+	// Header sizes
+	wav, err := os.ReadFile(base + ".wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const dataBytes = 1200 * 8
+	if len(wav) != wavHeaderSize+dataBytes {
+		t.Fatalf("wav is %d bytes", len(wav))
+	}
+	if riff := binary.LittleEndian.Uint32(wav[4:]); riff != 36+dataBytes {
+		t.Errorf("RIFF size = %d", riff)
+	}
+	if data := binary.LittleEndian.Uint32(wav[40:]); data != dataBytes {
+		t.Errorf("data size = %d", data)
+	}
+
+	// The replay source reads the same format and packet boundaries
+	src, err := openFileSource(base + ".wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.close()
+	if src.format != testPCMFormat || src.deviceID != "test-device" || src.fft == nil || *src.fft != DefaultFFTConfig() {
+		t.Errorf("format %+v, device %q, fft %+v", src.format, src.deviceID, src.fft)
+	}
+	wantFrames := []uint32{480, 240, 480}
+	var pcm []byte
+	for i, want := range wantFrames {
+		p, err := src.nextPacket()
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		if p.frames != want || len(p.data) != int(want)*8 {
+			t.Errorf("packet %d: %d frames, %d bytes", i, p.frames, len(p.data))
+		}
+		pcm = append(pcm, p.data...)
+	}
+	if _, err := src.nextPacket(); err != io.EOF {
+		t.Errorf("after the last packet: %v", err)
+	}
+	if !bytes.Equal(pcm, wav[wavHeaderSize:]) || !bytes.Equal(pcm[:480*8], first.data) {
+		t.Error("replayed PCM differs from the recording")
+	}
+
+	// The sidecar frames carry the player
+	sidecar, err := os.Open(base + ".jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sidecar.Close()
+	var frames []recordEntry
+	scanner := bufio.NewScanner(sidecar)
+	for scanner.Scan() {
+		var e recordEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Type == recordTypeFrame {
+			frames = append(frames, e)
+		}
+	}
+	if len(frames) != 2 {
+		t.Fatalf("%d frames in the sidecar", len(frames))
+	}
+	if f := frames[0]; f.Frame.RMS != 0.3 || f.Frame.DevicePosition != 480 || f.Player == nil || f.Player.Title != "Song" || f.Player.Position != 12 {
+		t.Errorf("first frame = %+v, player %+v", f.Frame, f.Player)
+	}
+	if frames[1].T < frames[0].T {
+		t.Errorf("frame offsets %d, %d", frames[0].T, frames[1].T)
+	}
+}
+
+func TestRecorderStopsAtWAVSizeLimit(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "rec")
+	r, err := NewRecorder(base, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.writePacket(testPacket(480, 0.5, 0))
+
+	// Pretend nearly 4 GiB are written: the next packet would overflow the sizes
+	r.mu.Lock()
+	r.dataBytes = wavMaxDataBytes - 100
+	r.mu.Unlock()
+	r.writePacket(testPacket(480, 0.5, 480))
+
+	select {
+	case <-r.Done():
+	default:
+		t.Fatal("recording not stopped at the size limit")
+	}
+	if status := r.Status(); status.Active || status.Error != "" || status.Packets != 1 {
+		t.Errorf("status = %+v", status)
+	}
+	wav, err := os.ReadFile(base + ".wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if riff := binary.LittleEndian.Uint32(wav[4:]); riff != math.MaxUint32-100 {
+		t.Errorf("RIFF size = %d", riff)
+	}
+}
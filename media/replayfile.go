@@ -0,0 +1,197 @@
+package media
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// replayPacketFrames is the packet size used when a WAV file has no sidecar (10ms at 48kHz)
+const replayPacketFrames = 480
+
+// replayPacket is one packet read back from a recording
+type replayPacket struct {
+	offset         time.Duration
+	frames         uint32
+	flags          uint32
+	devicePosition uint64
+	data           []byte
+}
+
+// fileSource reads a recorded WAV file, split into the packets listed in its JSONL
+// sidecar so the analysis sees the exact same packet boundaries as the live capture did.
+type fileSource struct {
+	path     string
+	wav      *os.File
+	format   pcmFormat
+	fft      *FFTConfig
+	deviceID string
+	packets  []recordEntry
+	next     int
+	position uint64 // frames read so far
+	dataLeft int64
+}
+
+// openFileSource opens a recording by its WAV path; the sidecar (<name>.jsonl) is optional
+func openFileSource(wavPath string) (*fileSource, error) {
+	f, err := os.Open(wavPath)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &fileSource{path: wavPath, wav: f}
+	if err := src.readWAVHeader(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	sidecar := strings.TrimSuffix(wavPath, ".wav") + ".jsonl"
+	if err := src.readSidecar(sidecar); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			f.Close()
+			return nil, err
+		}
+		log.Printf("[Replay] No sidecar for %s, using fixed %d-frame packets", wavPath, replayPacketFrames)
+	}
+
+	return src, nil
+}
+
+// readWAVHeader walks the RIFF chunks up to "data" and reads the "fmt " chunk
+func (src *fileSource) readWAVHeader() error {
+	var riff [12]byte
+	if _, err := io.ReadFull(src.wav, riff[:]); err != nil {
+		return fmt.Errorf("read riff header: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return fmt.Errorf("%s is not a WAV file", src.path)
+	}
+
+	haveFormat := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(src.wav, chunk[:]); err != nil {
+			return fmt.Errorf("read chunk header: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(src.wav, buf); err != nil {
+				return fmt.Errorf("read fmt chunk: %w", err)
+			}
+			if len(buf) < 16 {
+				return fmt.Errorf("fmt chunk too short")
+			}
+			src.format = pcmFormat{
+				FormatTag:     binary.LittleEndian.Uint16(buf[0:]),
+				Channels:      binary.LittleEndian.Uint16(buf[2:]),
+				SampleRate:    binary.LittleEndian.Uint32(buf[4:]),
+				BlockAlign:    binary.LittleEndian.Uint16(buf[12:]),
+				BitsPerSample: binary.LittleEndian.Uint16(buf[14:]),
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return fmt.Errorf("data chunk before fmt chunk")
+			}
+			src.dataLeft = size
+			return src.validateFormat()
+		default:
+			if _, err := src.wav.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return fmt.Errorf("skip %q chunk: %w", id, err)
+			}
+		}
+	}
+}
+
+// validateFormat accepts the two sample layouts extractSamples understands
+func (src *fileSource) validateFormat() error {
+	f := src.format
+	switch {
+	case f.Channels == 0 || f.SampleRate == 0 || f.BlockAlign == 0:
+		return fmt.Errorf("invalid WAV format")
+	case f.BitsPerSample == 32 && (f.FormatTag == wavFormatIEEEFloat || f.FormatTag == wavFormatExtensible):
+	case f.BitsPerSample == 16 && (f.FormatTag == wavFormatPCM || f.FormatTag == wavFormatExtensible):
+	default:
+		return fmt.Errorf("unsupported WAV format: tag=%d, bits=%d", f.FormatTag, f.BitsPerSample)
+	}
+	return nil
+}
+
+// readSidecar loads the header and packet entries of a recording
+func (src *fileSource) readSidecar(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e recordEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("parse sidecar: %w", err)
+		}
+		switch e.Type {
+		case recordTypeHeader:
+			src.fft = e.FFT
+			src.deviceID = e.DeviceID
+		case recordTypePacket:
+			src.packets = append(src.packets, e)
+		}
+	}
+	return scanner.Err()
+}
+
+// nextPacket returns the next packet, or io.EOF at the end of the recording
+func (src *fileSource) nextPacket() (*replayPacket, error) {
+	p := &replayPacket{frames: replayPacketFrames}
+
+	if src.packets != nil {
+		if src.next >= len(src.packets) {
+			return nil, io.EOF
+		}
+		e := src.packets[src.next]
+		src.next++
+		p.offset = time.Duration(e.T) * time.Microsecond
+		p.frames = e.Frames
+		p.flags = e.Flags
+		p.devicePosition = e.DevicePosition + uint64(e.Frames)
+	} else {
+		p.offset = time.Duration(src.position) * time.Second / time.Duration(src.format.SampleRate)
+		p.devicePosition = src.position + uint64(p.frames)
+	}
+
+	size := int64(p.frames) * int64(src.format.BlockAlign)
+	if size > src.dataLeft {
+		size = src.dataLeft - src.dataLeft%int64(src.format.BlockAlign)
+		p.frames = uint32(size / int64(src.format.BlockAlign))
+	}
+	if size <= 0 {
+		return nil, io.EOF
+	}
+
+	p.data = make([]byte, size)
+	if _, err := io.ReadFull(src.wav, p.data); err != nil {
+		return nil, fmt.Errorf("read wav data: %w", err)
+	}
+	src.dataLeft -= size
+	src.position += uint64(p.frames)
+
+	return p, nil
+}
+
+func (src *fileSource) close() {
+	src.wav.Close()
+}
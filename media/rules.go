@@ -0,0 +1,110 @@
+package media
+
+import (
+	"strings"
+	"time"
+)
+
+// PlayerRules customizes which player is shown as active
+type PlayerRules struct {
+	// Pinned always shows a player with this name (site) while one exists
+	Pinned string `json:"pinned"`
+	// Blocklist names are never shown (e.g. "Twitch")
+	Blocklist []string `json:"blocklist"`
+	// HideMuted skips players that report zero volume
+	HideMuted bool `json:"hideMuted"`
+	// Priority orders players by name; earlier names win among equally active players
+	Priority []string `json:"priority"`
+	// StickySeconds keeps the last shown player until another one has played this long (0 = off)
+	StickySeconds int `json:"stickySeconds"`
+}
+
+// Normalize trims names and drops empty entries
+func (r PlayerRules) Normalize() PlayerRules {
+	r.Pinned = strings.TrimSpace(r.Pinned)
+	r.Blocklist = normalizeNames(r.Blocklist)
+	r.Priority = normalizeNames(r.Priority)
+	if r.StickySeconds < 0 {
+		r.StickySeconds = 0
+	}
+	return r
+}
+
+func normalizeNames(names []string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// blocks reports whether the player must never be shown
+func (r *PlayerRules) blocks(p *Player) bool {
+	if r.HideMuted && p.CanSetVolume && p.Volume == 0 {
+		return true
+	}
+	for _, name := range r.Blocklist {
+		if strings.EqualFold(name, p.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// pins reports whether the player matches the pinned name
+func (r *PlayerRules) pins(p *Player) bool {
+	return r.Pinned != "" && strings.EqualFold(r.Pinned, p.Name)
+}
+
+// rank returns the player's position in the priority list (lower wins)
+func (r *PlayerRules) rank(p *Player) int {
+	for i, name := range r.Priority {
+		if strings.EqualFold(name, p.Name) {
+			return i
+		}
+	}
+	return len(r.Priority)
+}
+
+// activityClass orders players by how "live" they are:
+// 0 = playing with volume > 0, 1 = playing, 2 = anything else
+func activityClass(p *Player) int {
+	switch {
+	case p.State == StatePlaying && p.Volume > 0:
+		return 0
+	case p.State == StatePlaying:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// better reports whether a should be shown instead of b
+func (r *PlayerRules) better(a, b *Player) bool {
+	if ca, cb := activityClass(a), activityClass(b); ca != cb {
+		return ca < cb
+	}
+	if ra, rb := r.rank(a), r.rank(b); ra != rb {
+		return ra < rb
+	}
+	return a.ActiveAt > b.ActiveAt
+}
+
+// playedLongEnough reports whether p has been playing for at least the sticky period
+func (r *PlayerRules) playedLongEnough(p *Player, now time.Time) bool {
+	if p.State != StatePlaying || p.playingSince == 0 {
+		return false
+	}
+	return now.UnixMilli()-p.playingSince >= int64(r.StickySeconds)*1000
+}
+
+// markPlaying records when a player switched into the playing state
+func markPlaying(p *Player, wasPlaying bool, now time.Time) {
+	if p.State == StatePlaying && !wasPlaying {
+		p.playingSince = now.UnixMilli()
+	} else if p.State != StatePlaying {
+		p.playingSince = 0
+	}
+}
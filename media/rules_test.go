@@ -0,0 +1,212 @@
+package media
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlayerRulesNormalize(t *testing.T) {
+	got := PlayerRules{
+		Pinned:        "  Spotify ",
+		Blocklist:     []string{" Twitch", "", "  "},
+		Priority:      []string{"YouTube ", ""},
+		StickySeconds: -5,
+	}.Normalize()
+	want := PlayerRules{Pinned: "Spotify", Blocklist: []string{"Twitch"}, Priority: []string{"YouTube"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlayerRulesBetter(t *testing.T) {
+	rules := PlayerRules{Priority: []string{"Spotify", "youtube"}}
+	playing := func(name string, volume int, activeAt int64) *Player {
+		return &Player{Name: name, State: StatePlaying, Volume: volume, ActiveAt: activeAt}
+	}
+	paused := func(name string, activeAt int64) *Player {
+		return &Player{Name: name, State: StatePaused, Volume: 50, ActiveAt: activeAt}
+	}
+
+	tests := []struct {
+		name string
+		a, b *Player
+		want bool
+	}{
+		{"audible beats silent", playing("Twitch", 50, 1), playing("Spotify", 0, 2), true},
+		{"silent playing beats paused", playing("Twitch", 0, 1), paused("Spotify", 2), true},
+		{"paused loses to playing", paused("Spotify", 2), playing("Twitch", 0, 1), false},
+		{"priority within a class", playing("Spotify", 50, 1), playing("YouTube", 50, 2), true},
+		{"priority ignores case", playing("YOUTUBE", 50, 1), playing("Twitch", 50, 2), true},
+		{"listed beats unlisted", paused("YouTube", 1), paused("Twitch", 2), true},
+		{"priority does not cross classes", paused("Spotify", 2), playing("Twitch", 50, 1), false},
+		{"latest activity breaks ties", playing("Twitch", 50, 2), playing("Chrome", 50, 1), true},
+		{"earlier activity loses", playing("Chrome", 50, 1), playing("Twitch", 50, 2), false},
+		{"equal players", playing("Chrome", 50, 1), playing("Chrome", 50, 1), false},
+	}
+	for _, tt := range tests {
+		if got := rules.better(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: better = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlayerRulesBlocksAndPins(t *testing.T) {
+	rules := PlayerRules{Pinned: "spotify", Blocklist: []string{"Twitch"}, HideMuted: true}
+	tests := []struct {
+		player       *Player
+		blocks, pins bool
+	}{
+		{&Player{Name: "Spotify", Volume: 50, CanSetVolume: true}, false, true},
+		{&Player{Name: "twitch", Volume: 50}, true, false},
+		{&Player{Name: "YouTube", Volume: 0, CanSetVolume: true}, true, false},
+		{&Player{Name: "YouTube", Volume: 0}, false, false}, // no volume reported
+		{&Player{Name: "Spotify", Volume: 0, CanSetVolume: true}, true, true},
+	}
+	for _, tt := range tests {
+		if got := rules.blocks(tt.player); got != tt.blocks {
+			t.Errorf("%s (volume %d): blocks = %v", tt.player.Name, tt.player.Volume, got)
+		}
+		if got := rules.pins(tt.player); got != tt.pins {
+			t.Errorf("%s: pins = %v", tt.player.Name, got)
+		}
+	}
+	if (&PlayerRules{}).pins(&Player{}) {
+		t.Error("an empty pin matched a player without a name")
+	}
+}
+
+func TestPlayerRulesPlayedLongEnough(t *testing.T) {
+	rules := PlayerRules{StickySeconds: 10}
+	now := time.Unix(1000, 0)
+	p := &Player{State: StatePlaying}
+	markPlaying(p, false, now.Add(-10*time.Second))
+	if !rules.playedLongEnough(p, now) {
+		t.Error("10 s of playback not long enough")
+	}
+	if rules.playedLongEnough(p, now.Add(-time.Millisecond)) {
+		t.Error("under 10 s of playback long enough")
+	}
+
+	// Updates while playing keep the start; pausing resets it
+	markPlaying(p, true, now)
+	if !rules.playedLongEnough(p, now) {
+		t.Error("an update while playing restarted the period")
+	}
+	p.State = StatePaused
+	markPlaying(p, true, now)
+	if rules.playedLongEnough(p, now.Add(time.Hour)) {
+		t.Error("paused player played long enough")
+	}
+}
+
+func TestSelectActivePlayer(t *testing.T) {
+	now := time.Unix(1000, 0)
+	player := func(id int, name string, state StateMode, volume int, activeAt int64, playingFor time.Duration) *Player {
+		p := &Player{ID: id, Name: name, Title: name + " song", State: state, Volume: volume, CanSetVolume: true, ActiveAt: activeAt}
+		markPlaying(p, false, now.Add(-playingFor))
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		rules   PlayerRules
+		pinned  int // pinned player ID
+		current int // active player before the election
+		players []*Player
+		want    int
+	}{
+		{
+			name:    "most live player",
+			players: []*Player{player(1, "Spotify", StatePaused, 50, 9, 0), player(2, "YouTube", StatePlaying, 50, 1, time.Second)},
+			want:    2,
+		},
+		{
+			name:    "untitled players are skipped",
+			players: []*Player{{ID: 1, State: StatePlaying, Volume: 50}, player(2, "YouTube", StatePaused, 50, 1, 0)},
+			want:    2,
+		},
+		{
+			name:    "nothing to show",
+			rules:   PlayerRules{Blocklist: []string{"YouTube"}},
+			players: []*Player{player(1, "YouTube", StatePlaying, 50, 1, time.Second)},
+			want:    0,
+		},
+		{
+			name:    "blocklist",
+			rules:   PlayerRules{Blocklist: []string{"youtube"}},
+			players: []*Player{player(1, "Spotify", StatePaused, 50, 1, 0), player(2, "YouTube", StatePlaying, 50, 2, time.Second)},
+			want:    1,
+		},
+		{
+			name:    "muted players hidden",
+			rules:   PlayerRules{HideMuted: true},
+			players: []*Player{player(1, "Spotify", StatePaused, 50, 1, 0), player(2, "YouTube", StatePlaying, 0, 2, time.Second)},
+			want:    1,
+		},
+		{
+			name:    "pinned name beats livelier players",
+			rules:   PlayerRules{Pinned: "Spotify"},
+			players: []*Player{player(1, "Spotify", StatePaused, 50, 1, 0), player(2, "YouTube", StatePlaying, 50, 2, time.Second)},
+			want:    1,
+		},
+		{
+			name:    "blocked pinned name",
+			rules:   PlayerRules{Pinned: "Spotify", Blocklist: []string{"Spotify"}},
+			players: []*Player{player(1, "Spotify", StatePlaying, 50, 1, time.Second), player(2, "YouTube", StatePaused, 50, 2, 0)},
+			want:    2,
+		},
+		{
+			name:    "pinned player ID beats everything",
+			rules:   PlayerRules{Pinned: "YouTube", Blocklist: []string{"Spotify"}},
+			pinned:  1,
+			players: []*Player{player(1, "Spotify", StatePaused, 50, 1, 0), player(2, "YouTube", StatePlaying, 50, 2, time.Second)},
+			want:    1,
+		},
+		{
+			name:    "priority among playing players",
+			rules:   PlayerRules{Priority: []string{"Spotify"}},
+			players: []*Player{player(1, "Spotify", StatePlaying, 50, 1, time.Second), player(2, "YouTube", StatePlaying, 50, 2, time.Second)},
+			want:    1,
+		},
+		{
+			name:    "sticky keeps the current player",
+			rules:   PlayerRules{StickySeconds: 5},
+			current: 1,
+			players: []*Player{player(1, "Spotify", StatePaused, 50, 1, 0), player(2, "YouTube", StatePlaying, 50, 2, 4*time.Second)},
+			want:    1,
+		},
+		{
+			name:    "sticky hands over after the period",
+			rules:   PlayerRules{StickySeconds: 5},
+			current: 1,
+			players: []*Player{player(1, "Spotify", StatePaused, 50, 1, 0), player(2, "YouTube", StatePlaying, 50, 2, 5*time.Second)},
+			want:    2,
+		},
+		{
+			name:    "sticky yields to a pinned name",
+			rules:   PlayerRules{StickySeconds: 5, Pinned: "YouTube"},
+			current: 1,
+			players: []*Player{player(1, "Spotify", StatePlaying, 50, 1, time.Minute), player(2, "YouTube", StatePaused, 50, 2, 0)},
+			want:    2,
+		},
+		{
+			name:    "sticky drops a blocked current player",
+			rules:   PlayerRules{StickySeconds: 5, Blocklist: []string{"Spotify"}},
+			current: 1,
+			players: []*Player{player(1, "Spotify", StatePlaying, 50, 1, time.Minute), player(2, "YouTube", StatePlaying, 50, 2, time.Second)},
+			want:    2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &PlayerHub{players: make(map[int]*Player), rules: tt.rules.Normalize(), pinnedPlayerID: tt.pinned, activePlayerID: tt.current}
+			for _, p := range tt.players {
+				h.players[p.ID] = p
+			}
+			if got := h.selectActivePlayer(now); got != tt.want {
+				t.Errorf("selected %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,417 @@
+package media
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultListenBrainzEndpoint is the public ListenBrainz API root
+const DefaultListenBrainzEndpoint = "https://api.listenbrainz.org"
+
+const (
+	// scrobbleMinDuration is the shortest track (seconds) that may be scrobbled
+	scrobbleMinDuration = 30
+	// scrobbleMaxThreshold is the listening time (seconds) after which any track scrobbles
+	scrobbleMaxThreshold = 240
+	// scrobbleBatchSize is the number of queued listens submitted per request
+	scrobbleBatchSize = 50
+	// scrobbleRetryMin and scrobbleRetryMax bound the retry backoff of the queue
+	scrobbleRetryMin = 30 * time.Second
+	scrobbleRetryMax = 15 * time.Minute
+	// scrobbleRequestTimeout bounds one submission
+	scrobbleRequestTimeout = 15 * time.Second
+	// scrobbleClientName identifies the app in submissions
+	scrobbleClientName = "Round Sound"
+)
+
+// ScrobblerConfig configures a ListenBrainz-compatible scrobbling endpoint
+type ScrobblerConfig struct {
+	Enabled bool `json:"enabled"`
+	// Endpoint is the API root, e.g. https://api.listenbrainz.org or a self-hosted instance
+	Endpoint string `json:"endpoint"`
+	Token    string `json:"token"`
+}
+
+// ScrobblerStatus is a snapshot of the scrobbler state
+type ScrobblerStatus struct {
+	Enabled      bool   `json:"enabled"`
+	Queued       int    `json:"queued"`
+	Submitted    uint64 `json:"submitted"`
+	NowPlaying   string `json:"nowPlaying"`
+	LastError    string `json:"lastError"`
+	LastErrorAt  int64  `json:"lastErrorAt"`  // unix ms
+	LastSubmitAt int64  `json:"lastSubmitAt"` // unix ms
+}
+
+// Listen is a queued scrobble
+type Listen struct {
+	ListenedAt int64  `json:"listenedAt"` // unix seconds
+	Artist     string `json:"artist"`
+	Title      string `json:"title"`
+	Album      string `json:"album,omitempty"`
+	Duration   int    `json:"duration,omitempty"` // seconds
+	Player     string `json:"player,omitempty"`
+}
+
+// errPermanent marks submissions that will never succeed as sent (rejected data)
+var errPermanent = errors.New("rejected by server")
+
+// Scrobbler sends "now playing" notifications and scrobbles finished listens,
+// keeping a retry queue on disk while the endpoint is unreachable
+type Scrobbler struct {
+	mu        sync.Mutex
+	config    ScrobblerConfig
+	client    *http.Client
+	queuePath string
+	queue     []Listen
+	status    ScrobblerStatus
+	// scrobbledKey identifies the session already scrobbled
+	scrobbledKey string
+
+	wake   chan struct{}
+	stopCh chan struct{}
+}
+
+// ScrobbleEligible applies the standard rules: the track is longer than 30 seconds and
+// was listened to for at least half its length or 4 minutes
+func ScrobbleEligible(session PlaySession) bool {
+	if session.Artist == "" || session.Title == "" {
+		return false
+	}
+	if session.Duration == 0 {
+		return session.Listened >= scrobbleMaxThreshold
+	}
+	if session.Duration <= scrobbleMinDuration {
+		return false
+	}
+	threshold := min(float64(session.Duration)/2, scrobbleMaxThreshold)
+	return session.Listened >= threshold
+}
+
+// NewScrobbler creates a scrobbler persisting its queue at queuePath.
+// client may be nil to use a default client.
+func NewScrobbler(config ScrobblerConfig, queuePath string, client *http.Client) *Scrobbler {
+	if client == nil {
+		client = &http.Client{Timeout: scrobbleRequestTimeout}
+	}
+	s := &Scrobbler{
+		config:    normalizeScrobblerConfig(config),
+		client:    client,
+		queuePath: queuePath,
+		wake:      make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+	}
+	s.loadQueue()
+	s.status.Enabled = s.config.Enabled
+
+	go s.flushLoop()
+	return s
+}
+
+func normalizeScrobblerConfig(config ScrobblerConfig) ScrobblerConfig {
+	config.Endpoint = strings.TrimRight(strings.TrimSpace(config.Endpoint), "/")
+	if config.Endpoint == "" {
+		config.Endpoint = DefaultListenBrainzEndpoint
+	}
+	config.Token = strings.TrimSpace(config.Token)
+	return config
+}
+
+// SetConfig replaces the endpoint settings and retries the queue
+func (s *Scrobbler) SetConfig(config ScrobblerConfig) {
+	s.mu.Lock()
+	s.config = normalizeScrobblerConfig(config)
+	s.status.Enabled = s.config.Enabled
+	s.mu.Unlock()
+
+	s.Flush()
+}
+
+// Config returns the current settings
+func (s *Scrobbler) Config() ScrobblerConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
+
+// Status returns a snapshot of the scrobbler state
+func (s *Scrobbler) Status() ScrobblerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.status
+	status.Queued = len(s.queue)
+	return status
+}
+
+// Stop ends the background submission loop
+func (s *Scrobbler) Stop() {
+	close(s.stopCh)
+}
+
+// OnSessionStart sends a "now playing" notification for a new track
+func (s *Scrobbler) OnSessionStart(session PlaySession) {
+	s.mu.Lock()
+	config := s.config
+	s.mu.Unlock()
+	if !config.Enabled || config.Token == "" || session.Artist == "" {
+		return
+	}
+
+	listen := listenFromSession(session)
+	go func() {
+		err := s.submit(config, "playing_now", []Listen{listen})
+		s.mu.Lock()
+		if err != nil {
+			s.recordErrorLocked(err)
+		} else {
+			s.status.NowPlaying = listen.Artist + " - " + listen.Title
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// OnSessionUpdate scrobbles the running session as soon as it qualifies
+func (s *Scrobbler) OnSessionUpdate(session PlaySession) {
+	s.scrobble(session)
+}
+
+// OnSessionEnd scrobbles the finished session if it qualifies and was not scrobbled yet
+func (s *Scrobbler) OnSessionEnd(session PlaySession) {
+	s.scrobble(session)
+}
+
+func (s *Scrobbler) scrobble(session PlaySession) {
+	key := fmt.Sprintf("%d:%d", session.PlayerID, session.StartedAt)
+
+	s.mu.Lock()
+	if !s.config.Enabled || s.scrobbledKey == key || !ScrobbleEligible(session) {
+		s.mu.Unlock()
+		return
+	}
+	s.scrobbledKey = key
+	s.queue = append(s.queue, listenFromSession(session))
+	s.saveQueueLocked()
+	s.mu.Unlock()
+
+	log.Printf("[Scrobbler] Queued %s - %s", session.Artist, session.Title)
+	s.Flush()
+}
+
+// Flush asks the background loop to submit the queue now
+func (s *Scrobbler) Flush() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flushLoop submits queued listens, backing off while the endpoint fails
+func (s *Scrobbler) flushLoop() {
+	backoff := scrobbleRetryMin
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+
+		err := s.flushQueue()
+		if err == nil {
+			backoff = scrobbleRetryMin
+			continue
+		}
+
+		log.Printf("[Scrobbler] Submission failed, retrying in %v: %v", backoff, err)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(backoff)
+		backoff = min(backoff*2, scrobbleRetryMax)
+	}
+}
+
+// flushQueue submits queued listens in batches until the queue is empty or a batch fails
+func (s *Scrobbler) flushQueue() error {
+	for {
+		s.mu.Lock()
+		config := s.config
+		if !config.Enabled || config.Token == "" || len(s.queue) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		batch := append([]Listen(nil), s.queue[:min(len(s.queue), scrobbleBatchSize)]...)
+		s.mu.Unlock()
+
+		listenType := "import"
+		if len(batch) == 1 {
+			listenType = "single"
+		}
+		err := s.submit(config, listenType, batch)
+
+		s.mu.Lock()
+		if err != nil {
+			s.recordErrorLocked(err)
+		}
+		if err == nil || errors.Is(err, errPermanent) {
+			// Drop the batch: submitted, or rejected data that will never be accepted
+			s.queue = s.queue[len(batch):]
+			s.saveQueueLocked()
+			if err == nil {
+				s.status.Submitted += uint64(len(batch))
+				s.status.LastSubmitAt = time.Now().UnixMilli()
+				log.Printf("[Scrobbler] Submitted %d listen(s)", len(batch))
+			}
+		}
+		s.mu.Unlock()
+
+		if err != nil && !errors.Is(err, errPermanent) {
+			return err
+		}
+	}
+}
+
+// listenBrainzPayload is one listen in a ListenBrainz submission
+type listenBrainzPayload struct {
+	ListenedAt    int64                     `json:"listened_at,omitempty"`
+	TrackMetadata listenBrainzTrackMetadata `json:"track_metadata"`
+}
+
+type listenBrainzTrackMetadata struct {
+	ArtistName     string         `json:"artist_name"`
+	TrackName      string         `json:"track_name"`
+	ReleaseName    string         `json:"release_name,omitempty"`
+	AdditionalInfo map[string]any `json:"additional_info,omitempty"`
+}
+
+// submit posts listens to the ListenBrainz submit-listens API
+func (s *Scrobbler) submit(config ScrobblerConfig, listenType string, listens []Listen) error {
+	payload := make([]listenBrainzPayload, 0, len(listens))
+	for _, l := range listens {
+		info := map[string]any{"submission_client": scrobbleClientName}
+		if l.Duration > 0 {
+			info["duration_ms"] = l.Duration * 1000
+		}
+		if l.Player != "" {
+			info["media_player"] = l.Player
+		}
+		p := listenBrainzPayload{
+			TrackMetadata: listenBrainzTrackMetadata{
+				ArtistName:     l.Artist,
+				TrackName:      l.Title,
+				ReleaseName:    l.Album,
+				AdditionalInfo: info,
+			},
+		}
+		if listenType != "playing_now" {
+			p.ListenedAt = l.ListenedAt
+		}
+		payload = append(payload, p)
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"listen_type": listenType,
+		"payload":     payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, config.Endpoint+"/1/submit-listens", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+config.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(message)))
+	// 400 means the listens themselves are invalid; auth, rate limit and server errors are retried
+	if resp.StatusCode == http.StatusBadRequest {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	return err
+}
+
+func listenFromSession(session PlaySession) Listen {
+	return Listen{
+		ListenedAt: session.StartedAt / 1000,
+		Artist:     session.Artist,
+		Title:      session.Title,
+		Album:      session.Album,
+		Duration:   session.Duration,
+		Player:     session.PlayerName,
+	}
+}
+
+// recordErrorLocked stores the last error. Caller must hold mu.
+func (s *Scrobbler) recordErrorLocked(err error) {
+	s.status.LastError = err.Error()
+	s.status.LastErrorAt = time.Now().UnixMilli()
+}
+
+// loadQueue restores listens that were not submitted before the last exit
+func (s *Scrobbler) loadQueue() {
+	data, err := os.ReadFile(s.queuePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[Scrobbler] Failed to read queue: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &s.queue); err != nil {
+		log.Printf("[Scrobbler] Failed to parse queue: %v", err)
+		return
+	}
+	log.Printf("[Scrobbler] Restored %d queued listen(s)", len(s.queue))
+}
+
+// saveQueueLocked writes the queue atomically. Caller must hold mu.
+func (s *Scrobbler) saveQueueLocked() {
+	if len(s.queue) == 0 {
+		if err := os.Remove(s.queuePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("[Scrobbler] Failed to remove queue: %v", err)
+		}
+		return
+	}
+
+	data, err := json.Marshal(s.queue)
+	if err != nil {
+		log.Printf("[Scrobbler] Failed to encode queue: %v", err)
+		return
+	}
+	tmp := s.queuePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Printf("[Scrobbler] Failed to write queue: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, s.queuePath); err != nil {
+		log.Printf("[Scrobbler] Failed to replace queue: %v", err)
+	}
+}
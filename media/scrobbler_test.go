@@ -0,0 +1,250 @@
+package media
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeListenBrainz records submissions and answers with a configurable status
+type fakeListenBrainz struct {
+	server   *httptest.Server
+	status   atomic.Int32
+	requests chan listenBrainzRequest
+}
+
+type listenBrainzRequest struct {
+	Auth       string
+	ListenType string                `json:"listen_type"`
+	Payload    []listenBrainzPayload `json:"payload"`
+}
+
+func newFakeListenBrainz(t *testing.T) *fakeListenBrainz {
+	f := &fakeListenBrainz{requests: make(chan listenBrainzRequest, 16)}
+	f.status.Store(http.StatusOK)
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/1/submit-listens" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var req listenBrainzRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode submission: %v", err)
+		}
+		req.Auth = r.Header.Get("Authorization")
+		f.requests <- req
+
+		status := int(f.status.Load())
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"status":"ok"}`))
+		}
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// next waits for the next submission
+func (f *fakeListenBrainz) next(t *testing.T) listenBrainzRequest {
+	t.Helper()
+	select {
+	case req := <-f.requests:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("no submission received")
+		return listenBrainzRequest{}
+	}
+}
+
+// none checks that nothing is submitted for a moment
+func (f *fakeListenBrainz) none(t *testing.T) {
+	t.Helper()
+	select {
+	case req := <-f.requests:
+		t.Fatalf("unexpected %s submission", req.ListenType)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func newTestScrobbler(t *testing.T, f *fakeListenBrainz, queuePath string) *Scrobbler {
+	s := NewScrobbler(ScrobblerConfig{Enabled: true, Endpoint: f.server.URL + "/", Token: "secret"}, queuePath, f.server.Client())
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func testSession(startedAt int64, duration int, listened float64) PlaySession {
+	return PlaySession{
+		PlayerID:   1,
+		PlayerName: "Spotify",
+		Title:      "Song",
+		Artist:     "Artist",
+		Album:      "Album",
+		Duration:   duration,
+		StartedAt:  startedAt,
+		Listened:   listened,
+	}
+}
+
+func TestScrobbleEligible(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration int
+		listened float64
+		want     bool
+	}{
+		{"too short track", 30, 30, false},
+		{"just over 30 s", 31, 15.5, true},
+		{"less than half", 200, 99, false},
+		{"half", 200, 100, true},
+		{"long track before 4 minutes", 1200, 239, false},
+		{"long track after 4 minutes", 1200, 240, true},
+		{"unknown length before 4 minutes", 0, 239, false},
+		{"unknown length after 4 minutes", 0, 240, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScrobbleEligible(testSession(0, tt.duration, tt.listened)); got != tt.want {
+				t.Errorf("ScrobbleEligible(duration=%d, listened=%v) = %v, want %v", tt.duration, tt.listened, got, tt.want)
+			}
+		})
+	}
+
+	session := testSession(0, 200, 200)
+	session.Artist = ""
+	if ScrobbleEligible(session) {
+		t.Error("session without artist is eligible")
+	}
+}
+
+func TestScrobblerPlayingNow(t *testing.T) {
+	f := newFakeListenBrainz(t)
+	s := newTestScrobbler(t, f, filepath.Join(t.TempDir(), "scrobble-queue.json"))
+
+	s.OnSessionStart(testSession(1700000000000, 200, 0))
+	req := f.next(t)
+
+	if req.Auth != "Token secret" {
+		t.Errorf("Authorization = %q", req.Auth)
+	}
+	if req.ListenType != "playing_now" || len(req.Payload) != 1 {
+		t.Fatalf("got %s with %d listen(s)", req.ListenType, len(req.Payload))
+	}
+	listen := req.Payload[0]
+	if listen.ListenedAt != 0 {
+		t.Errorf("playing_now has listened_at %d", listen.ListenedAt)
+	}
+	meta := listen.TrackMetadata
+	if meta.ArtistName != "Artist" || meta.TrackName != "Song" || meta.ReleaseName != "Album" {
+		t.Errorf("metadata = %+v", meta)
+	}
+	if meta.AdditionalInfo["media_player"] != "Spotify" || meta.AdditionalInfo["duration_ms"] != float64(200000) {
+		t.Errorf("additional info = %v", meta.AdditionalInfo)
+	}
+
+	waitFor(t, func() bool { return s.Status().NowPlaying == "Artist - Song" })
+}
+
+func TestScrobblerSingle(t *testing.T) {
+	f := newFakeListenBrainz(t)
+	s := newTestScrobbler(t, f, filepath.Join(t.TempDir(), "scrobble-queue.json"))
+
+	// Not eligible yet: nothing is sent
+	s.OnSessionUpdate(testSession(1700000000000, 200, 50))
+	f.none(t)
+
+	s.OnSessionUpdate(testSession(1700000000000, 200, 100))
+	req := f.next(t)
+	if req.ListenType != "single" || len(req.Payload) != 1 {
+		t.Fatalf("got %s with %d listen(s)", req.ListenType, len(req.Payload))
+	}
+	if req.Payload[0].ListenedAt != 1700000000 {
+		t.Errorf("listened_at = %d", req.Payload[0].ListenedAt)
+	}
+
+	// The same session is scrobbled once, even when it ends later
+	s.OnSessionUpdate(testSession(1700000000000, 200, 150))
+	s.OnSessionEnd(testSession(1700000000000, 200, 200))
+	f.none(t)
+
+	waitFor(t, func() bool { return s.Status().Submitted == 1 })
+}
+
+func TestScrobblerQueueSurvivesServerErrors(t *testing.T) {
+	f := newFakeListenBrainz(t)
+	f.status.Store(http.StatusServiceUnavailable)
+	queuePath := filepath.Join(t.TempDir(), "scrobble-queue.json")
+	s := newTestScrobbler(t, f, queuePath)
+
+	s.OnSessionEnd(testSession(1700000000000, 200, 200))
+	if req := f.next(t); req.ListenType != "single" {
+		t.Fatalf("got %s", req.ListenType)
+	}
+	s.OnSessionEnd(testSession(1700000300000, 200, 200))
+	if req := f.next(t); req.ListenType != "import" || len(req.Payload) != 2 {
+		t.Fatalf("got %s with %d listen(s)", req.ListenType, len(req.Payload))
+	}
+	waitFor(t, func() bool { return s.Status().Queued == 2 })
+
+	// The failed listens are on disk for the next start
+	var saved []Listen
+	data, err := os.ReadFile(queuePath)
+	if err != nil {
+		t.Fatalf("read queue: %v", err)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil || len(saved) != 2 {
+		t.Fatalf("queue = %s (%v)", data, err)
+	}
+	if status := s.Status(); status.LastError == "" {
+		t.Error("no error recorded")
+	}
+
+	// A restarted scrobbler restores the queue and submits it as one import once the
+	// server is back (the first one waits out its backoff meanwhile)
+	f.status.Store(http.StatusOK)
+	restarted := newTestScrobbler(t, f, queuePath)
+
+	req := f.next(t)
+	if req.ListenType != "import" || len(req.Payload) != 2 {
+		t.Fatalf("got %s with %d listen(s)", req.ListenType, len(req.Payload))
+	}
+	if req.Payload[0].ListenedAt != 1700000000 || req.Payload[1].ListenedAt != 1700000300 {
+		t.Errorf("listened_at = %d, %d", req.Payload[0].ListenedAt, req.Payload[1].ListenedAt)
+	}
+
+	waitFor(t, func() bool { return restarted.Status().Queued == 0 })
+	if _, err := os.Stat(queuePath); !os.IsNotExist(err) {
+		t.Errorf("queue file left after flush: %v", err)
+	}
+}
+
+func TestScrobblerDropsRejectedListens(t *testing.T) {
+	f := newFakeListenBrainz(t)
+	f.status.Store(http.StatusBadRequest)
+	s := newTestScrobbler(t, f, filepath.Join(t.TempDir(), "scrobble-queue.json"))
+
+	s.OnSessionEnd(testSession(1700000000000, 200, 200))
+	f.next(t)
+
+	waitFor(t, func() bool { return s.Status().Queued == 0 && s.Status().LastError != "" })
+	if s.Status().Submitted != 0 {
+		t.Error("rejected listen counted as submitted")
+	}
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,30 @@
+package media
+
+// Player source names, reported in Player.Source
+const (
+	SourceWebNowPlaying = "wnp"
+	SourceMPRIS         = "mpris"
+	SourceMPD           = "mpd"
+)
+
+// SourceChangeCallback is called after a source adds, updates or removes players.
+// changed lists the IDs involved.
+type SourceChangeCallback func(changed []int)
+
+// PlayerSource is a backend that discovers players and controls them. Player IDs
+// must not clash between sources; commands are routed back to the source that
+// reported the player.
+type PlayerSource interface {
+	CommandSender
+
+	// Name identifies the source in logs and in Player.Source
+	Name() string
+	// GetPlayer returns a snapshot of the player with the given ID, or nil
+	GetPlayer(playerID int) *Player
+	// GetPlayers returns snapshots of all players of the source
+	GetPlayers() []*Player
+	// SetChangeCallback sets the callback invoked after players change
+	SetChangeCallback(onChange SourceChangeCallback)
+	// Stop disconnects the source
+	Stop()
+}
@@ -0,0 +1,167 @@
+package media
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// maxListenGap caps the listening time credited between two updates, so a
+	// stalled connection does not count as listening
+	maxListenGap = 10 * time.Second
+	// skipMargin is how close to the end a track must get to count as finished
+	skipMargin = 10
+	// restartWindow detects a track restarting (repeat one) near its beginning
+	restartWindow = 5
+)
+
+// PlaySession is one continuous listen of a track on a player
+type PlaySession struct {
+	PlayerID   int     `json:"playerId"`
+	PlayerName string  `json:"player"`
+	Title      string  `json:"title"`
+	Artist     string  `json:"artist"`
+	Album      string  `json:"album"`
+	Duration   int     `json:"duration"`  // track length, seconds
+	StartedAt  int64   `json:"startedAt"` // unix ms
+	EndedAt    int64   `json:"endedAt"`   // unix ms, 0 while playing
+	Listened   float64 `json:"listened"`  // seconds actually played
+	Skipped    bool    `json:"skipped"`   // ended before reaching the end of the track
+	// LastPosition is the last reported playback position, seconds
+	LastPosition int `json:"-"`
+}
+
+// SessionCallback receives play session lifecycle notifications
+type SessionCallback func(session PlaySession)
+
+// SessionTracker turns player updates into play sessions (track start/end and listened time)
+type SessionTracker struct {
+	mu       sync.Mutex
+	current  *PlaySession
+	playing  bool
+	lastAt   time.Time
+	onStart  SessionCallback
+	onUpdate SessionCallback
+	onEnd    SessionCallback
+}
+
+// NewSessionTracker creates a tracker. Any callback may be nil; onUpdate is called
+// after every update of a running session.
+func NewSessionTracker(onStart, onUpdate, onEnd SessionCallback) *SessionTracker {
+	return &SessionTracker{onStart: onStart, onUpdate: onUpdate, onEnd: onEnd}
+}
+
+// Update feeds the state of the displayed player
+func (t *SessionTracker) Update(player *Player, now time.Time) {
+	if player == nil || player.Title == "" {
+		t.End(now)
+		return
+	}
+
+	t.mu.Lock()
+	var ended, started *PlaySession
+	position := player.Position
+
+	if t.current != nil && !t.sameTrack(player, position) {
+		ended = t.finishLocked(now)
+	} else if t.current != nil {
+		t.creditLocked(now)
+	}
+
+	if t.current == nil {
+		t.current = &PlaySession{
+			PlayerID:   player.ID,
+			PlayerName: player.Name,
+			Title:      player.Title,
+			Artist:     player.Artist,
+			Album:      player.Album,
+			Duration:   player.Duration,
+			StartedAt:  now.UnixMilli(),
+		}
+		copied := *t.current
+		started = &copied
+	}
+
+	t.current.LastPosition = position
+	if player.Duration > 0 {
+		t.current.Duration = player.Duration
+	}
+	t.playing = player.State == StatePlaying
+	t.lastAt = now
+	current := *t.current
+	t.mu.Unlock()
+
+	if ended != nil && t.onEnd != nil {
+		t.onEnd(*ended)
+	}
+	if started != nil && t.onStart != nil {
+		t.onStart(*started)
+	}
+	if t.onUpdate != nil {
+		t.onUpdate(current)
+	}
+}
+
+// End finishes the running session (player gone or app shutting down)
+func (t *SessionTracker) End(now time.Time) {
+	t.mu.Lock()
+	var ended *PlaySession
+	if t.current != nil {
+		ended = t.finishLocked(now)
+	}
+	t.mu.Unlock()
+
+	if ended != nil && t.onEnd != nil {
+		t.onEnd(*ended)
+	}
+}
+
+// Current returns a copy of the running session, or nil
+func (t *SessionTracker) Current() *PlaySession {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.current == nil {
+		return nil
+	}
+	copied := *t.current
+	return &copied
+}
+
+// sameTrack reports whether the update continues the running session. Caller must hold mu.
+func (t *SessionTracker) sameTrack(player *Player, position int) bool {
+	c := t.current
+	if c.PlayerID != player.ID || c.Title != player.Title || c.Artist != player.Artist {
+		return false
+	}
+	// Jumping from the end back to the start is a new listen (repeat one)
+	if c.Duration > 0 && c.LastPosition >= c.Duration-restartWindow && position < restartWindow {
+		return false
+	}
+	return true
+}
+
+// creditLocked adds the time played since the last update. Caller must hold mu.
+func (t *SessionTracker) creditLocked(now time.Time) {
+	if !t.playing || t.lastAt.IsZero() {
+		return
+	}
+	gap := now.Sub(t.lastAt)
+	if gap > maxListenGap {
+		gap = maxListenGap
+	}
+	if gap > 0 {
+		t.current.Listened += gap.Seconds()
+	}
+}
+
+// finishLocked closes the running session and returns it. Caller must hold mu.
+func (t *SessionTracker) finishLocked(now time.Time) *PlaySession {
+	t.creditLocked(now)
+	ended := *t.current
+	ended.EndedAt = now.UnixMilli()
+	ended.Skipped = ended.Duration > 0 && ended.LastPosition < ended.Duration-skipMargin
+	t.current = nil
+	t.playing = false
+	t.lastAt = time.Time{}
+	return &ended
+}
@@ -0,0 +1,129 @@
+package media
+
+import (
+	"testing"
+	"time"
+)
+
+// sessionLog records the callbacks of a SessionTracker
+type sessionLog struct {
+	started, updated, ended []PlaySession
+}
+
+func newTestTracker() (*SessionTracker, *sessionLog) {
+	l := &sessionLog{}
+	t := NewSessionTracker(
+		func(s PlaySession) { l.started = append(l.started, s) },
+		func(s PlaySession) { l.updated = append(l.updated, s) },
+		func(s PlaySession) { l.ended = append(l.ended, s) },
+	)
+	return t, l
+}
+
+func TestSessionTrackerListened(t *testing.T) {
+	tracker, sessions := newTestTracker()
+	start := time.Unix(1000, 0)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	song := func(state StateMode, position int) *Player {
+		return &Player{ID: 1, Name: "Spotify", Title: "Song", Artist: "Artist", Album: "Album", Duration: 200, State: state, Position: position}
+	}
+
+	steps := []struct {
+		at       int
+		player   *Player
+		listened float64
+	}{
+		{0, song(StatePlaying, 0), 0},
+		{4, song(StatePlaying, 4), 4},
+		{6, song(StatePaused, 6), 6},      // played until the pause
+		{60, song(StatePaused, 6), 6},     // paused time is not listening
+		{61, song(StatePlaying, 6), 6},    // resuming credits nothing
+		{91, song(StatePlaying, 100), 16}, // a long gap counts as maxListenGap
+		{92, song(StatePlaying, 40), 17},  // a seek back keeps the session
+	}
+	for _, step := range steps {
+		tracker.Update(step.player, at(step.at))
+		current := tracker.Current()
+		if current == nil || !closeTo(current.Listened, step.listened) {
+			t.Fatalf("at %ds: session %+v, want %v s listened", step.at, current, step.listened)
+		}
+	}
+	if len(sessions.started) != 1 || len(sessions.ended) != 0 || len(sessions.updated) != len(steps) {
+		t.Fatalf("callbacks: %d started, %d updated, %d ended", len(sessions.started), len(sessions.updated), len(sessions.ended))
+	}
+	if s := sessions.started[0]; s.Title != "Song" || s.PlayerName != "Spotify" || s.StartedAt != start.UnixMilli() || s.Listened != 0 {
+		t.Errorf("started %+v", s)
+	}
+
+	// Another track ends the session as skipped and starts a new one
+	other := song(StatePlaying, 0)
+	other.Title = "Other"
+	tracker.Update(other, at(93))
+	if len(sessions.ended) != 1 || len(sessions.started) != 2 {
+		t.Fatalf("track change: %d started, %d ended", len(sessions.started), len(sessions.ended))
+	}
+	ended := sessions.ended[0]
+	if ended.Title != "Song" || !closeTo(ended.Listened, 18) || !ended.Skipped || ended.EndedAt != at(93).UnixMilli() {
+		t.Errorf("ended %+v", ended)
+	}
+	if s := sessions.started[1]; s.Title != "Other" || s.StartedAt != at(93).UnixMilli() {
+		t.Errorf("started %+v", s)
+	}
+
+	// Playing to the end is not a skip
+	other.Position = 195
+	tracker.Update(other, at(100))
+	tracker.End(at(101))
+	if len(sessions.ended) != 2 || sessions.ended[1].Skipped || !closeTo(sessions.ended[1].Listened, 8) {
+		t.Errorf("finished %+v", sessions.ended)
+	}
+	if tracker.Current() != nil {
+		t.Error("session kept after End")
+	}
+
+	// Ending without a session does nothing
+	tracker.End(at(102))
+	if len(sessions.ended) != 2 {
+		t.Errorf("%d sessions ended", len(sessions.ended))
+	}
+}
+
+func TestSessionTrackerNewListen(t *testing.T) {
+	start := time.Unix(1000, 0)
+	player := &Player{ID: 1, Title: "Song", Artist: "Artist", Duration: 100, State: StatePlaying, Position: 97}
+
+	tests := []struct {
+		name    string
+		next    func(p *Player) *Player
+		newSess bool
+	}{
+		{"repeat one", func(p *Player) *Player { p.Position = 1; return p }, true},
+		{"seek near the start", func(p *Player) *Player { p.Position = 10; return p }, false},
+		{"another player", func(p *Player) *Player { p.ID = 2; return p }, true},
+		{"another artist", func(p *Player) *Player { p.Artist = "Cover"; return p }, true},
+		{"no title", func(p *Player) *Player { p.Title = ""; return p }, false},
+		{"no player", func(p *Player) *Player { return nil }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, sessions := newTestTracker()
+			tracker.Update(player.Clone(), start)
+			tracker.Update(tt.next(player.Clone()), start.Add(time.Second))
+
+			wantStarted := 1
+			if tt.newSess {
+				wantStarted = 2
+			}
+			if len(sessions.started) != wantStarted {
+				t.Errorf("%d sessions started, want %d", len(sessions.started), wantStarted)
+			}
+			if tt.newSess || tracker.Current() == nil {
+				if len(sessions.ended) != 1 || !closeTo(sessions.ended[0].Listened, 1) {
+					t.Errorf("ended %+v", sessions.ended)
+				}
+			} else if len(sessions.ended) != 0 {
+				t.Errorf("ended %+v", sessions.ended)
+			}
+		})
+	}
+}
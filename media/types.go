@@ -1,5 +1,7 @@
 package media
 
+import "time"
+
 // StateMode represents playback state
 type StateMode int
 
@@ -31,17 +33,17 @@ const (
 // Player represents media player state
 type Player struct {
 	ID              int          `json:"id"`
+	Source          string       `json:"source"` // PlayerSource that reports the player
 	Name            string       `json:"name"`
 	Title           string       `json:"title"`
 	Artist          string       `json:"artist"`
 	Album           string       `json:"album"`
-	Cover           string       `json:"cover"`
-	CoverData       []byte       `json:"-"` // Binary cover data (not sent to frontend)
+	Cover           string       `json:"cover"` // URL, or the key of a cover in the cover cache
 	State           StateMode    `json:"state"`
 	Position        int          `json:"position"` // seconds
 	Duration        int          `json:"duration"` // seconds
 	Volume          int          `json:"volume"`   // 0-100
-	Rating          int          `json:"rating"`   // 0=none, 1=dislike, 5=like
+	Rating          int          `json:"rating"`   // 0=none; like systems: 1=dislike, 5=like; scale: 1-5 stars
 	Repeat          RepeatMode   `json:"repeat"`
 	Shuffle         bool         `json:"shuffle"`
 	RatingSystem    RatingSystem `json:"ratingSystem"`
@@ -57,6 +59,56 @@ type Player struct {
 	CreatedAt       int64        `json:"createdAt"`
 	UpdatedAt       int64        `json:"updatedAt"`
 	ActiveAt        int64        `json:"activeAt"`
+
+	// PositionAt is when the position was last received (or re-based), unix ms
+	PositionAt int64 `json:"positionAt"`
+	// PlaybackRate scales position extrapolation (1 = normal speed)
+	PlaybackRate float64 `json:"playbackRate"`
+	// EstimatedPosition is the position in seconds extrapolated to the time the
+	// snapshot was taken (see EstimatedPositionAt)
+	EstimatedPosition float64 `json:"estimatedPosition"`
+
+	// basePosition is the precise position (seconds) at PositionAt
+	basePosition float64
+	// playingSince is when the player last entered the playing state, unix ms
+	playingSince int64
+}
+
+// EstimatedPositionAt extrapolates the playback position (seconds) from the last
+// received position. Only a playing player advances; the result is clamped to Duration.
+func (p *Player) EstimatedPositionAt(now time.Time) float64 {
+	pos := p.basePosition
+	if p.State == StatePlaying && p.PositionAt > 0 {
+		rate := p.PlaybackRate
+		if rate <= 0 {
+			rate = 1
+		}
+		if elapsed := now.Sub(time.UnixMilli(p.PositionAt)).Seconds(); elapsed > 0 {
+			pos += elapsed * rate
+		}
+	}
+	if p.Duration > 0 && pos > float64(p.Duration) {
+		pos = float64(p.Duration)
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	return pos
+}
+
+// RebasePosition anchors extrapolation at pos (seconds) received at now
+func (p *Player) RebasePosition(pos float64, now time.Time) {
+	p.basePosition = pos
+	p.PositionAt = now.UnixMilli()
+}
+
+// Snapshot returns a copy with EstimatedPosition filled in for now
+func (p *Player) Snapshot(now time.Time) *Player {
+	clone := p.Clone()
+	if clone != nil {
+		clone.EstimatedPosition = clone.EstimatedPositionAt(now)
+	}
+	return clone
 }
 
 // Clone creates a copy of the player
@@ -2,11 +2,11 @@ package media
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,33 +28,94 @@ const (
 // PlayerUpdateCallback is called when player state changes
 type PlayerUpdateCallback func(player *Player)
 
+// EventStatus is the result code WebNowPlaying reports for a command
+type EventStatus int
+
+const (
+	EventSucceeded    EventStatus = 0
+	EventNotSupported EventStatus = 1
+	EventFailed       EventStatus = 2 // timeout or unable to execute
+)
+
+func (e EventStatus) String() string {
+	switch e {
+	case EventSucceeded:
+		return "Success"
+	case EventNotSupported:
+		return "Not Supported"
+	case EventFailed:
+		return "Timeout/Unable to execute"
+	}
+	return "Unknown"
+}
+
+// EventResultCallback is called when WebNowPlaying reports the result of a command
+type EventResultCallback func(eventID string, status EventStatus)
+
+// PlayersCallback is called with every known player whenever any of them changes
+type PlayersCallback func(players []*Player)
+
 // WebNowPlayingServer manages WebSocket connections with WebNowPlaying
 type WebNowPlayingServer struct {
-	port           int
-	server         *http.Server
-	upgrader       websocket.Upgrader
-	conn           *websocket.Conn
-	connMu         sync.Mutex
-	players        map[int]*Player
-	playersMu      sync.RWMutex
-	activePlayerID int
-	onUpdate       PlayerUpdateCallback
-	stopCh         chan struct{}
-	coverDir       string
-}
-
-// NewWebNowPlayingServer creates and starts a new WebNowPlaying server
-func NewWebNowPlayingServer(port int, onUpdate PlayerUpdateCallback) (*WebNowPlayingServer, error) {
-	// Create cover cache directory
-	coverDir := filepath.Join(os.TempDir(), "round-sound", "covers")
-	os.MkdirAll(coverDir, 0755)
+	port          int
+	server        *http.Server
+	upgrader      websocket.Upgrader
+	conn          *websocket.Conn
+	connMu        sync.Mutex
+	players       map[int]*Player
+	playersMu     sync.RWMutex
+	playerConns   map[int]*websocket.Conn // owning connection per player
+	expired       map[int]*Player         // players dropped by the TTL, restored when their page reports them again
+	playerTTL     time.Duration
+	onChange      SourceChangeCallback
+	onEventResult EventResultCallback
+	stopCh        chan struct{}
+	covers        *CoverCache
+
+	statsMu sync.Mutex
+	stats   ServerStats
+}
+
+// ServerStats is a snapshot of WebNowPlaying connection and protocol counters
+type ServerStats struct {
+	Port             int    `json:"port"`
+	Connected        bool   `json:"connected"`
+	Connections      uint64 `json:"connections"`
+	Disconnections   uint64 `json:"disconnections"`
+	MessagesReceived uint64 `json:"messagesReceived"`
+	BinaryMessages   uint64 `json:"binaryMessages"`
+	ParseErrors      uint64 `json:"parseErrors"`
+	CommandsSent     uint64 `json:"commandsSent"`
+	CommandErrors    uint64 `json:"commandErrors"`
+	PlayerCount      int    `json:"playerCount"`
+	ActivePlayerID   int    `json:"activePlayerId"` // filled in from the PlayerHub
+	LastError        string `json:"lastError"`
+	LastErrorAt      int64  `json:"lastErrorAt"`   // unix ms
+	LastMessageAt    int64  `json:"lastMessageAt"` // unix ms
+	ConnectedAt      int64  `json:"connectedAt"`   // unix ms
+}
 
+const (
+	// DefaultPlayerTTL is how long a player may go without updates before it expires
+	DefaultPlayerTTL = 10 * time.Minute
+	// ghostPlayerGrace is how long a player may stay without a title before it is dropped
+	ghostPlayerGrace = 30 * time.Second
+	// playerSweepInterval is how often stale players and sticky handovers are checked
+	playerSweepInterval = time.Second
+)
+
+// NewWebNowPlayingServer creates and starts a new WebNowPlaying server. Covers
+// sent as images are stored in covers.
+func NewWebNowPlayingServer(port int, covers *CoverCache) (*WebNowPlayingServer, error) {
 	s := &WebNowPlayingServer{
-		port:     port,
-		players:  make(map[int]*Player),
-		onUpdate: onUpdate,
-		stopCh:   make(chan struct{}),
-		coverDir: coverDir,
+		port:        port,
+		players:     make(map[int]*Player),
+		playerConns: make(map[int]*websocket.Conn),
+		expired:     make(map[int]*Player),
+		playerTTL:   DefaultPlayerTTL,
+		stopCh:      make(chan struct{}),
+		covers:      covers,
+		stats:       ServerStats{Port: port},
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true // Allow all origins for WebNowPlaying
@@ -76,15 +137,44 @@ func NewWebNowPlayingServer(port int, onUpdate PlayerUpdateCallback) (*WebNowPla
 		log.Printf("WebNowPlaying server starting on port %d", port)
 		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Printf("WebNowPlaying server error: %v", err)
+			s.recordError(err)
 		}
 	}()
 
+	go s.sweepLoop()
+
 	// Give server time to start
 	time.Sleep(100 * time.Millisecond)
 
 	return s, nil
 }
 
+// SetPlayerTTL sets how long a player may go without updates before it expires (0 = never)
+func (s *WebNowPlayingServer) SetPlayerTTL(ttl time.Duration) {
+	s.playersMu.Lock()
+	s.playerTTL = ttl
+	s.playersMu.Unlock()
+}
+
+// Name identifies the server as a player source
+func (s *WebNowPlayingServer) Name() string {
+	return SourceWebNowPlaying
+}
+
+// SetChangeCallback sets the callback invoked after players are added, updated or removed
+func (s *WebNowPlayingServer) SetChangeCallback(onChange SourceChangeCallback) {
+	s.playersMu.Lock()
+	s.onChange = onChange
+	s.playersMu.Unlock()
+}
+
+// SetEventResultCallback sets the callback invoked with command results
+func (s *WebNowPlayingServer) SetEventResultCallback(onEventResult EventResultCallback) {
+	s.playersMu.Lock()
+	s.onEventResult = onEventResult
+	s.playersMu.Unlock()
+}
+
 // Stop stops the WebNowPlaying server
 func (s *WebNowPlayingServer) Stop() {
 	close(s.stopCh)
@@ -94,6 +184,15 @@ func (s *WebNowPlayingServer) Stop() {
 	if s.server != nil {
 		s.server.Close()
 	}
+
+	s.playersMu.RLock()
+	for id := range s.players {
+		s.covers.Release(wnpCoverOwner(id))
+	}
+	for id := range s.expired {
+		s.covers.Release(wnpCoverOwner(id))
+	}
+	s.playersMu.RUnlock()
 	log.Println("WebNowPlaying server stopped")
 }
 
@@ -102,6 +201,7 @@ func (s *WebNowPlayingServer) handleConnection(w http.ResponseWriter, r *http.Re
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
+		s.recordError(err)
 		return
 	}
 
@@ -112,6 +212,12 @@ func (s *WebNowPlayingServer) handleConnection(w http.ResponseWriter, r *http.Re
 	s.conn = conn
 	s.connMu.Unlock()
 
+	s.statsMu.Lock()
+	s.stats.Connections++
+	s.stats.Connected = true
+	s.stats.ConnectedAt = time.Now().UnixMilli()
+	s.statsMu.Unlock()
+
 	log.Println("WebNowPlaying client connected")
 
 	// Send version info
@@ -122,28 +228,48 @@ func (s *WebNowPlayingServer) handleConnection(w http.ResponseWriter, r *http.Re
 		messageType, data, err := conn.ReadMessage()
 		if err != nil {
 			log.Printf("WebSocket read error: %v", err)
+			s.recordError(err)
 			break
 		}
 
+		s.statsMu.Lock()
+		s.stats.MessagesReceived++
+		if messageType == websocket.BinaryMessage {
+			s.stats.BinaryMessages++
+		}
+		s.stats.LastMessageAt = time.Now().UnixMilli()
+		s.statsMu.Unlock()
+
 		switch messageType {
 		case websocket.TextMessage:
-			s.handleTextMessage(string(data))
+			s.handleTextMessage(conn, string(data))
 		case websocket.BinaryMessage:
 			s.handleBinaryMessage(data)
 		}
 	}
 
 	s.connMu.Lock()
-	if s.conn == conn {
+	current := s.conn == conn
+	if current {
 		s.conn = nil
 	}
 	s.connMu.Unlock()
 
+	s.statsMu.Lock()
+	s.stats.Disconnections++
+	if current {
+		s.stats.Connected = false
+	}
+	s.statsMu.Unlock()
+
 	log.Println("WebNowPlaying client disconnected")
+
+	// Players of a dropped connection will never receive PLAYER_REMOVED
+	s.removeConnPlayers(conn)
 }
 
 // handleTextMessage processes text messages from WebNowPlaying
-func (s *WebNowPlayingServer) handleTextMessage(msg string) {
+func (s *WebNowPlayingServer) handleTextMessage(conn *websocket.Conn, msg string) {
 	// Log everything for debug
 	if len(msg) > 100 {
 		log.Printf("Incoming WNP message (len=%d): %s...", len(msg), msg[:100])
@@ -153,12 +279,14 @@ func (s *WebNowPlayingServer) handleTextMessage(msg string) {
 
 	parts := strings.SplitN(msg, " ", 3)
 	if len(parts) < 2 {
+		s.recordParseError(fmt.Errorf("malformed message: %q", msg))
 		return
 	}
 
 	msgType, err := strconv.Atoi(parts[0])
 	if err != nil {
 		log.Printf("Invalid message type: %s", parts[0])
+		s.recordParseError(fmt.Errorf("invalid message type: %s", parts[0]))
 		return
 	}
 
@@ -172,17 +300,18 @@ func (s *WebNowPlayingServer) handleTextMessage(msg string) {
 	playerID, err := strconv.Atoi(parts[1])
 	if err != nil {
 		log.Printf("Invalid player ID: %s", parts[1])
+		s.recordParseError(fmt.Errorf("invalid player ID: %s", parts[1]))
 		return
 	}
 
 	switch MessageType(msgType) {
 	case MessagePlayerAdded:
 		if len(parts) >= 3 {
-			s.handlePlayerAdded(playerID, parts[2])
+			s.handlePlayerAdded(conn, playerID, parts[2])
 		}
 	case MessagePlayerUpdated:
 		if len(parts) >= 3 {
-			s.handlePlayerUpdated(playerID, parts[2])
+			s.handlePlayerUpdated(conn, playerID, parts[2])
 		}
 	case MessagePlayerRemoved:
 		s.handlePlayerRemoved(playerID)
@@ -192,6 +321,7 @@ func (s *WebNowPlayingServer) handleTextMessage(msg string) {
 // handleBinaryMessage processes binary messages (cover art)
 func (s *WebNowPlayingServer) handleBinaryMessage(data []byte) {
 	if len(data) < 4 {
+		s.recordParseError(fmt.Errorf("binary message too short (%d bytes)", len(data)))
 		return
 	}
 
@@ -199,27 +329,51 @@ func (s *WebNowPlayingServer) handleBinaryMessage(data []byte) {
 	playerID := int(binary.LittleEndian.Uint32(data[:4]))
 	coverData := data[4:]
 
-	// Save cover to file
-	coverPath := filepath.Join(s.coverDir, fmt.Sprintf("%d.png", playerID))
-	if err := os.WriteFile(coverPath, coverData, 0644); err != nil {
+	// Save cover to the cache; the same image from another tab reuses the file
+	coverKey, err := s.covers.Put(wnpCoverOwner(playerID), coverData)
+	if errors.Is(err, ErrUnknownCoverFormat) {
+		s.recordParseError(fmt.Errorf("cover for player %d: %w", playerID, err))
+		// Drop the previous track's cover rather than showing it for this one
+		s.covers.Release(wnpCoverOwner(playerID))
+		s.setCover(playerID, "")
+		return
+	}
+	if err != nil {
 		log.Printf("Failed to save cover: %v", err)
+		s.recordError(err)
 		return
 	}
 
-	// Update player with cover path
-	s.playersMu.Lock()
-	if player, ok := s.players[playerID]; ok {
-		player.Cover = coverPath
-		player.CoverData = coverData
-		s.playersMu.Unlock()
-		s.notifyUpdate(player)
-	} else {
-		s.playersMu.Unlock()
+	if !s.setCover(playerID, coverKey) {
+		s.covers.Release(wnpCoverOwner(playerID))
+		return
 	}
 
 	log.Printf("Received cover for player %d (%d bytes)", playerID, len(coverData))
 }
 
+// setCover changes the cover of a player and reports it. It returns false when
+// the player is gone.
+func (s *WebNowPlayingServer) setCover(playerID int, cover string) bool {
+	s.playersMu.Lock()
+	player, ok := s.players[playerID]
+	changed := ok && player.Cover != cover
+	if changed {
+		player.Cover = cover
+	}
+	s.playersMu.Unlock()
+
+	if changed {
+		s.notifyChange([]int{playerID})
+	}
+	return ok
+}
+
+// wnpCoverOwner identifies a player's cover in the cover cache
+func wnpCoverOwner(playerID int) string {
+	return fmt.Sprintf("wnp:%d", playerID)
+}
+
 // parsePlayerData parses pipe-separated player data
 func parsePlayerData(data string) map[string]string {
 	fields := []string{
@@ -336,118 +490,179 @@ func applyPlayerData(player *Player, data map[string]string) {
 	}
 }
 
-// recalculateActivePlayer recalculates the active player based on priority algorithm from libwnp.
-// Priority order:
-// 1. Playing player with volume > 0 and highest activeAt
-// 2. Any playing player (fallback)
-// 3. Non-playing player with highest activeAt (if no playing players)
-// This prevents "flickering" when multiple players are active.
-func (s *WebNowPlayingServer) recalculateActivePlayer() (changed bool, activePlayer *Player) {
-	s.playersMu.Lock()
-	defer s.playersMu.Unlock()
-
-	var newActiveID int = 0
-	var maxActiveAt int64 = 0
-	foundPlaying := false
-
-	for id, player := range s.players {
-		// Skip ghost players with empty title
-		if player.Title == "" {
-			continue
-		}
-
-		// Priority 1: Playing player with volume > 0 and highest activeAt
-		if player.State == StatePlaying && player.Volume > 0 && player.ActiveAt > maxActiveAt {
-			newActiveID = id
-			maxActiveAt = player.ActiveAt
-			foundPlaying = true
-		} else if player.State == StatePlaying && !foundPlaying {
-			// Priority 2: Any playing player (fallback)
-			newActiveID = id
-			maxActiveAt = player.ActiveAt
-		} else if player.State != StatePlaying && player.ActiveAt > maxActiveAt && !foundPlaying {
-			// Priority 3: Non-playing player with highest activeAt (if no playing players)
-			currentActive := s.players[s.activePlayerID]
-			if currentActive == nil || currentActive.State != StatePlaying {
-				newActiveID = id
-				maxActiveAt = player.ActiveAt
-			}
+// updatePositionTiming re-anchors position extrapolation after an update.
+// estimated is the extrapolated position just before the update was applied.
+func updatePositionTiming(player *Player, data map[string]string, estimated float64, now time.Time) {
+	if v, ok := data["position"]; ok && v != "" {
+		reported := float64(player.Position)
+		// Positions are whole seconds: while playing, an estimate inside the reported
+		// second is more precise than the report itself, so keep it to avoid stepping back
+		if player.State == StatePlaying && estimated >= reported && estimated < reported+1 {
+			player.RebasePosition(estimated, now)
+		} else {
+			player.RebasePosition(reported, now)
 		}
+		return
 	}
 
-	// Check if active player changed
-	changed = newActiveID != s.activePlayerID
-	if changed {
-		s.activePlayerID = newActiveID
-		log.Printf("Active player changed to: %d", newActiveID)
+	// State changed without a position (pause/resume): freeze or restart from the estimate
+	if v, ok := data["state"]; ok && v != "" {
+		player.RebasePosition(estimated, now)
 	}
-
-	activePlayer = s.players[s.activePlayerID]
-	return changed, activePlayer
 }
 
 // handlePlayerAdded handles new player connection
-func (s *WebNowPlayingServer) handlePlayerAdded(playerID int, data string) {
+func (s *WebNowPlayingServer) handlePlayerAdded(conn *websocket.Conn, playerID int, data string) {
 	parsed := parsePlayerData(data)
 
+	now := time.Now()
 	player := &Player{
-		ID:        playerID,
-		CreatedAt: time.Now().UnixMilli(),
-		UpdatedAt: time.Now().UnixMilli(),
+		ID:           playerID,
+		Source:       SourceWebNowPlaying,
+		CreatedAt:    now.UnixMilli(),
+		UpdatedAt:    now.UnixMilli(),
+		PlaybackRate: 1,
 	}
 	applyPlayerData(player, parsed)
+	markPlaying(player, false, now)
+	player.RebasePosition(float64(player.Position), now)
+	s.covers.Release(wnpCoverOwner(playerID))
 
 	s.playersMu.Lock()
 	s.players[playerID] = player
+	s.playerConns[playerID] = conn
+	delete(s.expired, playerID)
 	s.playersMu.Unlock()
 
 	log.Printf("Player added: %d (%s) - %s", playerID, player.Name, player.Title)
-
-	// Recalculate active player and notify
-	_, activePlayer := s.recalculateActivePlayer()
-	s.notifyUpdate(activePlayer)
+	s.notifyChange([]int{playerID})
 }
 
 // handlePlayerUpdated handles player state update (partial data)
-func (s *WebNowPlayingServer) handlePlayerUpdated(playerID int, data string) {
+func (s *WebNowPlayingServer) handlePlayerUpdated(conn *websocket.Conn, playerID int, data string) {
 	parsed := parsePlayerData(data)
 
+	now := time.Now()
+
 	s.playersMu.Lock()
 	player, ok := s.players[playerID]
 	if !ok {
-		// Create new player if doesn't exist
-		player = &Player{
-			ID:        playerID,
-			CreatedAt: time.Now().UnixMilli(),
+		// A player dropped by the TTL continues where it was
+		player, ok = s.expired[playerID]
+		delete(s.expired, playerID)
+		if !ok {
+			// Create new player if doesn't exist
+			player = &Player{
+				ID:           playerID,
+				Source:       SourceWebNowPlaying,
+				CreatedAt:    now.UnixMilli(),
+				PlaybackRate: 1,
+			}
 		}
 		s.players[playerID] = player
 	}
-	player.UpdatedAt = time.Now().UnixMilli()
+	s.playerConns[playerID] = conn
+	player.UpdatedAt = now.UnixMilli()
+	estimated := player.EstimatedPositionAt(now)
+	wasPlaying := player.State == StatePlaying && player.playingSince != 0
 	applyPlayerData(player, parsed)
+	markPlaying(player, wasPlaying, now)
+	updatePositionTiming(player, parsed, estimated, now)
 	s.playersMu.Unlock()
 
-	// Recalculate active player with priority algorithm
-	changed, activePlayer := s.recalculateActivePlayer()
-
-	// Notify frontend ONLY if:
-	// 1. Active player changed, OR
-	// 2. This update is for the current active player
-	if changed || s.activePlayerID == playerID {
-		s.notifyUpdate(activePlayer)
+	// A cover URL replaces the cached image
+	if parsed["cover"] != "" {
+		s.covers.Release(wnpCoverOwner(playerID))
 	}
+
+	s.notifyChange([]int{playerID})
 }
 
 // handlePlayerRemoved handles player disconnection
 func (s *WebNowPlayingServer) handlePlayerRemoved(playerID int) {
+	s.removePlayers([]int{playerID}, "removed")
+}
+
+// removeConnPlayers drops every player owned by a closed connection
+func (s *WebNowPlayingServer) removeConnPlayers(conn *websocket.Conn) {
+	s.playersMu.RLock()
+	var ids []int
+	for id, owner := range s.playerConns {
+		if owner == conn {
+			ids = append(ids, id)
+		}
+	}
+	s.playersMu.RUnlock()
+
+	if len(ids) > 0 {
+		s.removePlayers(ids, "connection closed")
+	}
+}
+
+// sweepLoop periodically expires stale and ghost players until the server stops
+func (s *WebNowPlayingServer) sweepLoop() {
+	ticker := time.NewTicker(playerSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case now := <-ticker.C:
+			s.expirePlayers(now)
+		}
+	}
+}
+
+// expirePlayers removes players without updates for the TTL and untitled ghosts
+// past their grace period. Expired players are kept aside with their cover: a
+// paused tab sends nothing for a long time, and when it resumes its updates only
+// carry what changed, so they are applied to the player as it was.
+func (s *WebNowPlayingServer) expirePlayers(now time.Time) {
+	nowMs := now.UnixMilli()
+
+	s.playersMu.RLock()
+	var stale, ghosts []int
+	for id, player := range s.players {
+		if s.playerTTL > 0 && nowMs-player.UpdatedAt > s.playerTTL.Milliseconds() {
+			stale = append(stale, id)
+		} else if player.Title == "" && nowMs-player.CreatedAt > ghostPlayerGrace.Milliseconds() {
+			ghosts = append(ghosts, id)
+		}
+	}
+	s.playersMu.RUnlock()
+
+	if len(stale) > 0 {
+		s.playersMu.Lock()
+		for _, id := range stale {
+			if player, ok := s.players[id]; ok {
+				s.expired[id] = player
+				delete(s.players, id)
+			}
+		}
+		s.playersMu.Unlock()
+
+		log.Printf("Players expired: %v", stale)
+		s.notifyChange(stale)
+	}
+	if len(ghosts) > 0 {
+		s.removePlayers(ghosts, "ghost")
+	}
+}
+
+// removePlayers deletes players and reports them as changed
+func (s *WebNowPlayingServer) removePlayers(ids []int, reason string) {
 	s.playersMu.Lock()
-	delete(s.players, playerID)
+	for _, id := range ids {
+		delete(s.players, id)
+		delete(s.playerConns, id)
+		delete(s.expired, id)
+		s.covers.Release(wnpCoverOwner(id))
+	}
 	s.playersMu.Unlock()
 
-	log.Printf("Player removed: %d", playerID)
-
-	// Recalculate active player and notify
-	_, activePlayer := s.recalculateActivePlayer()
-	s.notifyUpdate(activePlayer)
+	log.Printf("Players %s: %v", reason, ids)
+	s.notifyChange(ids)
 }
 
 // handleEventResult handles command execution results from WebNowPlaying
@@ -459,6 +674,7 @@ func (s *WebNowPlayingServer) handleEventResult(parts []string) {
 
 	if len(parts) < 3 {
 		log.Printf("Event result: incomplete message (len=%d)", len(parts))
+		s.recordParseError(fmt.Errorf("incomplete event result"))
 		return
 	}
 
@@ -469,34 +685,51 @@ func (s *WebNowPlayingServer) handleEventResult(parts []string) {
 	// 0 = Success
 	// 1 = Not Supported
 	// 2 = Timeout/Unable to execute
-	statusText := "Unknown"
-	switch statusCode {
-	case "0":
-		statusText = "Success"
-	case "1":
-		statusText = "Not Supported"
-	case "2":
-		statusText = "Timeout/Unable to execute"
+	code, err := strconv.Atoi(statusCode)
+	if err != nil {
+		s.recordParseError(fmt.Errorf("invalid event status: %s", statusCode))
+		return
 	}
+	status := EventStatus(code)
+
+	log.Printf("Event result: eventId=%s, status=%s (%s)", eventID, status, statusCode)
 
-	log.Printf("Event result: eventId=%s, status=%s (%s)", eventID, statusText, statusCode)
+	s.playersMu.RLock()
+	onEventResult := s.onEventResult
+	s.playersMu.RUnlock()
+	if onEventResult != nil {
+		onEventResult(eventID, status)
+	}
 }
 
-// notifyUpdate calls the update callback with current active player
-func (s *WebNowPlayingServer) notifyUpdate(player *Player) {
-	if s.onUpdate != nil && player != nil {
-		s.onUpdate(player.Clone())
+// notifyChange calls the change callback with the touched player IDs
+func (s *WebNowPlayingServer) notifyChange(ids []int) {
+	s.playersMu.RLock()
+	onChange := s.onChange
+	s.playersMu.RUnlock()
+
+	if onChange != nil {
+		onChange(ids)
 	}
 }
 
 // SendCommand sends a control command to WebNowPlaying
 func (s *WebNowPlayingServer) SendCommand(playerID int, command string, data interface{}) error {
+	_, err := s.SendEvent(playerID, command, data)
+	return err
+}
+
+// SendEvent sends a control command and returns the event ID its EVENT_RESULT will carry
+func (s *WebNowPlayingServer) SendEvent(playerID int, command string, data interface{}) (string, error) {
 	s.connMu.Lock()
 	conn := s.conn
 	s.connMu.Unlock()
 
 	if conn == nil {
-		return fmt.Errorf("not connected")
+		s.statsMu.Lock()
+		s.stats.CommandErrors++
+		s.statsMu.Unlock()
+		return "", fmt.Errorf("not connected")
 	}
 
 	// Generate unique event ID
@@ -557,7 +790,10 @@ func (s *WebNowPlayingServer) SendCommand(playerID int, command string, data int
 		eventData = fmt.Sprintf("%v", data)
 
 	default:
-		return fmt.Errorf("unknown command: %s", command)
+		s.statsMu.Lock()
+		s.stats.CommandErrors++
+		s.statsMu.Unlock()
+		return "", fmt.Errorf("unknown command: %s", command)
 	}
 
 	// Format: <playerId> <eventId> <eventType> [data]
@@ -569,16 +805,74 @@ func (s *WebNowPlayingServer) SendCommand(playerID int, command string, data int
 	}
 
 	log.Printf("Sending command (Rev3): %s", msg)
-	return conn.WriteMessage(websocket.TextMessage, []byte(msg))
+	err := conn.WriteMessage(websocket.TextMessage, []byte(msg))
+
+	s.statsMu.Lock()
+	s.stats.CommandsSent++
+	s.statsMu.Unlock()
+	if err != nil {
+		s.statsMu.Lock()
+		s.stats.CommandErrors++
+		s.statsMu.Unlock()
+		s.recordError(err)
+		return "", err
+	}
+	return eventID, nil
 }
 
-// GetActivePlayer returns the current active player
-func (s *WebNowPlayingServer) GetActivePlayer() *Player {
+// GetPlayer returns a snapshot of the player with the given ID, or nil
+func (s *WebNowPlayingServer) GetPlayer(playerID int) *Player {
 	s.playersMu.RLock()
 	defer s.playersMu.RUnlock()
 
-	if player, ok := s.players[s.activePlayerID]; ok {
-		return player.Clone()
+	if player, ok := s.players[playerID]; ok {
+		return player.Snapshot(time.Now())
 	}
 	return nil
 }
+
+// GetPlayers returns snapshots of all known players ordered by ID
+func (s *WebNowPlayingServer) GetPlayers() []*Player {
+	s.playersMu.RLock()
+	defer s.playersMu.RUnlock()
+
+	now := time.Now()
+	players := make([]*Player, 0, len(s.players))
+	for _, player := range s.players {
+		players = append(players, player.Snapshot(now))
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].ID < players[j].ID
+	})
+	return players
+}
+
+// Stats returns a snapshot of the server counters
+func (s *WebNowPlayingServer) Stats() ServerStats {
+	s.playersMu.RLock()
+	playerCount := len(s.players)
+	s.playersMu.RUnlock()
+
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	stats := s.stats
+	stats.PlayerCount = playerCount
+	return stats
+}
+
+// recordError remembers the last server error for diagnostics
+func (s *WebNowPlayingServer) recordError(err error) {
+	s.statsMu.Lock()
+	s.stats.LastError = err.Error()
+	s.stats.LastErrorAt = time.Now().UnixMilli()
+	s.statsMu.Unlock()
+}
+
+// recordParseError counts a malformed message from the extension
+func (s *WebNowPlayingServer) recordParseError(err error) {
+	s.statsMu.Lock()
+	s.stats.ParseErrors++
+	s.statsMu.Unlock()
+	s.recordError(err)
+}
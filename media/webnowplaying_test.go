@@ -0,0 +1,76 @@
+package media
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestWebNowPlaying(t *testing.T) *WebNowPlayingServer {
+	s, err := NewWebNowPlayingServer(0, NewCoverCache(t.TempDir(), DefaultCoverCacheSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func TestWebNowPlayingRestoresExpiredPlayer(t *testing.T) {
+	s := newTestWebNowPlaying(t)
+
+	// A paused tab with its cover
+	s.handleTextMessage(nil, "0 7 7|YouTube Music|Song|Artist|Album||1|60|200|50")
+	s.handleBinaryMessage(append(binary.LittleEndian.AppendUint32(nil, 7), testJPEG(3, 16)...))
+	player := s.GetPlayer(7)
+	if player == nil || player.Cover == "" {
+		t.Fatalf("player = %+v", player)
+	}
+	cover := player.Cover
+
+	// Ten minutes without updates
+	s.expirePlayers(time.Now().Add(DefaultPlayerTTL + time.Second))
+	if s.GetPlayer(7) != nil {
+		t.Fatal("paused player not expired")
+	}
+
+	// Resuming only sends the state
+	s.handleTextMessage(nil, "1 7 ||||||0")
+	player = s.GetPlayer(7)
+	if player == nil {
+		t.Fatal("resumed player not restored")
+	}
+	if player.Title != "Song" || player.Artist != "Artist" || player.Name != "YouTube Music" || player.Duration != 200 {
+		t.Errorf("restored %q by %q in %q, duration %d", player.Title, player.Artist, player.Name, player.Duration)
+	}
+	if player.State != StatePlaying || player.Cover != cover {
+		t.Errorf("state %d, cover %q (was %q)", player.State, player.Cover, cover)
+	}
+	if _, err := os.Stat(s.covers.Path(cover)); err != nil {
+		t.Errorf("cover file: %v", err)
+	}
+
+	// It is no ghost
+	s.expirePlayers(time.Now().Add(ghostPlayerGrace + time.Second))
+	if s.GetPlayer(7) == nil {
+		t.Error("restored player dropped as a ghost")
+	}
+}
+
+func TestWebNowPlayingForgetsRemovedPlayer(t *testing.T) {
+	s := newTestWebNowPlaying(t)
+
+	s.handleTextMessage(nil, "0 7 7|YouTube Music|Song|Artist|Album||1|60|200|50")
+	s.expirePlayers(time.Now().Add(DefaultPlayerTTL + time.Second))
+	s.handleTextMessage(nil, "2 7")
+
+	// A removed player starts over: the update alone has no title
+	s.handleTextMessage(nil, "1 7 ||||||0")
+	if player := s.GetPlayer(7); player == nil || player.Title != "" {
+		t.Fatalf("player = %+v", player)
+	}
+	s.expirePlayers(time.Now().Add(ghostPlayerGrace + time.Second))
+	if s.GetPlayer(7) != nil {
+		t.Error("untitled player kept past the ghost grace")
+	}
+}